@@ -0,0 +1,161 @@
+package bloomfilter
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/pkg/errors"
+	"github.com/sabey/parquet-go/parquet"
+)
+
+const (
+	//Size of a block in bytes
+	BlockBytes = 32
+	//Minimum size of a filter in bytes
+	MinBytes = BlockBytes
+	//Maximum size of a filter in bytes
+	MaxBytes = 128 * 1024 * 1024
+	//Default false positive probability
+	DefaultFPP = 0.01
+)
+
+//salt of the split block Bloom filter in the parquet format
+var salt = [8]uint32{
+	0x47b6137b, 0x44974d91, 0x8824ad5b, 0xa2b7289d,
+	0x705495c7, 0x2df1424b, 0x9efc4947, 0x5c6bfb31,
+}
+
+type block [8]uint32
+
+//Filter is a split block Bloom filter with the XXHASH hash
+type Filter struct {
+	Blocks []block
+}
+
+//Get the number of bytes of a filter for numDistinct values with the false positive probability fpp.
+//It's a power of 2 between MinBytes and MaxBytes.
+func OptimalNumBytes(numDistinct int64, fpp float64) int32 {
+	if fpp <= 0 || fpp >= 1 {
+		fpp = DefaultFPP
+	}
+	numBits := -8 * float64(numDistinct) / math.Log(1-math.Pow(fpp, 1.0/8))
+	numBytes := int32(MinBytes)
+	for float64(numBytes)*8 < numBits && numBytes < MaxBytes {
+		numBytes <<= 1
+	}
+	return numBytes
+}
+
+//Create a empty filter, numBytes is rounded up to a multiple of BlockBytes
+func New(numBytes int32) *Filter {
+	if numBytes < MinBytes {
+		numBytes = MinBytes
+	}
+	if numBytes > MaxBytes {
+		numBytes = MaxBytes
+	}
+	return &Filter{
+		Blocks: make([]block, (numBytes+BlockBytes-1)/BlockBytes),
+	}
+}
+
+//Create a filter from its bitset
+func NewFromBytes(buf []byte) (*Filter, error) {
+	if len(buf) < MinBytes || len(buf) > MaxBytes || len(buf)%BlockBytes != 0 {
+		return nil, errors.Errorf("invalid bloom filter size: %v", len(buf))
+	}
+	f := &Filter{
+		Blocks: make([]block, len(buf)/BlockBytes),
+	}
+	for i := range f.Blocks {
+		for j := 0; j < 8; j++ {
+			f.Blocks[i][j] = binary.LittleEndian.Uint32(buf[i*BlockBytes+j*4:])
+		}
+	}
+	return f, nil
+}
+
+//Get the bitset of the filter
+func (f *Filter) Bytes() []byte {
+	buf := make([]byte, len(f.Blocks)*BlockBytes)
+	for i := range f.Blocks {
+		for j := 0; j < 8; j++ {
+			binary.LittleEndian.PutUint32(buf[i*BlockBytes+j*4:], f.Blocks[i][j])
+		}
+	}
+	return buf
+}
+
+//Get the header of the filter, which is written before the bitset
+func (f *Filter) Header() *parquet.BloomFilterHeader {
+	header := parquet.NewBloomFilterHeader()
+	header.NumBytes = int32(len(f.Blocks) * BlockBytes)
+	header.Algorithm = parquet.NewBloomFilterAlgorithm()
+	header.Algorithm.BLOCK = parquet.NewSplitBlockAlgorithm()
+	header.Hash = parquet.NewBloomFilterHash()
+	header.Hash.XXHASH = parquet.NewXxHash()
+	header.Compression = parquet.NewBloomFilterCompression()
+	header.Compression.UNCOMPRESSED = parquet.NewUncompressed()
+	return header
+}
+
+func mask(key uint32) block {
+	var res block
+	for i := 0; i < 8; i++ {
+		res[i] = 1 << ((key * salt[i]) >> 27)
+	}
+	return res
+}
+
+func (f *Filter) blockIndex(hash uint64) uint64 {
+	return ((hash >> 32) * uint64(len(f.Blocks))) >> 32
+}
+
+//Insert a hash to the filter
+func (f *Filter) Insert(hash uint64) {
+	b := &f.Blocks[f.blockIndex(hash)]
+	m := mask(uint32(hash))
+	for i := 0; i < 8; i++ {
+		b[i] |= m[i]
+	}
+}
+
+//Check whether the hash may be in the filter. False positives are possible, false negatives are not.
+func (f *Filter) Check(hash uint64) bool {
+	b := &f.Blocks[f.blockIndex(hash)]
+	m := mask(uint32(hash))
+	for i := 0; i < 8; i++ {
+		if b[i]&m[i] == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+//Get the hash of a value, which is the XXH64 of its plain encoding.
+//The go types of the values are the ones used for the parquet types in tables.
+func Hash(value interface{}) (uint64, error) {
+	var buf [8]byte
+	switch v := value.(type) {
+	case bool:
+		if v {
+			buf[0] = 1
+		}
+		return XXH64(buf[:1]), nil
+	case int32:
+		binary.LittleEndian.PutUint32(buf[:], uint32(v))
+		return XXH64(buf[:4]), nil
+	case int64:
+		binary.LittleEndian.PutUint64(buf[:], uint64(v))
+		return XXH64(buf[:8]), nil
+	case float32:
+		binary.LittleEndian.PutUint32(buf[:], math.Float32bits(v))
+		return XXH64(buf[:4]), nil
+	case float64:
+		binary.LittleEndian.PutUint64(buf[:], math.Float64bits(v))
+		return XXH64(buf[:8]), nil
+	case string:
+		return XXH64([]byte(v)), nil
+	}
+	return 0, errors.Errorf("unsupported bloom filter value type: %T", value)
+}
@@ -0,0 +1,60 @@
+package bloomfilter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestXXH64(t *testing.T) {
+	testData := []struct {
+		Input    string
+		Expected uint64
+	}{
+		{"", 0xef46db3751d8e999},
+		{"a", 0xd24ec4f1a98c6e5b},
+		{"abc", 0x44bc2cf5ad770999},
+		{"Nobody inspects the spammish repetition", 0xfbcea83c8a378bf1},
+	}
+
+	for _, data := range testData {
+		assert.Equal(t, data.Expected, XXH64([]byte(data.Input)), data.Input)
+	}
+}
+
+func TestOptimalNumBytes(t *testing.T) {
+	assert.Equal(t, int32(MinBytes), OptimalNumBytes(0, DefaultFPP))
+	assert.Equal(t, int32(MaxBytes), OptimalNumBytes(1<<40, DefaultFPP))
+	numBytes := OptimalNumBytes(1000000, DefaultFPP)
+	assert.Equal(t, int32(0), numBytes&(numBytes-1))
+	assert.True(t, numBytes >= 1000000)
+}
+
+func TestFilter(t *testing.T) {
+	f := New(OptimalNumBytes(1000, DefaultFPP))
+	for i := int64(0); i < 1000; i++ {
+		hash, err := Hash(i)
+		assert.Nil(t, err)
+		f.Insert(hash)
+	}
+
+	g, err := NewFromBytes(f.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, f, g)
+
+	falsePositives := 0
+	for i := int64(0); i < 10000; i++ {
+		hash, _ := Hash(i)
+		if i < 1000 {
+			assert.True(t, g.Check(hash))
+		} else if g.Check(hash) {
+			falsePositives++
+		}
+	}
+	assert.True(t, falsePositives < 9000/20, "false positives %v", falsePositives)
+
+	_, err = Hash([]int{1})
+	assert.NotNil(t, err)
+	_, err = NewFromBytes(make([]byte, 33))
+	assert.NotNil(t, err)
+}
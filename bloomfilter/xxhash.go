@@ -0,0 +1,75 @@
+package bloomfilter
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+const (
+	prime64_1 uint64 = 11400714785074694791
+	prime64_2 uint64 = 14029467366897019727
+	prime64_3 uint64 = 1609587929392839161
+	prime64_4 uint64 = 9650029242287828579
+	prime64_5 uint64 = 2870177450012600261
+)
+
+func xxh64Round(acc uint64, input uint64) uint64 {
+	acc += input * prime64_2
+	acc = bits.RotateLeft64(acc, 31)
+	return acc * prime64_1
+}
+
+func xxh64MergeRound(acc uint64, val uint64) uint64 {
+	acc ^= xxh64Round(0, val)
+	return acc*prime64_1 + prime64_4
+}
+
+//XXH64 returns the 64-bit xxHash of buf with seed 0, which is the hash used by parquet Bloom filters
+func XXH64(buf []byte) uint64 {
+	ln := len(buf)
+	var h uint64
+
+	if ln >= 32 {
+		v1 := uint64(6983438078262162902) //prime64_1 + prime64_2
+		v2 := prime64_2
+		v3 := uint64(0)
+		v4 := uint64(7046029288634856825) //0 - prime64_1
+		for len(buf) >= 32 {
+			v1 = xxh64Round(v1, binary.LittleEndian.Uint64(buf[0:8]))
+			v2 = xxh64Round(v2, binary.LittleEndian.Uint64(buf[8:16]))
+			v3 = xxh64Round(v3, binary.LittleEndian.Uint64(buf[16:24]))
+			v4 = xxh64Round(v4, binary.LittleEndian.Uint64(buf[24:32]))
+			buf = buf[32:]
+		}
+		h = bits.RotateLeft64(v1, 1) + bits.RotateLeft64(v2, 7) + bits.RotateLeft64(v3, 12) + bits.RotateLeft64(v4, 18)
+		h = xxh64MergeRound(h, v1)
+		h = xxh64MergeRound(h, v2)
+		h = xxh64MergeRound(h, v3)
+		h = xxh64MergeRound(h, v4)
+	} else {
+		h = prime64_5
+	}
+
+	h += uint64(ln)
+	for len(buf) >= 8 {
+		h ^= xxh64Round(0, binary.LittleEndian.Uint64(buf[:8]))
+		h = bits.RotateLeft64(h, 27)*prime64_1 + prime64_4
+		buf = buf[8:]
+	}
+	if len(buf) >= 4 {
+		h ^= uint64(binary.LittleEndian.Uint32(buf[:4])) * prime64_1
+		h = bits.RotateLeft64(h, 23)*prime64_2 + prime64_3
+		buf = buf[4:]
+	}
+	for _, b := range buf {
+		h ^= uint64(b) * prime64_5
+		h = bits.RotateLeft64(h, 11) * prime64_1
+	}
+
+	h ^= h >> 33
+	h *= prime64_2
+	h ^= h >> 29
+	h *= prime64_3
+	h ^= h >> 32
+	return h
+}
@@ -2,8 +2,10 @@ package common
 
 import (
 	"bytes"
+	"encoding/binary"
 	"encoding/gob"
 	"fmt"
+	"math"
 	"reflect"
 	"strconv"
 	"strings"
@@ -52,10 +54,33 @@ type Tag struct {
 	KeyEncoding   parquet.Encoding
 	ValueEncoding parquet.Encoding
 
+	//The encodings are set by the tag, PLAIN is also the zero value of parquet.Encoding
+	HasEncoding      bool
+	KeyHasEncoding   bool
+	ValueHasEncoding bool
+
 	OmitStats      bool
 	KeyOmitStats   bool
 	ValueOmitStats bool
 
+	BloomFilter      bool
+	KeyBloomFilter   bool
+	ValueBloomFilter bool
+
+	DataPageVersion      int32
+	KeyDataPageVersion   int32
+	ValueDataPageVersion int32
+
+	DictSizeLimit      int64
+	KeyDictSizeLimit   int64
+	ValueDictSizeLimit int64
+
+	//Names of the compression codecs of the columns, the codec of the writer is used if they are empty.
+	//They aren't parquet.CompressionCodec since UNCOMPRESSED is its zero value.
+	Compression      string
+	KeyCompression   string
+	ValueCompression string
+
 	RepetitionType      parquet.FieldRepetitionType
 	KeyRepetitionType   parquet.FieldRepetitionType
 	ValueRepetitionType parquet.FieldRepetitionType
@@ -185,6 +210,54 @@ func StringToTag(tag string) (*Tag, error) {
 			if mp.ValueOmitStats, err = Str2Bool(val); err != nil {
 				return nil, errors.Wrap(err, "failed to parse valueomitstats")
 			}
+		case "bloomfilter":
+			if mp.BloomFilter, err = Str2Bool(val); err != nil {
+				return nil, errors.Wrap(err, "failed to parse bloomfilter")
+			}
+		case "keybloomfilter":
+			if mp.KeyBloomFilter, err = Str2Bool(val); err != nil {
+				return nil, errors.Wrap(err, "failed to parse keybloomfilter")
+			}
+		case "valuebloomfilter":
+			if mp.ValueBloomFilter, err = Str2Bool(val); err != nil {
+				return nil, errors.Wrap(err, "failed to parse valuebloomfilter")
+			}
+		case "datapageversion":
+			if mp.DataPageVersion, err = Str2DataPageVersion(val); err != nil {
+				return nil, errors.Wrap(err, "failed to parse datapageversion")
+			}
+		case "keydatapageversion":
+			if mp.KeyDataPageVersion, err = Str2DataPageVersion(val); err != nil {
+				return nil, errors.Wrap(err, "failed to parse keydatapageversion")
+			}
+		case "valuedatapageversion":
+			if mp.ValueDataPageVersion, err = Str2DataPageVersion(val); err != nil {
+				return nil, errors.Wrap(err, "failed to parse valuedatapageversion")
+			}
+		case "dictsizelimit":
+			if mp.DictSizeLimit, err = Str2Int64(val); err != nil {
+				return nil, errors.Wrap(err, "failed to parse dictsizelimit")
+			}
+		case "keydictsizelimit":
+			if mp.KeyDictSizeLimit, err = Str2Int64(val); err != nil {
+				return nil, errors.Wrap(err, "failed to parse keydictsizelimit")
+			}
+		case "valuedictsizelimit":
+			if mp.ValueDictSizeLimit, err = Str2Int64(val); err != nil {
+				return nil, errors.Wrap(err, "failed to parse valuedictsizelimit")
+			}
+		case "compression":
+			if mp.Compression, err = Str2Compression(val); err != nil {
+				return nil, errors.Wrap(err, "failed to parse compression")
+			}
+		case "keycompression":
+			if mp.KeyCompression, err = Str2Compression(val); err != nil {
+				return nil, errors.Wrap(err, "failed to parse keycompression")
+			}
+		case "valuecompression":
+			if mp.ValueCompression, err = Str2Compression(val); err != nil {
+				return nil, errors.Wrap(err, "failed to parse valuecompression")
+			}
 		case "repetitiontype":
 			switch strings.ToLower(val) {
 			case "repeated":
@@ -239,6 +312,7 @@ func StringToTag(tag string) (*Tag, error) {
 			default:
 				return nil, errors.Errorf("unknown encoding type: '%v'", val)
 			}
+			mp.HasEncoding = true
 		case "keyencoding":
 			switch strings.ToLower(val) {
 			case "rle":
@@ -249,13 +323,18 @@ func StringToTag(tag string) (*Tag, error) {
 				mp.KeyEncoding = parquet.Encoding_DELTA_LENGTH_BYTE_ARRAY
 			case "delta_byte_array":
 				mp.KeyEncoding = parquet.Encoding_DELTA_BYTE_ARRAY
+			case "plain":
+				mp.KeyEncoding = parquet.Encoding_PLAIN
 			case "plain_dictionary":
 				mp.KeyEncoding = parquet.Encoding_PLAIN_DICTIONARY
+			case "rle_dictionary":
+				mp.KeyEncoding = parquet.Encoding_RLE_DICTIONARY
 			case "byte_stream_split":
 				mp.KeyEncoding = parquet.Encoding_BYTE_STREAM_SPLIT
 			default:
 				return nil, errors.Errorf("unknown keyencoding type: '%v'", val)
 			}
+			mp.KeyHasEncoding = true
 		case "valueencoding":
 			switch strings.ToLower(val) {
 			case "rle":
@@ -266,13 +345,18 @@ func StringToTag(tag string) (*Tag, error) {
 				mp.ValueEncoding = parquet.Encoding_DELTA_LENGTH_BYTE_ARRAY
 			case "delta_byte_array":
 				mp.ValueEncoding = parquet.Encoding_DELTA_BYTE_ARRAY
+			case "plain":
+				mp.ValueEncoding = parquet.Encoding_PLAIN
 			case "plain_dictionary":
 				mp.ValueEncoding = parquet.Encoding_PLAIN_DICTIONARY
+			case "rle_dictionary":
+				mp.ValueEncoding = parquet.Encoding_RLE_DICTIONARY
 			case "byte_stream_split":
 				mp.ValueEncoding = parquet.Encoding_BYTE_STREAM_SPLIT
 			default:
 				return nil, errors.Errorf("unknown valueencoding type: '%v'", val)
 			}
+			mp.ValueHasEncoding = true
 		default:
 			if strings.HasPrefix(key, "logicaltype") {
 				mp.LogicalTypeFields[key] = val
@@ -306,6 +390,9 @@ func NewSchemaElementFromTagMap(info *Tag) (*parquet.SchemaElement, error) {
 	} else {
 		return nil, errors.Wrap(err, "parquet.TypeFromString")
 	}
+	if err := CheckEncoding(info.Encoding, *schema.Type); err != nil {
+		return nil, errors.Wrap(err, "CheckEncoding")
+	}
 
 	if ct, err := parquet.ConvertedTypeFromString(info.ConvertedType); err == nil {
 		schema.ConvertedType = &ct
@@ -327,6 +414,29 @@ func NewSchemaElementFromTagMap(info *Tag) (*parquet.SchemaElement, error) {
 	return schema, nil
 }
 
+//CheckEncoding returns an error if the values of the physical type can't be written with the encoding
+func CheckEncoding(encoding parquet.Encoding, pT parquet.Type) error {
+	var types []parquet.Type
+	switch encoding {
+	case parquet.Encoding_DELTA_BINARY_PACKED:
+		types = []parquet.Type{parquet.Type_INT32, parquet.Type_INT64}
+	case parquet.Encoding_DELTA_LENGTH_BYTE_ARRAY:
+		types = []parquet.Type{parquet.Type_BYTE_ARRAY}
+	case parquet.Encoding_DELTA_BYTE_ARRAY:
+		types = []parquet.Type{parquet.Type_BYTE_ARRAY, parquet.Type_FIXED_LEN_BYTE_ARRAY}
+	case parquet.Encoding_BYTE_STREAM_SPLIT:
+		types = []parquet.Type{parquet.Type_FLOAT, parquet.Type_DOUBLE}
+	default:
+		return nil
+	}
+	for _, t := range types {
+		if t == pT {
+			return nil
+		}
+	}
+	return errors.Errorf("the encoding %v can't be used with the type %v", encoding, pT)
+}
+
 func NewLogicalTypeFromFieldsMap(mp map[string]string) (*parquet.LogicalType, error) {
 	if val, ok := mp["logicaltype"]; !ok {
 		return nil, errors.New("does not have logicaltype")
@@ -544,7 +654,12 @@ func GetKeyTagMap(src *Tag) *Tag {
 	res.Precision = src.KeyPrecision
 	res.FieldID = src.KeyFieldID
 	res.Encoding = src.KeyEncoding
+	res.HasEncoding = src.KeyHasEncoding
 	res.OmitStats = src.KeyOmitStats
+	res.BloomFilter = src.KeyBloomFilter
+	res.DataPageVersion = src.KeyDataPageVersion
+	res.DictSizeLimit = src.KeyDictSizeLimit
+	res.Compression = src.KeyCompression
 	res.RepetitionType = parquet.FieldRepetitionType_REQUIRED
 	return res
 }
@@ -562,7 +677,12 @@ func GetValueTagMap(src *Tag) *Tag {
 	res.Precision = src.ValuePrecision
 	res.FieldID = src.ValueFieldID
 	res.Encoding = src.ValueEncoding
+	res.HasEncoding = src.ValueHasEncoding
 	res.OmitStats = src.ValueOmitStats
+	res.BloomFilter = src.ValueBloomFilter
+	res.DataPageVersion = src.ValueDataPageVersion
+	res.DictSizeLimit = src.ValueDictSizeLimit
+	res.Compression = src.ValueCompression
 	res.RepetitionType = src.ValueRepetitionType
 	return res
 }
@@ -756,6 +876,14 @@ func Str2Int32(val string) (int32, error) {
 	return int32(valInt), nil
 }
 
+func Str2Int64(val string) (int64, error) {
+	valInt, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return 0, errors.Wrap(err, "strconv.ParseInt")
+	}
+	return valInt, nil
+}
+
 func Str2Bool(val string) (bool, error) {
 	valBoolean, err := strconv.ParseBool(val)
 	if err != nil {
@@ -764,6 +892,27 @@ func Str2Bool(val string) (bool, error) {
 	return valBoolean, nil
 }
 
+//Str2DataPageVersion parses a data page version, 1 for DATA_PAGE or 2 for DATA_PAGE_V2
+func Str2DataPageVersion(val string) (int32, error) {
+	version, err := Str2Int32(val)
+	if err != nil {
+		return 0, errors.Wrap(err, "Str2Int32")
+	}
+	if version != 1 && version != 2 {
+		return 0, errors.Errorf("unknown data page version: '%v'", val)
+	}
+	return version, nil
+}
+
+//Str2Compression checks the name of a compression codec, e.g. ZSTD, and returns it in upper case
+func Str2Compression(val string) (string, error) {
+	codec, err := parquet.CompressionCodecFromString(strings.ToUpper(val))
+	if err != nil {
+		return "", errors.Wrap(err, "parquet.CompressionCodecFromString")
+	}
+	return codec.String(), nil
+}
+
 type FuncTable interface {
 	LessThan(a interface{}, b interface{}) bool
 	MinMaxSize(minVal interface{}, maxVal interface{}, val interface{}) (interface{}, interface{}, int32)
@@ -973,6 +1122,17 @@ func SizeOf(val reflect.Value) int64 {
 
 const PAR_GO_PATH_DELIMITER = "\x01"
 
+//CreatedBy is the created_by of the files written by parquet-go. LegacyCreatedBy is the created_by of the
+//older writer, which split the records of the repeated columns across the pages and counted the values
+//instead of the rows in the FirstRowIndex of the OffsetIndex.
+const (
+	CreatedBy       = "parquet-go version latest (build 2)"
+	LegacyCreatedBy = "parquet-go version latest"
+)
+
+//MillisecondsPerDay converts the milliseconds of arrow Date64 values to the days of DATE columns
+const MillisecondsPerDay = 24 * 60 * 60 * 1000
+
 // . -> \x01
 func ReformPathStr(pathStr string) string {
 	return strings.ReplaceAll(pathStr, ".", "\x01")
@@ -1029,7 +1189,7 @@ func ArrowColToParquetCol(field arrow.Field, col array.Interface, len int,
 	el *parquet.SchemaElement) ([]interface{}, error) {
 	var err error
 	recs := make([]interface{}, len)
-	switch field.Type.(type) {
+	switch fieldType := field.Type.(type) {
 	case *arrow.Int8Type:
 		arr := col.(*array.Int8)
 		var rec int8
@@ -1242,12 +1402,16 @@ func ArrowColToParquetCol(field arrow.Field, col array.Interface, len int,
 			} else {
 				rec = 0
 			}
-			recs[i], err = types.StrToParquetType(fmt.Sprintf("%v", rec),
+			// milliseconds are converted to days, rounded down
+			days := int64(rec) / MillisecondsPerDay
+			if int64(rec)%MillisecondsPerDay < 0 {
+				days--
+			}
+			recs[i], err = types.StrToParquetType(fmt.Sprintf("%v", days),
 				el.Type,
 				el.ConvertedType,
 				int(el.GetTypeLength()),
 				int(el.GetScale()))
-
 			if err != nil {
 				return nil, errors.Wrap(err, "types.StrToParquetType")
 			}
@@ -1316,6 +1480,9 @@ func ArrowColToParquetCol(field arrow.Field, col array.Interface, len int,
 			} else {
 				rec = 0
 			}
+			if fieldType.Unit == arrow.Second {
+				rec *= 1000
+			}
 			recs[i], err = types.StrToParquetType(fmt.Sprintf("%v", rec),
 				el.Type,
 				el.ConvertedType,
@@ -1334,6 +1501,9 @@ func ArrowColToParquetCol(field arrow.Field, col array.Interface, len int,
 			} else {
 				rec = 0
 			}
+			if fieldType.Unit == arrow.Second {
+				rec *= 1000
+			}
 			recs[i], err = types.StrToParquetType(fmt.Sprintf("%v", rec),
 				el.Type,
 				el.ConvertedType,
@@ -1343,6 +1513,148 @@ func ArrowColToParquetCol(field arrow.Field, col array.Interface, len int,
 				return nil, errors.Wrap(err, "types.StrToParquetType")
 			}
 		}
+	case *arrow.Time64Type:
+		arr := col.(*array.Time64)
+		for i := 0; i < arr.Len(); i++ {
+			recs[i] = int64(arr.Value(i))
+		}
+	case *arrow.DurationType:
+		arr := col.(*array.Duration)
+		for i := 0; i < arr.Len(); i++ {
+			recs[i] = int64(arr.Value(i))
+		}
+	case *arrow.Float16Type:
+		arr := col.(*array.Float16)
+		for i := 0; i < arr.Len(); i++ {
+			recs[i] = arr.Value(i).Float32()
+		}
+	case *arrow.Decimal128Type:
+		arr := col.(*array.Decimal128)
+		for i := 0; i < arr.Len(); i++ {
+			rec := arr.Value(i)
+			switch el.GetType() {
+			case parquet.Type_INT32:
+				recs[i] = int32(rec.LowBits())
+			case parquet.Type_INT64:
+				recs[i] = int64(rec.LowBits())
+			default:
+				buf := make([]byte, 16)
+				binary.BigEndian.PutUint64(buf, uint64(rec.HighBits()))
+				binary.BigEndian.PutUint64(buf[8:], rec.LowBits())
+				recs[i] = string(buf[16-int(el.GetTypeLength()):])
+			}
+		}
+	case *arrow.FixedSizeBinaryType:
+		arr := col.(*array.FixedSizeBinary)
+		for i := 0; i < arr.Len(); i++ {
+			if arr.IsValid(i) {
+				recs[i] = string(arr.Value(i))
+			} else {
+				recs[i] = string(make([]byte, fieldType.ByteWidth))
+			}
+		}
 	}
 	return recs, nil
 }
+
+// ArrowNestedColToParquetCol creates column with parquet values from a
+// column with arrow values of any supported type. schemas holds the schema
+// element of the field followed by the elements of its descendants.
+//
+// The values of a group are slices holding the values of its fields, the
+// values of a repeated field are slices holding its values, and the null
+// values of an optional field are nil.
+func ArrowNestedColToParquetCol(field arrow.Field, col array.Interface,
+	schemas []*parquet.SchemaElement) ([]interface{}, error) {
+	var recs []interface{}
+	var err error
+	el := schemas[0]
+	switch fieldType := field.Type.(type) {
+	case *arrow.StructType:
+		arr := col.(*array.Struct)
+		fields := make([][]interface{}, arr.NumField())
+		pos := 1
+		for j := range fields {
+			fields[j], err = ArrowNestedColToParquetCol(fieldType.Field(j),
+				arr.Field(j), schemas[pos:])
+			if err != nil {
+				return nil, err
+			}
+			pos += SchemaSize(schemas[pos:])
+		}
+		recs = make([]interface{}, arr.Len())
+		for i := range recs {
+			rec := make([]interface{}, len(fields))
+			for j := range fields {
+				rec[j] = fields[j][i]
+			}
+			recs[i] = rec
+		}
+	case *arrow.ListType, *arrow.FixedSizeListType:
+		var values array.Interface
+		var offsets func(i int) (int, int)
+		offset := col.Data().Offset()
+		switch arr := col.(type) {
+		case *array.List:
+			values = arr.ListValues()
+			offsets = func(i int) (int, int) {
+				return int(arr.Offsets()[offset+i]), int(arr.Offsets()[offset+i+1])
+			}
+		case *array.FixedSizeList:
+			n := int(fieldType.(*arrow.FixedSizeListType).Len())
+			values = arr.ListValues()
+			offsets = func(i int) (int, int) {
+				return (offset + i) * n, (offset + i + 1) * n
+			}
+		}
+		//schemas[1] is the repeated group of the list, schemas[2] its element
+		elemField := arrow.Field{Name: schemas[2].GetName(),
+			Type: values.DataType(), Nullable: true}
+		elems, err := ArrowNestedColToParquetCol(elemField, values, schemas[2:])
+		if err != nil {
+			return nil, err
+		}
+		recs = make([]interface{}, col.Len())
+		for i := range recs {
+			begin, end := offsets(i)
+			rec := make([]interface{}, 0, end-begin)
+			for _, elem := range elems[begin:end] {
+				rec = append(rec, []interface{}{elem})
+			}
+			recs[i] = []interface{}{rec}
+		}
+	default:
+		recs, err = ArrowColToParquetCol(field, col, col.Len(), el)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if el.GetRepetitionType() == parquet.FieldRepetitionType_OPTIONAL {
+		for i := range recs {
+			if col.IsNull(i) {
+				recs[i] = nil
+			}
+		}
+	}
+	return recs, nil
+}
+
+// DecimalLength returns the length of the fixed length byte arrays holding
+// the decimals of a precision
+func DecimalLength(precision int32) int32 {
+	length := int32(1)
+	for float64(precision) > float64(8*length-1)*math.Log10(2) {
+		length++
+	}
+	return length
+}
+
+// SchemaSize returns the number of elements of the subtree of schemas[0]
+func SchemaSize(schemas []*parquet.SchemaElement) int {
+	size, children := 1, int(schemas[0].GetNumChildren())
+	for i := 0; i < children; i++ {
+		size += SchemaSize(schemas[size:])
+	}
+	return size
+}
@@ -0,0 +1,63 @@
+//go:build !no_brotli
+// +build !no_brotli
+
+package compress
+
+import (
+	"bytes"
+	"io/ioutil"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/pkg/errors"
+	"github.com/sabey/parquet-go/parquet"
+)
+
+func init() {
+	mustRegisterCodec(parquet.CompressionCodec_BROTLI, newBrotliCompressor)
+}
+
+//newBrotliCompressor creates a BROTLI compressor, its writers and readers are pooled
+func newBrotliCompressor(opts Options) (*Compressor, error) {
+	level := brotli.DefaultCompression
+	if opts.Level != 0 {
+		level = opts.Level
+	}
+	if level < brotli.BestSpeed || level > brotli.BestCompression {
+		return nil, errors.Errorf("invalid brotli compression level: %v", level)
+	}
+	brotliWriterPool := sync.Pool{
+		New: func() interface{} {
+			return brotli.NewWriterLevel(nil, level)
+		},
+	}
+	brotliReaderPool := sync.Pool{
+		New: func() interface{} {
+			return brotli.NewReader(nil)
+		},
+	}
+	return &Compressor{
+		Compress: func(buf []byte) []byte {
+			res := new(bytes.Buffer)
+			brotliWriter := brotliWriterPool.Get().(*brotli.Writer)
+			brotliWriter.Reset(res)
+			brotliWriter.Write(buf)
+			brotliWriter.Close()
+			brotliWriter.Reset(nil)
+			brotliWriterPool.Put(brotliWriter)
+			return res.Bytes()
+		},
+		Uncompress: func(buf []byte) (i []byte, err error) {
+			brotliReader := brotliReaderPool.Get().(*brotli.Reader)
+			defer brotliReaderPool.Put(brotliReader)
+			if err = brotliReader.Reset(bytes.NewReader(buf)); err != nil {
+				return nil, errors.Wrap(err, "brotliReader.Reset")
+			}
+			res, err := ioutil.ReadAll(brotliReader)
+			if err != nil {
+				return res, errors.Wrap(err, "ioutil.ReadAll")
+			}
+			return res, nil
+		},
+	}, nil
+}
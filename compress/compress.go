@@ -1,21 +1,112 @@
 package compress
 
 import (
+	"sync"
+
 	"github.com/pkg/errors"
 	"github.com/sabey/parquet-go/parquet"
 )
 
+//Compressor compresses and uncompresses the pages of a codec, its functions are safe for concurrent use
 type Compressor struct {
 	Compress   func(buf []byte) []byte
 	Uncompress func(buf []byte) ([]byte, error)
+	//UncompressWithSize uncompresses data whose uncompressed size is known, like the pages. It's optional,
+	//Uncompress is used without it, but the codecs which don't store the size, like LZ4_RAW, need it.
+	UncompressWithSize func(buf []byte, size int) ([]byte, error)
 }
 
-var compressors = map[parquet.CompressionCodec]*Compressor{}
+//Options configures the compressors of a codec, the zero value uses the defaults of the codec
+type Options struct {
+	//Compression level, 0 means the default level of the codec. The levels are 1-9 for GZIP,
+	//1-11 for BROTLI and 1-22 for ZSTD, the other codecs ignore it.
+	Level int
+	//Dictionary of ZSTD, the files compressed with it are read after the codec is configured with it by SetOptions
+	Dictionary []byte
+	//Maximum number of goroutines of an encoder of ZSTD, 0 means GOMAXPROCS
+	Concurrency int
+}
 
-func Uncompress(buf []byte, compressMethod parquet.CompressionCodec) ([]byte, error) {
-	c, ok := compressors[compressMethod]
+//CodecFunc creates a compressor of a codec with options
+type CodecFunc func(opts Options) (*Compressor, error)
+
+type codec struct {
+	newCompressor CodecFunc
+	//compressor is the compressor used by Compress and Uncompress
+	compressor *Compressor
+}
+
+var (
+	codecsMutex sync.RWMutex
+	codecs      = map[parquet.CompressionCodec]*codec{}
+)
+
+//RegisterCodec registers the compressors of a codec, it replaces the registered ones.
+//The default compressor of the codec, used by Compress and Uncompress, is created with the zero options.
+func RegisterCodec(compressMethod parquet.CompressionCodec, newCompressor CodecFunc) error {
+	c, err := newCompressor(Options{})
+	if err != nil {
+		return errors.Wrap(err, "newCompressor")
+	}
+	codecsMutex.Lock()
+	defer codecsMutex.Unlock()
+	codecs[compressMethod] = &codec{newCompressor: newCompressor, compressor: c}
+	return nil
+}
+
+//SetOptions replaces the default compressor of a registered codec by one with the options
+func SetOptions(compressMethod parquet.CompressionCodec, opts Options) error {
+	codecsMutex.Lock()
+	defer codecsMutex.Unlock()
+	cd, ok := codecs[compressMethod]
 	if !ok {
-		return nil, errors.Errorf("unsupported compress method")
+		return errors.Errorf("unsupported compress method: %v", compressMethod)
+	}
+	c, err := cd.newCompressor(opts)
+	if err != nil {
+		return errors.Wrap(err, "newCompressor")
+	}
+	codecs[compressMethod] = &codec{newCompressor: cd.newCompressor, compressor: c}
+	return nil
+}
+
+//NewCompressor creates a compressor of a registered codec with options
+func NewCompressor(compressMethod parquet.CompressionCodec, opts Options) (*Compressor, error) {
+	codecsMutex.RLock()
+	cd, ok := codecs[compressMethod]
+	codecsMutex.RUnlock()
+	if !ok {
+		return nil, errors.Errorf("unsupported compress method: %v", compressMethod)
+	}
+	c, err := cd.newCompressor(opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "newCompressor")
+	}
+	return c, nil
+}
+
+//GetCompressor returns the default compressor of a registered codec
+func GetCompressor(compressMethod parquet.CompressionCodec) (*Compressor, error) {
+	codecsMutex.RLock()
+	defer codecsMutex.RUnlock()
+	cd, ok := codecs[compressMethod]
+	if !ok {
+		return nil, errors.Errorf("unsupported compress method: %v", compressMethod)
+	}
+	return cd.compressor, nil
+}
+
+//mustRegisterCodec registers a built-in codec
+func mustRegisterCodec(compressMethod parquet.CompressionCodec, newCompressor CodecFunc) {
+	if err := RegisterCodec(compressMethod, newCompressor); err != nil {
+		panic(err)
+	}
+}
+
+func Uncompress(buf []byte, compressMethod parquet.CompressionCodec) ([]byte, error) {
+	c, err := GetCompressor(compressMethod)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetCompressor")
 	}
 
 	bs, err := c.Uncompress(buf)
@@ -25,10 +116,32 @@ func Uncompress(buf []byte, compressMethod parquet.CompressionCodec) ([]byte, er
 	return bs, nil
 }
 
-func Compress(buf []byte, compressMethod parquet.CompressionCodec) []byte {
-	c, ok := compressors[compressMethod]
-	if !ok {
-		return nil
+//UncompressWithSize uncompresses data of size bytes once uncompressed, e.g. the UncompressedPageSize of a page.
+//The size is ignored by the compressors without UncompressWithSize.
+func UncompressWithSize(buf []byte, compressMethod parquet.CompressionCodec, size int) ([]byte, error) {
+	c, err := GetCompressor(compressMethod)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetCompressor")
+	}
+	if c.UncompressWithSize == nil || size < 0 {
+		bs, err := c.Uncompress(buf)
+		if err != nil {
+			return bs, errors.Wrap(err, "c.Uncompress")
+		}
+		return bs, nil
+	}
+
+	bs, err := c.UncompressWithSize(buf, size)
+	if err != nil {
+		return bs, errors.Wrap(err, "c.UncompressWithSize")
+	}
+	return bs, nil
+}
+
+func Compress(buf []byte, compressMethod parquet.CompressionCodec) ([]byte, error) {
+	c, err := GetCompressor(compressMethod)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetCompressor")
 	}
-	return c.Compress(buf)
+	return c.Compress(buf), nil
 }
@@ -0,0 +1,140 @@
+package compress
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sabey/parquet-go/parquet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompressCodecs(t *testing.T) {
+	inputs := [][]byte{
+		{},
+		[]byte("test data"),
+		bytes.Repeat([]byte("parquet "), 10000),
+	}
+	codecs := []parquet.CompressionCodec{
+		parquet.CompressionCodec_UNCOMPRESSED,
+		parquet.CompressionCodec_SNAPPY,
+		parquet.CompressionCodec_GZIP,
+		parquet.CompressionCodec_LZ4,
+		parquet.CompressionCodec_ZSTD,
+		parquet.CompressionCodec_BROTLI,
+		parquet.CompressionCodec_LZ4_RAW,
+	}
+	for _, codec := range codecs {
+		for _, input := range inputs {
+			compressed, err := Compress(input, codec)
+			assert.Nil(t, err, codec.String())
+			output, err := Uncompress(compressed, codec)
+			assert.Nil(t, err, codec.String())
+			assert.Equal(t, len(input), len(output), codec.String())
+			assert.True(t, bytes.Equal(input, output), codec.String())
+			output, err = UncompressWithSize(compressed, codec, len(input))
+			assert.Nil(t, err, codec.String())
+			assert.True(t, bytes.Equal(input, output), codec.String())
+		}
+	}
+}
+
+func TestLz4RawUncompressWithSize(t *testing.T) {
+	input := bytes.Repeat([]byte("parquet "), 10000)
+	compressed, err := Compress(input, parquet.CompressionCodec_LZ4_RAW)
+	assert.Nil(t, err)
+
+	//the size of the page header is the size of the block
+	_, err = UncompressWithSize(compressed, parquet.CompressionCodec_LZ4_RAW, len(input)-1)
+	assert.NotNil(t, err)
+	_, err = UncompressWithSize(compressed, parquet.CompressionCodec_LZ4_RAW, len(input)+1)
+	assert.NotNil(t, err)
+	_, err = UncompressWithSize(compressed, parquet.CompressionCodec_LZ4_RAW, 1<<30)
+	assert.NotNil(t, err)
+}
+
+func TestCompressUnknownCodec(t *testing.T) {
+	_, err := Compress([]byte("test data"), parquet.CompressionCodec_LZO)
+	assert.NotNil(t, err)
+	_, err = Uncompress([]byte("test data"), parquet.CompressionCodec_LZO)
+	assert.NotNil(t, err)
+	_, err = UncompressWithSize([]byte("test data"), parquet.CompressionCodec_LZO, 9)
+	assert.NotNil(t, err)
+	_, err = NewCompressor(parquet.CompressionCodec_LZO, Options{})
+	assert.NotNil(t, err)
+}
+
+func TestCompressorOptions(t *testing.T) {
+	input := bytes.Repeat([]byte("parquet go "), 1000)
+	tests := []struct {
+		codec parquet.CompressionCodec
+		opts  Options
+		err   bool
+	}{
+		{parquet.CompressionCodec_GZIP, Options{Level: 1}, false},
+		{parquet.CompressionCodec_GZIP, Options{Level: 9}, false},
+		{parquet.CompressionCodec_GZIP, Options{Level: 42}, true},
+		{parquet.CompressionCodec_ZSTD, Options{Level: 19, Concurrency: 2}, false},
+		{parquet.CompressionCodec_ZSTD, Options{Level: 23}, true},
+		{parquet.CompressionCodec_BROTLI, Options{Level: 11}, false},
+		{parquet.CompressionCodec_BROTLI, Options{Level: 12}, true},
+	}
+	for _, test := range tests {
+		compressor, err := NewCompressor(test.codec, test.opts)
+		if test.err {
+			assert.NotNil(t, err, test.codec.String())
+			continue
+		}
+		assert.Nil(t, err, test.codec.String())
+		output, err := compressor.Uncompress(compressor.Compress(input))
+		assert.Nil(t, err, test.codec.String())
+		assert.Equal(t, input, output, test.codec.String())
+
+		//the default compressor reads the data of the compressor with options
+		output, err = Uncompress(compressor.Compress(input), test.codec)
+		assert.Nil(t, err, test.codec.String())
+		assert.Equal(t, input, output, test.codec.String())
+	}
+}
+
+func TestZstdDictionary(t *testing.T) {
+	//the dictionaries are in the zstd dictionary format, raw content is rejected
+	_, err := NewCompressor(parquet.CompressionCodec_ZSTD, Options{Dictionary: []byte("parquet")})
+	assert.NotNil(t, err)
+}
+
+func TestRegisterCodec(t *testing.T) {
+	defer func() {
+		assert.Nil(t, RegisterCodec(parquet.CompressionCodec_LZ4_RAW, newLz4RawCompressor))
+	}()
+
+	var levels []int
+	err := RegisterCodec(parquet.CompressionCodec_LZ4_RAW, func(opts Options) (*Compressor, error) {
+		levels = append(levels, opts.Level)
+		return &Compressor{
+			Compress: func(buf []byte) []byte {
+				return append([]byte{byte(opts.Level)}, buf...)
+			},
+			Uncompress: func(buf []byte) ([]byte, error) {
+				return buf[1:], nil
+			},
+		}, nil
+	})
+	assert.Nil(t, err)
+
+	compressed, err := Compress([]byte("test data"), parquet.CompressionCodec_LZ4_RAW)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("\x00test data"), compressed)
+	compressor, err := NewCompressor(parquet.CompressionCodec_LZ4_RAW, Options{Level: 3})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("\x03test data"), compressor.Compress([]byte("test data")))
+	assert.Nil(t, SetOptions(parquet.CompressionCodec_LZ4_RAW, Options{Level: 5}))
+	compressed, err = Compress([]byte("test data"), parquet.CompressionCodec_LZ4_RAW)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("\x05test data"), compressed)
+	output, err := Uncompress(compressed, parquet.CompressionCodec_LZ4_RAW)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("test data"), output)
+	assert.Equal(t, []int{0, 3, 5}, levels)
+
+	assert.NotNil(t, SetOptions(parquet.CompressionCodec_LZO, Options{}))
+}
@@ -3,12 +3,14 @@ package compress
 import "github.com/sabey/parquet-go/parquet"
 
 func init() {
-	compressors[parquet.CompressionCodec_UNCOMPRESSED] = &Compressor{
-		Compress: func(buf []byte) []byte {
-			return buf
-		},
-		Uncompress: func(buf []byte) (bytes []byte, err error) {
-			return buf, nil
-		},
-	}
+	mustRegisterCodec(parquet.CompressionCodec_UNCOMPRESSED, func(opts Options) (*Compressor, error) {
+		return &Compressor{
+			Compress: func(buf []byte) []byte {
+				return buf
+			},
+			Uncompress: func(buf []byte) (bytes []byte, err error) {
+				return buf, nil
+			},
+		}, nil
+	})
 }
@@ -13,16 +13,28 @@ import (
 	"github.com/sabey/parquet-go/parquet"
 )
 
-var gzipWriterPool sync.Pool
-
 func init() {
-	gzipWriterPool = sync.Pool{
+	mustRegisterCodec(parquet.CompressionCodec_GZIP, newGzipCompressor)
+}
+
+//newGzipCompressor creates a GZIP compressor, its writers and readers are pooled
+func newGzipCompressor(opts Options) (*Compressor, error) {
+	level := gzip.DefaultCompression
+	if opts.Level != 0 {
+		level = opts.Level
+	}
+	if _, err := gzip.NewWriterLevel(nil, level); err != nil {
+		return nil, errors.Wrap(err, "gzip.NewWriterLevel")
+	}
+	gzipWriterPool := sync.Pool{
 		New: func() interface{} {
-			return gzip.NewWriter(nil)
+			gzipWriter, _ := gzip.NewWriterLevel(nil, level)
+			return gzipWriter
 		},
 	}
+	var gzipReaderPool sync.Pool
 
-	compressors[parquet.CompressionCodec_GZIP] = &Compressor{
+	return &Compressor{
 		Compress: func(buf []byte) []byte {
 			res := new(bytes.Buffer)
 			gzipWriter := gzipWriterPool.Get().(*gzip.Writer)
@@ -35,12 +47,20 @@ func init() {
 		},
 		Uncompress: func(buf []byte) (i []byte, err error) {
 			rbuf := bytes.NewReader(buf)
-			gzipReader, _ := gzip.NewReader(rbuf)
+			gzipReader, _ := gzipReaderPool.Get().(*gzip.Reader)
+			if gzipReader == nil {
+				if gzipReader, err = gzip.NewReader(rbuf); err != nil {
+					return nil, errors.Wrap(err, "gzip.NewReader")
+				}
+			} else if err = gzipReader.Reset(rbuf); err != nil {
+				return nil, errors.Wrap(err, "gzipReader.Reset")
+			}
+			defer gzipReaderPool.Put(gzipReader)
 			res, err := ioutil.ReadAll(gzipReader)
 			if err != nil {
 				return res, errors.Wrap(err, "ioutil.ReadAll")
 			}
 			return res, nil
 		},
-	}
+	}, nil
 }
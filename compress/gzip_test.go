@@ -8,7 +8,7 @@ import (
 )
 
 func TestGzipCompression(t *testing.T) {
-	gzipCompressor := compressors[parquet.CompressionCodec_GZIP]
+	gzipCompressor, _ := GetCompressor(parquet.CompressionCodec_GZIP)
 	input := []byte("test data")
 	compressed := gzipCompressor.Compress(input)
 	output, err := gzipCompressor.Uncompress(compressed)
@@ -22,7 +22,7 @@ func TestGzipCompression(t *testing.T) {
 }
 
 func BenchmarkGzipCompression(b *testing.B) {
-	gzipCompressor := compressors[parquet.CompressionCodec_GZIP]
+	gzipCompressor, _ := GetCompressor(parquet.CompressionCodec_GZIP)
 	input := []byte("test data")
 	b.ReportAllocs()
 	for i := 0; i < b.N; i++ {
@@ -14,12 +14,23 @@ import (
 )
 
 func init() {
+	mustRegisterCodec(parquet.CompressionCodec_LZ4, newLz4Compressor)
+	mustRegisterCodec(parquet.CompressionCodec_LZ4_RAW, newLz4RawCompressor)
+}
+
+//newLz4Compressor creates a compressor of the LZ4 frame format, its writers and readers are pooled
+func newLz4Compressor(opts Options) (*Compressor, error) {
 	lz4WriterPool := sync.Pool{
 		New: func() interface{} {
 			return lz4.NewWriter(nil)
 		},
 	}
-	compressors[parquet.CompressionCodec_LZ4] = &Compressor{
+	lz4ReaderPool := sync.Pool{
+		New: func() interface{} {
+			return lz4.NewReader(nil)
+		},
+	}
+	return &Compressor{
 		Compress: func(buf []byte) []byte {
 			lz4Writer := lz4WriterPool.Get().(*lz4.Writer)
 			res := new(bytes.Buffer)
@@ -31,13 +42,79 @@ func init() {
 			return res.Bytes()
 		},
 		Uncompress: func(buf []byte) (i []byte, err error) {
-			rbuf := bytes.NewReader(buf)
-			lz4Reader := lz4.NewReader(rbuf)
+			lz4Reader := lz4ReaderPool.Get().(*lz4.Reader)
+			lz4Reader.Reset(bytes.NewReader(buf))
 			res, err := ioutil.ReadAll(lz4Reader)
+			lz4Reader.Reset(nil)
+			lz4ReaderPool.Put(lz4Reader)
 			if err != nil {
 				return res, errors.Wrap(err, "ioutil.ReadAll")
 			}
 			return res, nil
 		},
+	}, nil
+}
+
+//newLz4RawCompressor creates a compressor of the LZ4 block format, without frame, its block compressors are pooled
+func newLz4RawCompressor(opts Options) (*Compressor, error) {
+	lz4CompressorPool := sync.Pool{
+		New: func() interface{} {
+			return new(lz4.Compressor)
+		},
 	}
+	return &Compressor{
+		Compress: func(buf []byte) []byte {
+			lz4Compressor := lz4CompressorPool.Get().(*lz4.Compressor)
+			defer lz4CompressorPool.Put(lz4Compressor)
+			res := make([]byte, lz4.CompressBlockBound(len(buf)))
+			//the compression can't fail with a buffer of the bound size
+			n, _ := lz4Compressor.CompressBlock(buf, res)
+			return res[:n]
+		},
+		//the size of the data isn't stored in a block, without it the buffer grows up to the maximum ratio of LZ4
+		Uncompress: func(buf []byte) (i []byte, err error) {
+			if isEmptyLz4Block(buf) {
+				return []byte{}, nil
+			}
+			maxSize := maxLz4BlockSize(buf)
+			for size := 4 * len(buf); ; size *= 2 {
+				if size > maxSize {
+					size = maxSize
+				}
+				res := make([]byte, size)
+				n, err := lz4.UncompressBlock(buf, res)
+				if err == nil {
+					return res[:n], nil
+				} else if size == maxSize {
+					return nil, errors.Wrap(err, "lz4.UncompressBlock")
+				}
+			}
+		},
+		UncompressWithSize: func(buf []byte, size int) (i []byte, err error) {
+			if isEmptyLz4Block(buf) && size == 0 {
+				return []byte{}, nil
+			}
+			if size > maxLz4BlockSize(buf) {
+				return nil, errors.Errorf("invalid uncompressed size %v of %v bytes", size, len(buf))
+			}
+			res := make([]byte, size)
+			n, err := lz4.UncompressBlock(buf, res)
+			if err != nil {
+				return nil, errors.Wrap(err, "lz4.UncompressBlock")
+			} else if n != size {
+				return nil, errors.Errorf("uncompressed %v bytes instead of %v", n, size)
+			}
+			return res, nil
+		},
+	}, nil
+}
+
+//isEmptyLz4Block reports whether an LZ4 block is empty, it's a single token without literals which UncompressBlock rejects
+func isEmptyLz4Block(buf []byte) bool {
+	return len(buf) == 0 || len(buf) == 1 && buf[0] == 0
+}
+
+//maxLz4BlockSize returns the maximum uncompressed size of an LZ4 block, from the maximum ratio of LZ4
+func maxLz4BlockSize(buf []byte) int {
+	return 255*len(buf) + 16
 }
@@ -10,17 +10,19 @@ import (
 )
 
 func init() {
-	compressors[parquet.CompressionCodec_SNAPPY] = &Compressor{
-		Compress: func(buf []byte) []byte {
-			return snappy.Encode(nil, buf)
-		},
-		Uncompress: func(buf []byte) (bytes []byte, err error) {
-			bs, err := snappy.Decode(nil, buf)
-			if err != nil {
-				return bs, errors.Wrap(err, "snappy.Decode")
-			}
+	mustRegisterCodec(parquet.CompressionCodec_SNAPPY, func(opts Options) (*Compressor, error) {
+		return &Compressor{
+			Compress: func(buf []byte) []byte {
+				return snappy.Encode(nil, buf)
+			},
+			Uncompress: func(buf []byte) (bytes []byte, err error) {
+				bs, err := snappy.Decode(nil, buf)
+				if err != nil {
+					return bs, errors.Wrap(err, "snappy.Decode")
+				}
 
-			return bs, nil
-		},
-	}
+				return bs, nil
+			},
+		}, nil
+	})
 }
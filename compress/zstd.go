@@ -10,10 +10,34 @@ import (
 )
 
 func init() {
-	// Create encoder/decoder with default parameters.
-	enc, _ := zstd.NewWriter(nil, zstd.WithZeroFrames(true))
-	dec, _ := zstd.NewReader(nil)
-	compressors[parquet.CompressionCodec_ZSTD] = &Compressor{
+	mustRegisterCodec(parquet.CompressionCodec_ZSTD, newZstdCompressor)
+}
+
+//newZstdCompressor creates a ZSTD compressor, its encoder and decoder are safe for concurrent use
+func newZstdCompressor(opts Options) (*Compressor, error) {
+	encoderOptions := []zstd.EOption{zstd.WithZeroFrames(true)}
+	decoderOptions := []zstd.DOption{}
+	if opts.Level < 0 || opts.Level > 22 {
+		return nil, errors.Errorf("invalid zstd compression level: %v", opts.Level)
+	} else if opts.Level != 0 {
+		encoderOptions = append(encoderOptions, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(opts.Level)))
+	}
+	if opts.Concurrency != 0 {
+		encoderOptions = append(encoderOptions, zstd.WithEncoderConcurrency(opts.Concurrency))
+	}
+	if opts.Dictionary != nil {
+		encoderOptions = append(encoderOptions, zstd.WithEncoderDict(opts.Dictionary))
+		decoderOptions = append(decoderOptions, zstd.WithDecoderDicts(opts.Dictionary))
+	}
+	enc, err := zstd.NewWriter(nil, encoderOptions...)
+	if err != nil {
+		return nil, errors.Wrap(err, "zstd.NewWriter")
+	}
+	dec, err := zstd.NewReader(nil, decoderOptions...)
+	if err != nil {
+		return nil, errors.Wrap(err, "zstd.NewReader")
+	}
+	return &Compressor{
 		Compress: func(buf []byte) []byte {
 			return enc.EncodeAll(buf, nil)
 		},
@@ -25,5 +49,5 @@ func init() {
 
 			return bs, nil
 		},
-	}
+	}, nil
 }
@@ -478,14 +478,19 @@ func ReadDeltaByteArray(bytesReader *bytes.Reader) ([]interface{}, error) {
 	if err != nil {
 		return res, errors.Wrap(err, "ReadDeltaLengthByteArray")
 	}
+	if len(suffixes) != len(prefixLengths) {
+		return res, errors.Errorf("%v prefix lengths and %v suffixes", len(prefixLengths), len(suffixes))
+	}
 	res = make([]interface{}, len(prefixLengths))
 
-	res[0] = suffixes[0]
-	for i := 1; i < len(prefixLengths); i++ {
+	prefix := ""
+	for i := 0; i < len(prefixLengths); i++ {
 		prefixLength := prefixLengths[i].(int64)
-		prefix := res[i-1].(string)[:prefixLength]
-		suffix := suffixes[i].(string)
-		res[i] = prefix + suffix
+		if prefixLength < 0 || prefixLength > int64(len(prefix)) {
+			return res, errors.Errorf("invalid prefix length %v", prefixLength)
+		}
+		prefix = prefix[:prefixLength] + suffixes[i].(string)
+		res[i] = prefix
 	}
 	return res, nil
 }
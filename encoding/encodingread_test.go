@@ -151,6 +151,8 @@ func TestReadDeltaBinaryPackedINT(t *testing.T) {
 	testData := [][]interface{}{
 		{int64(1), int64(2), int64(3), int64(4)},
 		{int64(math.MaxInt64), int64(math.MinInt64), int64(-15654523568543623), int64(4354365463543632), int64(0)},
+		{int64(math.MinInt64), int64(math.MaxInt64), int64(-1), int64(math.MinInt64)},
+		{},
 	}
 
 	for _, data := range testData {
@@ -191,6 +193,8 @@ func TestReadDeltaBinaryPackedINT32(t *testing.T) {
 	testData := [][]interface{}{
 		{int32(1), int32(2), int32(3), int32(4)},
 		{int32(-1570499385), int32(-1570499385), int32(-1570499386), int32(-1570499388), int32(-1570499385)},
+		{int32(math.MaxInt32), int32(math.MinInt32), int32(0), int32(math.MaxInt32), int32(-1)},
+		{},
 	}
 
 	for _, data := range testData {
@@ -209,6 +213,7 @@ func TestReadDeltaBinaryPackedINT32(t *testing.T) {
 func TestReadDeltaByteArray(t *testing.T) {
 	testData := [][]interface{}{
 		{"Hello", "world"},
+		{},
 	}
 	for _, data := range testData {
 		res, _ := ReadDeltaByteArray(bytes.NewReader(WriteDeltaByteArray(data)))
@@ -221,6 +226,7 @@ func TestReadDeltaByteArray(t *testing.T) {
 func TestReadLengthDeltaByteArray(t *testing.T) {
 	testData := [][]interface{}{
 		{"Hello", "world"},
+		{},
 	}
 	for _, data := range testData {
 		res, _ := ReadDeltaLengthByteArray(bytes.NewReader(WriteDeltaLengthByteArray(data)))
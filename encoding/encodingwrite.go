@@ -272,7 +272,8 @@ func WriteBitPacked(vals []interface{}, bitWidth int64, ifHeader bool) []byte {
 func WriteDelta(nums []interface{}) []byte {
 	ln := len(nums)
 	if ln <= 0 {
-		return []byte{}
+		//the header of an empty page is the same for both types
+		return WriteDeltaINT32(nums)
 	}
 
 	if _, ok := nums[0].(int32); ok {
@@ -291,8 +292,11 @@ func WriteDeltaINT32(nums []interface{}) []byte {
 	var numValuesInMiniBlock uint64 = 32
 	var totalNumValues uint64 = uint64(len(nums))
 
-	num := nums[0].(int32)
-	var firstValue uint64 = uint64((num >> 31) ^ (num << 1))
+	var firstValue uint64 = 0
+	if totalNumValues > 0 {
+		num := nums[0].(int32)
+		firstValue = uint64((num >> 31) ^ (num << 1))
+	}
 
 	res = append(res, WriteUnsignedVarInt(blockSize)...)
 	res = append(res, WriteUnsignedVarInt(numMiniBlocksInBlock)...)
@@ -320,14 +324,16 @@ func WriteDeltaINT32(nums []interface{}) []byte {
 		bitWidths := make([]byte, numMiniBlocksInBlock)
 
 		for j := 0; uint64(j) < numMiniBlocksInBlock; j++ {
-			var maxValue int32 = 0
+			var maxValue uint32 = 0
 			for k := uint64(j) * numValuesInMiniBlock; k < uint64(j+1)*numValuesInMiniBlock; k++ {
-				blockBuf[k] = blockBuf[k].(int32) - minDelta
-				if blockBuf[k].(int32) > maxValue {
-					maxValue = blockBuf[k].(int32)
+				//the deltas wrap around, their differences with the min delta are unsigned
+				value := uint32(blockBuf[k].(int32) - minDelta)
+				blockBuf[k] = int64(value)
+				if value > maxValue {
+					maxValue = value
 				}
 			}
-			bitWidths[j] = byte(bits.Len32(uint32(maxValue)))
+			bitWidths[j] = byte(bits.Len32(maxValue))
 		}
 
 		var minDeltaZigZag uint64 = uint64((minDelta >> 31) ^ (minDelta << 1))
@@ -349,8 +355,11 @@ func WriteDeltaINT64(nums []interface{}) []byte {
 	var numValuesInMiniBlock uint64 = 32
 	var totalNumValues uint64 = uint64(len(nums))
 
-	num := nums[0].(int64)
-	var firstValue uint64 = uint64((num >> 63) ^ (num << 1))
+	var firstValue uint64 = 0
+	if totalNumValues > 0 {
+		num := nums[0].(int64)
+		firstValue = uint64((num >> 63) ^ (num << 1))
+	}
 
 	res = append(res, WriteUnsignedVarInt(blockSize)...)
 	res = append(res, WriteUnsignedVarInt(numMiniBlocksInBlock)...)
@@ -378,14 +387,16 @@ func WriteDeltaINT64(nums []interface{}) []byte {
 		bitWidths := make([]byte, numMiniBlocksInBlock)
 
 		for j := 0; uint64(j) < numMiniBlocksInBlock; j++ {
-			var maxValue int64 = 0
+			var maxValue uint64 = 0
 			for k := uint64(j) * numValuesInMiniBlock; k < uint64(j+1)*numValuesInMiniBlock; k++ {
-				blockBuf[k] = blockBuf[k].(int64) - minDelta
-				if blockBuf[k].(int64) > maxValue {
-					maxValue = blockBuf[k].(int64)
+				//the deltas wrap around, their differences with the min delta are unsigned
+				value := uint64(blockBuf[k].(int64) - minDelta)
+				blockBuf[k] = int64(value)
+				if value > maxValue {
+					maxValue = value
 				}
 			}
-			bitWidths[j] = byte(bits.Len64(uint64(maxValue)))
+			bitWidths[j] = byte(bits.Len64(maxValue))
 		}
 
 		var minDeltaZigZag uint64 = uint64((minDelta >> 63) ^ (minDelta << 1))
@@ -468,7 +479,8 @@ func WriteBitPackedDeprecated(vals []interface{}, bitWidth int64) []byte {
 func WriteDeltaByteArray(arrays []interface{}) []byte {
 	ln := len(arrays)
 	if ln <= 0 {
-		return []byte{}
+		//the prefix lengths and the suffixes of an empty page
+		return append(WriteDeltaINT32(arrays), WriteDeltaLengthByteArray(arrays)...)
 	}
 
 	prefixLengths := make([]interface{}, ln)
@@ -0,0 +1,179 @@
+package encoding
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+//The typed readers decode len(dst) values into dst without boxing them
+
+func ReadPlainBOOLEANs(bytesReader *bytes.Reader, dst []bool) error {
+	buf := make([]byte, (len(dst)+7)/8)
+	if _, err := io.ReadFull(bytesReader, buf); err != nil {
+		return errors.Wrap(err, "io.ReadFull")
+	}
+	for i := range dst {
+		dst[i] = (buf[i/8]>>uint(i%8))&1 == 1
+	}
+	return nil
+}
+
+func ReadPlainINT32s(bytesReader *bytes.Reader, dst []int32) error {
+	buf := make([]byte, len(dst)*4)
+	if _, err := io.ReadFull(bytesReader, buf); err != nil {
+		return errors.Wrap(err, "io.ReadFull")
+	}
+	for i := range dst {
+		dst[i] = int32(binary.LittleEndian.Uint32(buf[i*4:]))
+	}
+	return nil
+}
+
+func ReadPlainINT64s(bytesReader *bytes.Reader, dst []int64) error {
+	buf := make([]byte, len(dst)*8)
+	if _, err := io.ReadFull(bytesReader, buf); err != nil {
+		return errors.Wrap(err, "io.ReadFull")
+	}
+	for i := range dst {
+		dst[i] = int64(binary.LittleEndian.Uint64(buf[i*8:]))
+	}
+	return nil
+}
+
+func ReadPlainFLOATs(bytesReader *bytes.Reader, dst []float32) error {
+	buf := make([]byte, len(dst)*4)
+	if _, err := io.ReadFull(bytesReader, buf); err != nil {
+		return errors.Wrap(err, "io.ReadFull")
+	}
+	for i := range dst {
+		dst[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[i*4:]))
+	}
+	return nil
+}
+
+func ReadPlainDOUBLEs(bytesReader *bytes.Reader, dst []float64) error {
+	buf := make([]byte, len(dst)*8)
+	if _, err := io.ReadFull(bytesReader, buf); err != nil {
+		return errors.Wrap(err, "io.ReadFull")
+	}
+	for i := range dst {
+		dst[i] = math.Float64frombits(binary.LittleEndian.Uint64(buf[i*8:]))
+	}
+	return nil
+}
+
+//The byte arrays share one buffer holding the data of all the values
+func ReadPlainBYTE_ARRAYs(bytesReader *bytes.Reader, dst [][]byte) error {
+	buf := make([]byte, bytesReader.Len())
+	if _, err := bytesReader.ReadAt(buf, bytesReader.Size()-int64(bytesReader.Len())); err != nil && err != io.EOF {
+		return errors.Wrap(err, "bytesReader.ReadAt")
+	}
+	pos := 0
+	for i := range dst {
+		if pos+4 > len(buf) {
+			return errors.Wrap(io.ErrUnexpectedEOF, "io.ErrUnexpectedEOF")
+		}
+		ln := int(binary.LittleEndian.Uint32(buf[pos:]))
+		pos += 4
+		if ln < 0 || pos+ln > len(buf) {
+			return errors.Wrap(io.ErrUnexpectedEOF, "io.ErrUnexpectedEOF")
+		}
+		dst[i] = buf[pos : pos+ln : pos+ln]
+		pos += ln
+	}
+	if _, err := bytesReader.Seek(int64(pos), io.SeekCurrent); err != nil {
+		return errors.Wrap(err, "bytesReader.Seek")
+	}
+	return nil
+}
+
+func ReadPlainFIXED_LEN_BYTE_ARRAYs(bytesReader *bytes.Reader, dst [][]byte, fixedLength uint64) error {
+	ln := int(fixedLength)
+	buf := make([]byte, len(dst)*ln)
+	if _, err := io.ReadFull(bytesReader, buf); err != nil {
+		return errors.Wrap(err, "io.ReadFull")
+	}
+	for i := range dst {
+		dst[i] = buf[i*ln : (i+1)*ln : (i+1)*ln]
+	}
+	return nil
+}
+
+func ReadPlainINT96s(bytesReader *bytes.Reader, dst [][]byte) error {
+	return ReadPlainFIXED_LEN_BYTE_ARRAYs(bytesReader, dst, 12)
+}
+
+//ReadRLEBitPackedHybridINT32s decodes the values of length bytes, if length is 0 it's read
+//from the 4 bytes before the values. The values after len(dst) are ignored.
+func ReadRLEBitPackedHybridINT32s(bytesReader *bytes.Reader, bitWidth uint64, length uint64, dst []int32) error {
+	if length <= 0 {
+		lb := make([]int32, 1)
+		if err := ReadPlainINT32s(bytesReader, lb); err != nil {
+			return errors.Wrap(err, "ReadPlainINT32s")
+		}
+		length = uint64(lb[0])
+	}
+
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(bytesReader, buf); err != nil {
+		return errors.Wrap(err, "io.ReadFull")
+	}
+
+	i, pos := 0, 0
+	for i < len(dst) && pos < len(buf) {
+		header, n := binary.Uvarint(buf[pos:])
+		if n <= 0 {
+			return errors.Errorf("invalid RLE/bit-packed header")
+		}
+		pos += n
+
+		if header&1 == 0 {
+			width := int((bitWidth + 7) / 8)
+			if pos+width > len(buf) {
+				return errors.Wrap(io.ErrUnexpectedEOF, "io.ErrUnexpectedEOF")
+			}
+			var val uint32
+			for k := 0; k < width; k++ {
+				val |= uint32(buf[pos+k]) << uint(8*k)
+			}
+			pos += width
+			for cnt := header >> 1; cnt > 0 && i < len(dst); cnt-- {
+				dst[i] = int32(val)
+				i++
+			}
+
+		} else {
+			cnt := int(header>>1) * 8
+			end := pos + cnt*int(bitWidth)/8
+			if end > len(buf) {
+				end = len(buf)
+			}
+			if cnt > len(dst)-i {
+				cnt = len(dst) - i
+			}
+			unpackINT32s(buf[pos:end], bitWidth, dst[i:i+cnt])
+			i, pos = i+cnt, end
+		}
+	}
+	if i < len(dst) {
+		return errors.Wrap(io.ErrUnexpectedEOF, "io.ErrUnexpectedEOF")
+	}
+	return nil
+}
+
+//unpackINT32s decodes the bit-packed values of buf, the missing bits are 0
+func unpackINT32s(buf []byte, bitWidth uint64, dst []int32) {
+	mask := uint64(1)<<bitWidth - 1
+	for i := range dst {
+		bit := uint64(i) * bitWidth
+		var val uint64
+		for j, k := bit/8, uint64(0); k < bit%8+bitWidth && j < uint64(len(buf)); j, k = j+1, k+8 {
+			val |= uint64(buf[j]) << k
+		}
+		dst[i] = int32((val >> (bit % 8)) & mask)
+	}
+}
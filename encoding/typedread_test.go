@@ -0,0 +1,55 @@
+package encoding
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/sabey/parquet-go/parquet"
+)
+
+func TestReadPlainTyped(t *testing.T) {
+	bools := make([]bool, 3)
+	if err := ReadPlainBOOLEANs(bytes.NewReader(WritePlainBOOLEAN([]interface{}{true, false, true})), bools); err != nil || fmt.Sprint(bools) != "[true false true]" {
+		t.Errorf("ReadPlainBOOLEANs error, get %v, err info:%v", bools, err)
+	}
+
+	int64s := make([]int64, 3)
+	if err := ReadPlainINT64s(bytes.NewReader(WritePlainINT64([]interface{}{int64(-1), int64(0), int64(1 << 40)})), int64s); err != nil || fmt.Sprint(int64s) != "[-1 0 1099511627776]" {
+		t.Errorf("ReadPlainINT64s error, get %v, err info:%v", int64s, err)
+	}
+
+	float64s := make([]float64, 2)
+	if err := ReadPlainDOUBLEs(bytes.NewReader(WritePlainDOUBLE([]interface{}{1.5, -2.25})), float64s); err != nil || fmt.Sprint(float64s) != "[1.5 -2.25]" {
+		t.Errorf("ReadPlainDOUBLEs error, get %v, err info:%v", float64s, err)
+	}
+
+	bytesReader := bytes.NewReader(append(WritePlainBYTE_ARRAY([]interface{}{"a", "", "bcd"}), 1, 2))
+	arrays := make([][]byte, 3)
+	if err := ReadPlainBYTE_ARRAYs(bytesReader, arrays); err != nil || fmt.Sprintf("%q", arrays) != `["a" "" "bcd"]` || bytesReader.Len() != 2 {
+		t.Errorf("ReadPlainBYTE_ARRAYs error, get %q, err info:%v", arrays, err)
+	}
+
+	if err := ReadPlainINT32s(bytes.NewReader([]byte{0, 0, 0}), make([]int32, 1)); err == nil {
+		t.Errorf("ReadPlainINT32s error, no error for truncated data")
+	}
+}
+
+func TestReadRLEBitPackedHybridINT32s(t *testing.T) {
+	testData := [][]interface{}{
+		{int64(1), int64(2), int64(3), int64(4)},
+		{int64(0), int64(0), int64(0), int64(0), int64(0)},
+		{int64(5), int64(5), int64(5), int64(5), int64(5), int64(5), int64(5), int64(5), int64(5), int64(1), int64(7)},
+	}
+	for _, data := range testData {
+		for _, bitWidth := range []int32{3, 17} {
+			buf := WriteRLEBitPackedHybrid(data, bitWidth, parquet.Type_INT64)
+			expected, _ := ReadRLEBitPackedHybrid(bytes.NewReader(buf), uint64(bitWidth), 0)
+			res := make([]int32, len(data))
+			err := ReadRLEBitPackedHybridINT32s(bytes.NewReader(buf), uint64(bitWidth), 0, res)
+			if err != nil || fmt.Sprint(expected[:len(data)]) != fmt.Sprint(res) {
+				t.Errorf("ReadRLEBitPackedHybridINT32s error, expect %v, get %v, err info:%v", expected, res, err)
+			}
+		}
+	}
+}
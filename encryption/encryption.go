@@ -0,0 +1,229 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"io"
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+//Algorithm of the parquet modular encryption
+type Algorithm int
+
+const (
+	//All the modules are encrypted with AES GCM
+	AES_GCM_V1 Algorithm = iota
+	//The page data are encrypted with AES CTR, the other modules with AES GCM
+	AES_GCM_CTR_V1
+)
+
+//ModuleType is the type of an encrypted module, it's a part of the AAD
+type ModuleType int8
+
+const (
+	Footer ModuleType = iota
+	ColumnMetaData
+	DataPage
+	DictionaryPage
+	DataPageHeader
+	DictionaryPageHeader
+	ColumnIndex
+	OffsetIndex
+	BloomFilterHeader
+	BloomFilterBitset
+)
+
+const (
+	//Size of the length of a module
+	LengthSize = 4
+	//Size of the nonce of a module
+	NonceSize = 12
+	//Size of the GCM tag of a module
+	TagSize = 16
+	//Size of the unique part of the file AAD
+	AADFileUniqueSize = 8
+)
+
+//Get the AAD of a module. The ordinals are only used by the modules of column chunks
+//and the page ordinal only by the data pages and their headers.
+func ModuleAAD(fileAAD []byte, moduleType ModuleType, rowGroupOrdinal int16, columnOrdinal int16, pageOrdinal int16) []byte {
+	aad := make([]byte, len(fileAAD), len(fileAAD)+7)
+	copy(aad, fileAAD)
+	aad = append(aad, byte(moduleType))
+	if moduleType == Footer {
+		return aad
+	}
+	aad = appendInt16(aad, rowGroupOrdinal)
+	aad = appendInt16(aad, columnOrdinal)
+	if moduleType == DataPage || moduleType == DataPageHeader {
+		aad = appendInt16(aad, pageOrdinal)
+	}
+	return aad
+}
+
+func appendInt16(buf []byte, v int16) []byte {
+	return append(buf, byte(v), byte(uint16(v)>>8))
+}
+
+//Cipher encrypts and decrypts the modules of a file with one key
+type Cipher struct {
+	Algorithm Algorithm
+	FileAAD   []byte
+
+	block cipher.Block
+	gcm   cipher.AEAD
+}
+
+//Create a cipher, the key must have 16, 24 or 32 bytes
+func NewCipher(key []byte, algorithm Algorithm, fileAAD []byte) (*Cipher, error) {
+	if algorithm != AES_GCM_V1 && algorithm != AES_GCM_CTR_V1 {
+		return nil, errors.Errorf("unsupported encryption algorithm: %v", algorithm)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "aes.NewCipher")
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.Wrap(err, "cipher.NewGCM")
+	}
+	return &Cipher{
+		Algorithm: algorithm,
+		FileAAD:   fileAAD,
+		block:     block,
+		gcm:       gcm,
+	}, nil
+}
+
+//ctr reports whether the modules of the type are encrypted with AES CTR
+func (c *Cipher) ctr(moduleType ModuleType) bool {
+	return c.Algorithm == AES_GCM_CTR_V1 && (moduleType == DataPage || moduleType == DictionaryPage)
+}
+
+//Get the size added to a plaintext by the encryption of a module of the type
+func (c *Cipher) Overhead(moduleType ModuleType) int {
+	if c.ctr(moduleType) {
+		return LengthSize + NonceSize
+	}
+	return LengthSize + NonceSize + TagSize
+}
+
+//Encrypt a module: the result is the length, the nonce and the ciphertext
+func (c *Cipher) Encrypt(buf []byte, moduleType ModuleType, rowGroupOrdinal int16, columnOrdinal int16, pageOrdinal int16) ([]byte, error) {
+	size := len(buf) + c.Overhead(moduleType)
+	if size > math.MaxInt32 {
+		return nil, errors.Errorf("module is too large: %v", size)
+	}
+	res := make([]byte, LengthSize+NonceSize, size)
+	binary.LittleEndian.PutUint32(res, uint32(size-LengthSize))
+	nonce := res[LengthSize:]
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, errors.Wrap(err, "io.ReadFull")
+	}
+
+	if c.ctr(moduleType) {
+		res = res[:size]
+		cipher.NewCTR(c.block, ctrIV(nonce)).XORKeyStream(res[LengthSize+NonceSize:], buf)
+		return res, nil
+	}
+	aad := ModuleAAD(c.FileAAD, moduleType, rowGroupOrdinal, columnOrdinal, pageOrdinal)
+	return c.gcm.Seal(res, nonce, buf, aad), nil
+}
+
+//Decrypt a module written by Encrypt
+func (c *Cipher) Decrypt(module []byte, moduleType ModuleType, rowGroupOrdinal int16, columnOrdinal int16, pageOrdinal int16) ([]byte, error) {
+	if len(module) < c.Overhead(moduleType) {
+		return nil, errors.Errorf("module is too short: %v", len(module))
+	}
+	if size := binary.LittleEndian.Uint32(module); int64(size) != int64(len(module)-LengthSize) {
+		return nil, errors.Errorf("invalid module length: %v", size)
+	}
+	nonce := module[LengthSize : LengthSize+NonceSize]
+	ciphertext := module[LengthSize+NonceSize:]
+
+	if c.ctr(moduleType) {
+		res := make([]byte, len(ciphertext))
+		cipher.NewCTR(c.block, ctrIV(nonce)).XORKeyStream(res, ciphertext)
+		return res, nil
+	}
+	aad := ModuleAAD(c.FileAAD, moduleType, rowGroupOrdinal, columnOrdinal, pageOrdinal)
+	res, err := c.gcm.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return nil, errors.Wrap(err, "c.gcm.Open")
+	}
+	return res, nil
+}
+
+//ctrIV returns the IV of AES CTR: the nonce and a counter starting at 1
+func ctrIV(nonce []byte) []byte {
+	iv := make([]byte, aes.BlockSize)
+	copy(iv, nonce)
+	iv[aes.BlockSize-1] = 1
+	return iv
+}
+
+//Sign a plaintext footer: the signature is the nonce and the GCM tag of the footer
+func (c *Cipher) SignFooter(footer []byte) ([]byte, error) {
+	nonce := make([]byte, NonceSize)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, errors.Wrap(err, "io.ReadFull")
+	}
+	return append(nonce, c.footerTag(footer, nonce)...), nil
+}
+
+//Verify the signature of a plaintext footer
+func (c *Cipher) VerifyFooter(footer []byte, signature []byte) error {
+	if len(signature) != NonceSize+TagSize {
+		return errors.Errorf("invalid footer signature length: %v", len(signature))
+	}
+	tag := c.footerTag(footer, signature[:NonceSize])
+	if subtle.ConstantTimeCompare(tag, signature[NonceSize:]) != 1 {
+		return errors.New("footer signature mismatch")
+	}
+	return nil
+}
+
+func (c *Cipher) footerTag(footer []byte, nonce []byte) []byte {
+	sealed := c.gcm.Seal(nil, nonce, footer, ModuleAAD(c.FileAAD, Footer, 0, 0, 0))
+	return sealed[len(sealed)-TagSize:]
+}
+
+//ChunkCipher encrypts and decrypts the modules of a column chunk
+type ChunkCipher struct {
+	Cipher          *Cipher
+	RowGroupOrdinal int16
+	ColumnOrdinal   int16
+}
+
+//Encrypt a module of the column chunk, the page ordinal is only used by data pages
+func (c *ChunkCipher) Encrypt(buf []byte, moduleType ModuleType, pageOrdinal int16) ([]byte, error) {
+	return c.Cipher.Encrypt(buf, moduleType, c.RowGroupOrdinal, c.ColumnOrdinal, pageOrdinal)
+}
+
+//Decrypt a module of the column chunk, the page ordinal is only used by data pages
+func (c *ChunkCipher) Decrypt(module []byte, moduleType ModuleType, pageOrdinal int16) ([]byte, error) {
+	return c.Cipher.Decrypt(module, moduleType, c.RowGroupOrdinal, c.ColumnOrdinal, pageOrdinal)
+}
+
+//Read a whole module from r, including its length
+func ReadModule(r io.Reader) ([]byte, error) {
+	var lengthBuf [LengthSize]byte
+	if _, err := io.ReadFull(r, lengthBuf[:]); err != nil {
+		return nil, errors.Wrap(err, "io.ReadFull")
+	}
+	size := binary.LittleEndian.Uint32(lengthBuf[:])
+	if size > math.MaxInt32-LengthSize {
+		return nil, errors.Errorf("invalid module length: %v", size)
+	}
+	module := make([]byte, LengthSize+int(size))
+	copy(module, lengthBuf[:])
+	if _, err := io.ReadFull(r, module[LengthSize:]); err != nil {
+		return nil, errors.Wrap(err, "io.ReadFull")
+	}
+	return module, nil
+}
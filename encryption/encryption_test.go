@@ -0,0 +1,49 @@
+package encryption
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModuleAAD(t *testing.T) {
+	fileAAD := []byte("aad")
+	assert.Equal(t, []byte("aad\x00"), ModuleAAD(fileAAD, Footer, 1, 2, 3))
+	assert.Equal(t, []byte("aad\x01\x01\x00\x02\x00"), ModuleAAD(fileAAD, ColumnMetaData, 1, 2, 3))
+	assert.Equal(t, []byte("aad\x02\x01\x00\x02\x00\x03\x01"), ModuleAAD(fileAAD, DataPage, 1, 2, 259))
+	assert.Equal(t, []byte("aad"), fileAAD)
+}
+
+func TestCipher(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	plaintext := []byte("parquet modular encryption")
+
+	for _, algorithm := range []Algorithm{AES_GCM_V1, AES_GCM_CTR_V1} {
+		c, err := NewCipher(key, algorithm, []byte("file"))
+		assert.Nil(t, err)
+		for _, moduleType := range []ModuleType{DataPage, DataPageHeader, DictionaryPage, ColumnIndex} {
+			module, err := c.Encrypt(plaintext, moduleType, 1, 2, 3)
+			assert.Nil(t, err)
+			assert.Equal(t, len(plaintext)+c.Overhead(moduleType), len(module))
+
+			res, err := c.Decrypt(module, moduleType, 1, 2, 3)
+			assert.Nil(t, err)
+			assert.Equal(t, plaintext, res)
+
+			//the AAD binds the GCM modules to their place in the file
+			_, err = c.Decrypt(module, moduleType, 1, 2, 4)
+			assert.Equal(t, c.ctr(moduleType) || moduleType != DataPage && moduleType != DataPageHeader, err == nil)
+			_, err = c.Decrypt(module, moduleType, 1, 3, 3)
+			assert.Equal(t, c.ctr(moduleType), err == nil)
+		}
+	}
+
+	c, _ := NewCipher(key, AES_GCM_V1, []byte("file"))
+	signature, err := c.SignFooter(plaintext)
+	assert.Nil(t, err)
+	assert.Nil(t, c.VerifyFooter(plaintext, signature))
+	assert.NotNil(t, c.VerifyFooter(plaintext[1:], signature))
+
+	_, err = NewCipher([]byte("short"), AES_GCM_V1, nil)
+	assert.NotNil(t, err)
+}
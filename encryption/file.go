@@ -0,0 +1,297 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"io"
+	"sync"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/pkg/errors"
+	"github.com/sabey/parquet-go/parquet"
+)
+
+//KeyRetriever finds the keys of a file from the key metadata stored in it
+type KeyRetriever interface {
+	RetrieveKey(keyMetadata []byte) ([]byte, error)
+}
+
+//KeyMap is a KeyRetriever with the keys by their metadata
+type KeyMap map[string][]byte
+
+func (m KeyMap) RetrieveKey(keyMetadata []byte) ([]byte, error) {
+	key, ok := m[string(keyMetadata)]
+	if !ok {
+		return nil, errors.Errorf("key not found: %q", keyMetadata)
+	}
+	return key, nil
+}
+
+//ColumnKey is the key of an encrypted column
+type ColumnKey struct {
+	Key []byte
+	//Metadata stored in the file, readers get the key from it with a KeyRetriever
+	KeyMetadata []byte
+}
+
+//FileEncryptionProperties configures the encryption of a file
+type FileEncryptionProperties struct {
+	Algorithm Algorithm
+	//Key of the footer and of the columns without their own key
+	FooterKey         []byte
+	FooterKeyMetadata []byte
+	//The footer isn't encrypted but signed with the footer key, so the plaintext columns
+	//can be read without keys
+	PlaintextFooter bool
+	//Keys of the columns by path. If it's empty all the columns are encrypted with the footer key,
+	//otherwise only the columns in it are encrypted.
+	ColumnKeys map[string]*ColumnKey
+	//Prefix of the AAD, e.g. the file name, to detect files which are replaced
+	AADPrefix []byte
+	//The AAD prefix isn't stored in the file and readers must supply it
+	SupplyAADPrefix bool
+}
+
+//FileDecryptionProperties configures the decryption of a file
+type FileDecryptionProperties struct {
+	KeyRetriever KeyRetriever
+	//AAD prefix of the files written with SupplyAADPrefix
+	AADPrefix []byte
+}
+
+//FileEncryptor encrypts a file with its properties
+type FileEncryptor struct {
+	Properties          *FileEncryptionProperties
+	EncryptionAlgorithm *parquet.EncryptionAlgorithm
+
+	footerCipher  *Cipher
+	columnCiphers map[string]*Cipher
+}
+
+//Create a file encryptor, a random unique AAD is generated for the file
+func NewFileEncryptor(properties *FileEncryptionProperties) (*FileEncryptor, error) {
+	aadFileUnique := make([]byte, AADFileUniqueSize)
+	if _, err := io.ReadFull(rand.Reader, aadFileUnique); err != nil {
+		return nil, errors.Wrap(err, "io.ReadFull")
+	}
+	fileAAD := append(append([]byte{}, properties.AADPrefix...), aadFileUnique...)
+
+	aesGcm := parquet.NewAesGcmV1()
+	aesGcm.AadFileUnique = aadFileUnique
+	aesGcmCtr := parquet.NewAesGcmCtrV1()
+	aesGcmCtr.AadFileUnique = aadFileUnique
+	if len(properties.AADPrefix) > 0 {
+		if properties.SupplyAADPrefix {
+			aesGcm.SupplyAadPrefix = &properties.SupplyAADPrefix
+			aesGcmCtr.SupplyAadPrefix = &properties.SupplyAADPrefix
+		} else {
+			aesGcm.AadPrefix = properties.AADPrefix
+			aesGcmCtr.AadPrefix = properties.AADPrefix
+		}
+	}
+	encryptionAlgorithm := parquet.NewEncryptionAlgorithm()
+	if properties.Algorithm == AES_GCM_CTR_V1 {
+		encryptionAlgorithm.AES_GCM_CTR_V1 = aesGcmCtr
+	} else {
+		encryptionAlgorithm.AES_GCM_V1 = aesGcm
+	}
+
+	footerCipher, err := NewCipher(properties.FooterKey, properties.Algorithm, fileAAD)
+	if err != nil {
+		return nil, errors.Wrap(err, "NewCipher")
+	}
+	columnCiphers := make(map[string]*Cipher)
+	for path, columnKey := range properties.ColumnKeys {
+		if columnCiphers[path], err = NewCipher(columnKey.Key, properties.Algorithm, fileAAD); err != nil {
+			return nil, errors.Wrap(err, "NewCipher")
+		}
+	}
+
+	return &FileEncryptor{
+		Properties:          properties,
+		EncryptionAlgorithm: encryptionAlgorithm,
+		footerCipher:        footerCipher,
+		columnCiphers:       columnCiphers,
+	}, nil
+}
+
+//Get the cipher of the footer
+func (e *FileEncryptor) FooterCipher() *Cipher {
+	return e.footerCipher
+}
+
+//Get the cipher and the crypto metadata of a column by its path in ColumnKeys, nil if the column isn't encrypted.
+//All the columns are encrypted with the footer key when there are no column keys, then path is ignored.
+//The PathInSchema of a column key is left to the caller.
+func (e *FileEncryptor) ColumnCipher(path string) (*Cipher, *parquet.ColumnCryptoMetaData) {
+	cryptoMetaData := parquet.NewColumnCryptoMetaData()
+	if len(e.columnCiphers) == 0 {
+		cryptoMetaData.ENCRYPTION_WITH_FOOTER_KEY = parquet.NewEncryptionWithFooterKey()
+		return e.footerCipher, cryptoMetaData
+	}
+	columnCipher, ok := e.columnCiphers[path]
+	if !ok {
+		return nil, nil
+	}
+	cryptoMetaData.ENCRYPTION_WITH_COLUMN_KEY = parquet.NewEncryptionWithColumnKey()
+	cryptoMetaData.ENCRYPTION_WITH_COLUMN_KEY.KeyMetadata = e.Properties.ColumnKeys[path].KeyMetadata
+	return columnCipher, cryptoMetaData
+}
+
+//Get the magic number of the file, which is "PARE" when the footer is encrypted
+func (e *FileEncryptor) Magic() []byte {
+	if e.Properties.PlaintextFooter {
+		return []byte("PAR1")
+	}
+	return []byte("PARE")
+}
+
+//Encrypt the serialized footer. An encrypted footer is preceded by the FileCryptoMetaData,
+//a plaintext footer is followed by its signature.
+func (e *FileEncryptor) EncryptFooter(footer []byte) ([]byte, error) {
+	if e.Properties.PlaintextFooter {
+		signature, err := e.footerCipher.SignFooter(footer)
+		if err != nil {
+			return nil, errors.Wrap(err, "e.footerCipher.SignFooter")
+		}
+		return append(footer, signature...), nil
+	}
+
+	cryptoMetaData := parquet.NewFileCryptoMetaData()
+	cryptoMetaData.EncryptionAlgorithm = e.EncryptionAlgorithm
+	cryptoMetaData.KeyMetadata = e.Properties.FooterKeyMetadata
+	ts := thrift.NewTSerializer()
+	ts.Protocol = thrift.NewTCompactProtocolFactory().GetProtocol(ts.Transport)
+	cryptoMetaDataBuf, err := ts.Write(context.TODO(), cryptoMetaData)
+	if err != nil {
+		return nil, errors.Wrap(err, "ts.Write")
+	}
+	module, err := e.footerCipher.Encrypt(footer, Footer, 0, 0, 0)
+	if err != nil {
+		return nil, errors.Wrap(err, "e.footerCipher.Encrypt")
+	}
+	return append(cryptoMetaDataBuf, module...), nil
+}
+
+//FileDecryptor decrypts a file with its properties
+type FileDecryptor struct {
+	Properties *FileDecryptionProperties
+	Algorithm  Algorithm
+	FileAAD    []byte
+
+	footerKeyMetadata []byte
+	mutex             sync.Mutex
+	ciphers           map[string]*Cipher
+}
+
+//Create a file decryptor from the encryption algorithm and the footer key metadata stored in the file
+func NewFileDecryptor(properties *FileDecryptionProperties, encryptionAlgorithm *parquet.EncryptionAlgorithm, footerKeyMetadata []byte) (*FileDecryptor, error) {
+	if properties == nil || properties.KeyRetriever == nil {
+		return nil, errors.New("the file is encrypted, no key retriever")
+	}
+
+	var algorithm Algorithm
+	var aadPrefix, aadFileUnique []byte
+	var supplyAADPrefix bool
+	if encryptionAlgorithm == nil {
+		return nil, errors.New("no encryption algorithm")
+	} else if encryptionAlgorithm.IsSetAES_GCM_V1() {
+		algorithm = AES_GCM_V1
+		aadPrefix = encryptionAlgorithm.AES_GCM_V1.AadPrefix
+		aadFileUnique = encryptionAlgorithm.AES_GCM_V1.AadFileUnique
+		supplyAADPrefix = encryptionAlgorithm.AES_GCM_V1.GetSupplyAadPrefix()
+	} else if encryptionAlgorithm.IsSetAES_GCM_CTR_V1() {
+		algorithm = AES_GCM_CTR_V1
+		aadPrefix = encryptionAlgorithm.AES_GCM_CTR_V1.AadPrefix
+		aadFileUnique = encryptionAlgorithm.AES_GCM_CTR_V1.AadFileUnique
+		supplyAADPrefix = encryptionAlgorithm.AES_GCM_CTR_V1.GetSupplyAadPrefix()
+	} else {
+		return nil, errors.Errorf("unsupported encryption algorithm: %v", encryptionAlgorithm)
+	}
+
+	if len(properties.AADPrefix) > 0 {
+		if len(aadPrefix) > 0 && !bytes.Equal(aadPrefix, properties.AADPrefix) {
+			return nil, errors.New("AAD prefix mismatch")
+		}
+		aadPrefix = properties.AADPrefix
+	} else if supplyAADPrefix {
+		return nil, errors.New("the file needs an AAD prefix")
+	}
+
+	return &FileDecryptor{
+		Properties:        properties,
+		Algorithm:         algorithm,
+		FileAAD:           append(append([]byte{}, aadPrefix...), aadFileUnique...),
+		footerKeyMetadata: footerKeyMetadata,
+		ciphers:           make(map[string]*Cipher),
+	}, nil
+}
+
+//cipher returns the cipher of the key with the metadata, the ciphers are cached
+func (d *FileDecryptor) cipher(keyMetadata []byte) (*Cipher, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if c, ok := d.ciphers[string(keyMetadata)]; ok {
+		return c, nil
+	}
+	key, err := d.Properties.KeyRetriever.RetrieveKey(keyMetadata)
+	if err != nil {
+		return nil, errors.Wrap(err, "d.Properties.KeyRetriever.RetrieveKey")
+	}
+	c, err := NewCipher(key, d.Algorithm, d.FileAAD)
+	if err != nil {
+		return nil, errors.Wrap(err, "NewCipher")
+	}
+	d.ciphers[string(keyMetadata)] = c
+	return c, nil
+}
+
+//Get the cipher of the footer
+func (d *FileDecryptor) FooterCipher() (*Cipher, error) {
+	return d.cipher(d.footerKeyMetadata)
+}
+
+//Get the cipher of a column from its crypto metadata
+func (d *FileDecryptor) ColumnCipher(cryptoMetaData *parquet.ColumnCryptoMetaData) (*Cipher, error) {
+	if cryptoMetaData.IsSetENCRYPTION_WITH_COLUMN_KEY() {
+		return d.cipher(cryptoMetaData.ENCRYPTION_WITH_COLUMN_KEY.KeyMetadata)
+	}
+	return d.FooterCipher()
+}
+
+//Decrypt an encrypted footer, buf is the FileCryptoMetaData followed by the footer module
+func DecryptFooter(properties *FileDecryptionProperties, buf []byte) (*FileDecryptor, *parquet.FileMetaData, error) {
+	transport := thrift.NewTMemoryBufferLen(len(buf))
+	transport.Write(buf)
+	cryptoMetaData := parquet.NewFileCryptoMetaData()
+	if err := cryptoMetaData.Read(context.TODO(), thrift.NewTCompactProtocol(transport)); err != nil {
+		return nil, nil, errors.Wrap(err, "cryptoMetaData.Read")
+	}
+
+	fileDecryptor, err := NewFileDecryptor(properties, cryptoMetaData.EncryptionAlgorithm, cryptoMetaData.KeyMetadata)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "NewFileDecryptor")
+	}
+	footerCipher, err := fileDecryptor.FooterCipher()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "fileDecryptor.FooterCipher")
+	}
+	footerBuf, err := footerCipher.Decrypt(transport.Bytes(), Footer, 0, 0, 0)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "footerCipher.Decrypt")
+	}
+
+	footer := parquet.NewFileMetaData()
+	if err = Deserialize(footer, footerBuf); err != nil {
+		return nil, nil, errors.Wrap(err, "Deserialize")
+	}
+	return fileDecryptor, footer, nil
+}
+
+//Deserialize a thrift struct in the compact protocol
+func Deserialize(obj thrift.TStruct, buf []byte) error {
+	td := thrift.NewTDeserializer()
+	td.Protocol = thrift.NewTCompactProtocolFactory().GetProtocol(td.Transport)
+	return td.Read(context.TODO(), obj, buf)
+}
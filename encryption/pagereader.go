@@ -0,0 +1,98 @@
+package encryption
+
+import (
+	"context"
+	"io"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/pkg/errors"
+	"github.com/sabey/parquet-go/parquet"
+)
+
+//PageReader decrypts the pages of a column chunk. It returns the plaintext pages,
+//whose headers have the sizes of the plaintext data.
+type PageReader struct {
+	r           io.Reader
+	chunkCipher *ChunkCipher
+	pageOrdinal int16
+	dictionary  bool
+	header      *parquet.PageHeader
+	buf         []byte
+	serializer  *thrift.TSerializer
+}
+
+//Create a page reader from the first page at r. The page ordinal is the number of data pages
+//before it, dictionary tells whether it's a dictionary page.
+func NewPageReader(r io.Reader, chunkCipher *ChunkCipher, pageOrdinal int16, dictionary bool) *PageReader {
+	ts := thrift.NewTSerializer()
+	ts.Protocol = thrift.NewTCompactProtocolFactory().GetProtocol(ts.Transport)
+	return &PageReader{
+		r:           r,
+		chunkCipher: chunkCipher,
+		pageOrdinal: pageOrdinal,
+		dictionary:  dictionary,
+		serializer:  ts,
+	}
+}
+
+func (pr *PageReader) Read(p []byte) (int, error) {
+	for len(pr.buf) == 0 {
+		if err := pr.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, pr.buf)
+	pr.buf = pr.buf[n:]
+	return n, nil
+}
+
+//next decrypts the next module, which is a page header or the data of a page
+func (pr *PageReader) next() error {
+	module, err := ReadModule(pr.r)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return io.EOF
+		}
+		return errors.Wrap(err, "ReadModule")
+	}
+
+	if pr.header == nil {
+		moduleType := DataPageHeader
+		if pr.dictionary {
+			moduleType = DictionaryPageHeader
+		}
+		buf, err := pr.chunkCipher.Decrypt(module, moduleType, pr.pageOrdinal)
+		if err != nil {
+			return errors.Wrap(err, "pr.chunkCipher.Decrypt")
+		}
+		header := parquet.NewPageHeader()
+		if err = Deserialize(header, buf); err != nil {
+			return errors.Wrap(err, "Deserialize")
+		}
+
+		dataType := DataPage
+		if header.GetType() == parquet.PageType_DICTIONARY_PAGE {
+			dataType = DictionaryPage
+		}
+		header.CompressedPageSize -= int32(pr.chunkCipher.Cipher.Overhead(dataType))
+		if pr.buf, err = pr.serializer.Write(context.TODO(), header); err != nil {
+			return errors.Wrap(err, "pr.serializer.Write")
+		}
+		pr.header = header
+		return nil
+	}
+
+	moduleType := DataPage
+	if pr.header.GetType() == parquet.PageType_DICTIONARY_PAGE {
+		moduleType = DictionaryPage
+	}
+	if pr.buf, err = pr.chunkCipher.Decrypt(module, moduleType, pr.pageOrdinal); err != nil {
+		return errors.Wrap(err, "pr.chunkCipher.Decrypt")
+	}
+	if moduleType == DataPage {
+		pr.pageOrdinal++
+	}
+	pr.dictionary = false
+	pr.header = nil
+	return nil
+}
@@ -3,6 +3,7 @@ module github.com/sabey/parquet-go
 go 1.16
 
 require (
+	github.com/andybalholm/brotli v1.0.4
 	github.com/apache/arrow/go/arrow v0.0.0-20200730104253-651201b0f516
 	github.com/apache/thrift v0.14.2
 	github.com/aws/aws-sdk-go v1.30.19
@@ -0,0 +1,147 @@
+package layout
+
+import (
+	"context"
+	"io"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/pkg/errors"
+	"github.com/sabey/parquet-go/bloomfilter"
+	"github.com/sabey/parquet-go/encryption"
+	"github.com/sabey/parquet-go/parquet"
+	"github.com/sabey/parquet-go/source"
+)
+
+//maxBloomFilterHeaderSize bounds the bytes read for the header of a Bloom filter without bloom_filter_length
+const maxBloomFilterHeaderSize = 4 * 1024
+
+//Read the Bloom filter of a column chunk, nil is returned if the chunk has none.
+//chunkCipher decrypts the Bloom filter of an encrypted chunk, it's nil for plaintext chunks.
+func ReadBloomFilter(pFile source.ParquetFile, columnChunk *parquet.ColumnChunk, chunkCipher *encryption.ChunkCipher) (*bloomfilter.Filter, error) {
+	metaData := columnChunk.GetMetaData()
+	if metaData == nil || !metaData.IsSetBloomFilterOffset() {
+		return nil, nil
+	}
+
+	var err error
+	var buf []byte
+	header := parquet.NewBloomFilterHeader()
+	if chunkCipher != nil {
+		if header, buf, err = readEncryptedBloomFilter(pFile, metaData.GetBloomFilterOffset(), chunkCipher); err != nil {
+			return nil, errors.Wrap(err, "readEncryptedBloomFilter")
+		}
+		if err = checkBloomFilterHeader(header); err != nil {
+			return nil, err
+		}
+
+	} else {
+		size, err := bloomFilterSize(pFile, metaData)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = pFile.Seek(metaData.GetBloomFilterOffset(), io.SeekStart); err != nil {
+			return nil, errors.Wrap(err, "pFile.Seek")
+		}
+		window := make([]byte, size)
+		if _, err = io.ReadFull(pFile, window); err != nil {
+			return nil, errors.Wrap(err, "io.ReadFull")
+		}
+
+		transport := thrift.NewTMemoryBufferLen(len(window))
+		transport.Write(window)
+		if err = header.Read(context.TODO(), thrift.NewTCompactProtocol(transport)); err != nil {
+			return nil, errors.Wrap(err, "header.Read")
+		}
+		if err = checkBloomFilterHeader(header); err != nil {
+			return nil, err
+		}
+
+		//the window holds the whole Bloom filter when its length is known,
+		//otherwise the rest of the bitset follows it
+		buf = transport.Bytes()
+		if !metaData.IsSetBloomFilterLength() {
+			if len(buf) > int(header.NumBytes) {
+				buf = buf[:header.NumBytes]
+			} else if len(buf) < int(header.NumBytes) {
+				rest := make([]byte, int(header.NumBytes)-len(buf))
+				if _, err = io.ReadFull(pFile, rest); err != nil {
+					return nil, errors.Wrap(err, "io.ReadFull")
+				}
+				buf = append(buf, rest...)
+			}
+		}
+	}
+
+	if len(buf) != int(header.NumBytes) {
+		return nil, errors.Errorf("invalid bloom filter size: %v", len(buf))
+	}
+	bloomFilter, err := bloomfilter.NewFromBytes(buf)
+	if err != nil {
+		return nil, errors.Wrap(err, "bloomfilter.NewFromBytes")
+	}
+	return bloomFilter, nil
+}
+
+//bloomFilterSize returns the number of bytes read for the header of a plaintext Bloom filter:
+//its bloom_filter_length if it's set, otherwise at most 4KB before the end of the file
+func bloomFilterSize(pFile source.ParquetFile, metaData *parquet.ColumnMetaData) (int64, error) {
+	if metaData.IsSetBloomFilterLength() {
+		length := int64(metaData.GetBloomFilterLength())
+		if length <= 0 || length > bloomfilter.MaxBytes+maxBloomFilterHeaderSize {
+			return 0, errors.Errorf("invalid bloom filter length: %v", length)
+		}
+		return length, nil
+	}
+
+	fileSize, err := pFile.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, errors.Wrap(err, "pFile.Seek")
+	}
+	size := fileSize - metaData.GetBloomFilterOffset()
+	if size <= 0 {
+		return 0, errors.Errorf("invalid bloom filter offset: %v", metaData.GetBloomFilterOffset())
+	}
+	if size > maxBloomFilterHeaderSize {
+		size = maxBloomFilterHeaderSize
+	}
+	return size, nil
+}
+
+//checkBloomFilterHeader checks that the Bloom filter is supported
+func checkBloomFilterHeader(header *parquet.BloomFilterHeader) error {
+	if !header.Algorithm.IsSetBLOCK() || !header.Hash.IsSetXXHASH() || !header.Compression.IsSetUNCOMPRESSED() {
+		return errors.Errorf("unsupported bloom filter: %v", header)
+	}
+	if header.NumBytes < bloomfilter.MinBytes || header.NumBytes > bloomfilter.MaxBytes {
+		return errors.Errorf("invalid bloom filter size: %v", header.NumBytes)
+	}
+	return nil
+}
+
+//readEncryptedBloomFilter reads the header and the bitset of a Bloom filter, which are two modules
+func readEncryptedBloomFilter(pFile source.ParquetFile, offset int64, chunkCipher *encryption.ChunkCipher) (*parquet.BloomFilterHeader, []byte, error) {
+	if _, err := pFile.Seek(offset, io.SeekStart); err != nil {
+		return nil, nil, errors.Wrap(err, "pFile.Seek")
+	}
+	module, err := encryption.ReadModule(pFile)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "encryption.ReadModule")
+	}
+	headerBuf, err := chunkCipher.Decrypt(module, encryption.BloomFilterHeader, 0)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "chunkCipher.Decrypt")
+	}
+	header := parquet.NewBloomFilterHeader()
+	if err = encryption.Deserialize(header, headerBuf); err != nil {
+		return nil, nil, errors.Wrap(err, "encryption.Deserialize")
+	}
+
+	if module, err = encryption.ReadModule(pFile); err != nil {
+		return nil, nil, errors.Wrap(err, "encryption.ReadModule")
+	}
+	buf, err := chunkCipher.Decrypt(module, encryption.BloomFilterBitset, 0)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "chunkCipher.Decrypt")
+	}
+	return header, buf, nil
+}
@@ -0,0 +1,86 @@
+package layout
+
+import (
+	"context"
+	"testing"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/sabey/parquet-go-source/buffer"
+	"github.com/sabey/parquet-go/bloomfilter"
+	"github.com/sabey/parquet-go/parquet"
+)
+
+//writeBloomFilter returns a file holding prefix, the header and the bitset of a filter of numBytes and suffix,
+//and the column chunk of the filter
+func writeBloomFilter(t *testing.T, numBytes int32, prefix []byte, suffix []byte) ([]byte, *parquet.ColumnChunk) {
+	filter := bloomfilter.New(numBytes)
+	hash, err := bloomfilter.Hash(int64(42))
+	if err != nil {
+		t.Fatal(err)
+	}
+	filter.Insert(hash)
+
+	ts := thrift.NewTSerializer()
+	ts.Protocol = thrift.NewTCompactProtocolFactory().GetProtocol(ts.Transport)
+	headerBuf, err := ts.Write(context.TODO(), filter.Header())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	offset := int64(len(prefix))
+	length := int32(len(headerBuf) + len(filter.Bytes()))
+	file := append(append(append(append([]byte{}, prefix...), headerBuf...), filter.Bytes()...), suffix...)
+	metaData := parquet.NewColumnMetaData()
+	metaData.BloomFilterOffset = &offset
+	metaData.BloomFilterLength = &length
+	return file, &parquet.ColumnChunk{MetaData: metaData}
+}
+
+func TestReadBloomFilter(t *testing.T) {
+	testData := []struct {
+		Name      string
+		NumBytes  int32
+		Suffix    []byte
+		HasLength bool
+	}{
+		{"length", 32, make([]byte, 8*1024), true},
+		{"no length at the end of the file", 32, nil, false},
+		{"no length before other data", 32, make([]byte, 8*1024), false},
+		{"no length larger than the header window", 16 * 1024, nil, false},
+	}
+
+	for _, data := range testData {
+		file, chunk := writeBloomFilter(t, data.NumBytes, []byte("PAR1"), data.Suffix)
+		if !data.HasLength {
+			chunk.MetaData.BloomFilterLength = nil
+		}
+		filter, err := ReadBloomFilter(buffer.NewBufferFileFromBytes(file), chunk, nil)
+		if err != nil {
+			t.Errorf("%s: %v", data.Name, err)
+			continue
+		}
+		hash, _ := bloomfilter.Hash(int64(42))
+		if len(filter.Bytes()) != int(data.NumBytes) || !filter.Check(hash) {
+			t.Errorf("%s: wrong filter", data.Name)
+		}
+	}
+}
+
+func TestReadBloomFilterInvalidLength(t *testing.T) {
+	file, chunk := writeBloomFilter(t, 32, []byte("PAR1"), make([]byte, 64))
+	length := chunk.MetaData.GetBloomFilterLength()
+
+	for _, invalid := range []int32{0, length - 1, length + 1} {
+		chunk.MetaData.BloomFilterLength = &invalid
+		if _, err := ReadBloomFilter(buffer.NewBufferFileFromBytes(file), chunk, nil); err == nil {
+			t.Errorf("ReadBloomFilter should fail with the length %v", invalid)
+		}
+	}
+
+	offset := int64(len(file))
+	chunk.MetaData.BloomFilterOffset = &offset
+	chunk.MetaData.BloomFilterLength = nil
+	if _, err := ReadBloomFilter(buffer.NewBufferFileFromBytes(file), chunk, nil); err == nil {
+		t.Error("ReadBloomFilter should fail with an offset at the end of the file")
+	}
+}
@@ -0,0 +1,68 @@
+package layout
+
+import (
+	"context"
+	"fmt"
+	"hash/crc32"
+	"strings"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/pkg/errors"
+	"github.com/sabey/parquet-go/common"
+	"github.com/sabey/parquet-go/parquet"
+)
+
+//CorruptPageError is returned when the CRC32 of the data of a page doesn't match the Crc of its header
+type CorruptPageError struct {
+	Path     string
+	PageType parquet.PageType
+	Crc      uint32
+	Checksum uint32
+}
+
+func (e *CorruptPageError) Error() string {
+	return fmt.Sprintf("corrupt %v of column %v: crc %08x, checksum %08x",
+		e.PageType, strings.Replace(e.Path, common.PAR_GO_PATH_DELIMITER, ".", -1), e.Crc, e.Checksum)
+}
+
+//SetChecksum sets the Crc of the header of a compressed page to the CRC32 of its data.
+//It must be called before the page is encrypted.
+func (page *Page) SetChecksum() error {
+	headerSize := len(page.RawData) - int(page.Header.CompressedPageSize)
+	if headerSize < 0 {
+		return errors.Errorf("invalid compressed page size %v", page.Header.CompressedPageSize)
+	}
+	data := page.RawData[headerSize:]
+	crc := int32(crc32.ChecksumIEEE(data))
+	page.Header.Crc = &crc
+
+	ts := thrift.NewTSerializer()
+	ts.Protocol = thrift.NewTCompactProtocolFactory().GetProtocol(ts.Transport)
+	pageHeaderBuf, err := ts.Write(context.TODO(), page.Header)
+	if err != nil {
+		return errors.Wrap(err, "ts.Write")
+	}
+	page.RawData = append(pageHeaderBuf, data...)
+	return nil
+}
+
+//verifyChecksum checks the data of a page with the Crc of its header, if it's set.
+//The data of a page can be passed in several buffers.
+func verifyChecksum(pageHeader *parquet.PageHeader, path []string, data ...[]byte) error {
+	if !pageHeader.IsSetCrc() {
+		return nil
+	}
+	var checksum uint32
+	for _, buf := range data {
+		checksum = crc32.Update(checksum, crc32.IEEETable, buf)
+	}
+	if crc := uint32(pageHeader.GetCrc()); crc != checksum {
+		return &CorruptPageError{
+			Path:     common.PathToStr(path),
+			PageType: pageHeader.GetType(),
+			Crc:      crc,
+			Checksum: checksum,
+		}
+	}
+	return nil
+}
@@ -48,10 +48,7 @@ func PagesToChunk(pages []*Page) *Chunk {
 	chunk.ChunkHeader = parquet.NewColumnChunk()
 	metaData := parquet.NewColumnMetaData()
 	metaData.Type = *pages[0].Schema.Type
-	metaData.Encodings = append(metaData.Encodings, parquet.Encoding_RLE)
-	metaData.Encodings = append(metaData.Encodings, parquet.Encoding_BIT_PACKED)
-	metaData.Encodings = append(metaData.Encodings, parquet.Encoding_PLAIN)
-	//metaData.Encodings = append(metaData.Encodings, parquet.Encoding_DELTA_BINARY_PACKED)
+	metaData.Encodings, metaData.EncodingStats = PagesEncodings(pages)
 	metaData.Codec = pages[0].CompressType
 	metaData.NumValues = numValues
 	metaData.TotalCompressedSize = totalCompressedSize
@@ -115,11 +112,7 @@ func PagesToDictChunk(pages []*Page) *Chunk {
 	chunk.ChunkHeader = parquet.NewColumnChunk()
 	metaData := parquet.NewColumnMetaData()
 	metaData.Type = *pages[1].Schema.Type
-	metaData.Encodings = append(metaData.Encodings, parquet.Encoding_RLE)
-	metaData.Encodings = append(metaData.Encodings, parquet.Encoding_BIT_PACKED)
-	metaData.Encodings = append(metaData.Encodings, parquet.Encoding_PLAIN)
-	metaData.Encodings = append(metaData.Encodings, parquet.Encoding_PLAIN_DICTIONARY)
-	metaData.Encodings = append(metaData.Encodings, parquet.Encoding_RLE_DICTIONARY)
+	metaData.Encodings, metaData.EncodingStats = PagesEncodings(pages)
 
 	metaData.Codec = pages[1].CompressType
 	metaData.NumValues = numValues
@@ -149,6 +142,50 @@ func PagesToDictChunk(pages []*Page) *Chunk {
 	return chunk
 }
 
+//PagesEncodings returns the encodings used by the pages of a chunk, the encodings of the levels included,
+//and the number of pages of each page type and encoding of the values
+func PagesEncodings(pages []*Page) ([]parquet.Encoding, []*parquet.PageEncodingStats) {
+	encodings := make([]parquet.Encoding, 0)
+	addEncoding := func(encoding parquet.Encoding) {
+		for _, e := range encodings {
+			if e == encoding {
+				return
+			}
+		}
+		encodings = append(encodings, encoding)
+	}
+	encodingStats := make([]*parquet.PageEncodingStats, 0)
+	addPage := func(pageType parquet.PageType, encoding parquet.Encoding) {
+		addEncoding(encoding)
+		for _, stats := range encodingStats {
+			if stats.PageType == pageType && stats.Encoding == encoding {
+				stats.Count++
+				return
+			}
+		}
+		stats := parquet.NewPageEncodingStats()
+		stats.PageType, stats.Encoding, stats.Count = pageType, encoding, 1
+		encodingStats = append(encodingStats, stats)
+	}
+
+	for _, page := range pages {
+		header := page.Header
+		switch {
+		case header.DictionaryPageHeader != nil:
+			addPage(parquet.PageType_DICTIONARY_PAGE, header.DictionaryPageHeader.Encoding)
+		case header.DataPageHeader != nil:
+			addEncoding(header.DataPageHeader.RepetitionLevelEncoding)
+			addEncoding(header.DataPageHeader.DefinitionLevelEncoding)
+			addPage(parquet.PageType_DATA_PAGE, header.DataPageHeader.Encoding)
+		case header.DataPageHeaderV2 != nil:
+			//the levels of the v2 pages are always RLE encoded
+			addEncoding(parquet.Encoding_RLE)
+			addPage(parquet.PageType_DATA_PAGE_V2, header.DataPageHeaderV2.Encoding)
+		}
+	}
+	return encodings, encodingStats
+}
+
 //Decode a dict chunk
 func DecodeDictChunk(chunk *Chunk) {
 	dictPage := chunk.Pages[0]
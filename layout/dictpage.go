@@ -3,18 +3,32 @@ package layout
 import (
 	"context"
 	"math/bits"
+	"sync"
 
 	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/pkg/errors"
 	"github.com/sabey/parquet-go/common"
 	"github.com/sabey/parquet-go/compress"
 	"github.com/sabey/parquet-go/encoding"
 	"github.com/sabey/parquet-go/parquet"
 )
 
+//DictRecType is the dictionary of a column chunk, it can be shared by the goroutines encoding its tables
 type DictRecType struct {
 	DictMap   map[interface{}]int32
 	DictSlice []interface{}
 	Type      parquet.Type
+	//Size of the plain encoded values of the dictionary
+	Size int64
+	//Maximum size of the dictionary, 0 means no limit. The tables whose values would make the dictionary
+	//larger, and all the next ones, are written in PLAIN pages.
+	SizeLimit int64
+	//Full is set when a table didn't fit in the dictionary
+	Full bool
+	//Compressor of the dictionary page, the registered compressor of the codec is used if it's nil
+	Compressor *compress.Compressor
+
+	mutex sync.Mutex
 }
 
 func NewDictRec(pT parquet.Type) *DictRecType {
@@ -24,7 +38,14 @@ func NewDictRec(pT parquet.Type) *DictRecType {
 	return res
 }
 
+//Convert a dict rec to a dict page, the errors of DictRecToDictPageErr are ignored
 func DictRecToDictPage(dictRec *DictRecType, pageSize int32, compressType parquet.CompressionCodec) (*Page, int64) {
+	page, totSize, _ := DictRecToDictPageErr(dictRec, pageSize, compressType)
+	return page, totSize
+}
+
+//DictRecToDictPageErr converts a dict rec to a dict page, it returns an error if it can't be compressed
+func DictRecToDictPageErr(dictRec *DictRecType, pageSize int32, compressType parquet.CompressionCodec) (*Page, int64, error) {
 	var totSize int64 = 0
 
 	page := NewDataPage()
@@ -39,16 +60,28 @@ func DictRecToDictPage(dictRec *DictRecType, pageSize int32, compressType parque
 		Type: &dataType,
 	}
 	page.CompressType = compressType
+	page.Compressor = dictRec.Compressor
 
-	page.DictPageCompress(compressType, dictRec.Type)
+	if _, err := page.DictPageCompressErr(compressType, dictRec.Type); err != nil {
+		return nil, 0, errors.Wrap(err, "page.DictPageCompressErr")
+	}
 	totSize += int64(len(page.RawData))
-	return page, totSize
+	return page, totSize, nil
 }
 
-//Compress the dict page to parquet file
+//Compress the dict page to parquet file, the errors of DictPageCompressErr are ignored
 func (page *Page) DictPageCompress(compressType parquet.CompressionCodec, pT parquet.Type) []byte {
+	res, _ := page.DictPageCompressErr(compressType, pT)
+	return res
+}
+
+//DictPageCompressErr compresses the dict page to parquet file, it returns an error if it can't be compressed
+func (page *Page) DictPageCompressErr(compressType parquet.CompressionCodec, pT parquet.Type) ([]byte, error) {
 	dataBuf := encoding.WritePlain(page.DataTable.Values, pT)
-	var dataEncodeBuf []byte = compress.Compress(dataBuf, compressType)
+	dataEncodeBuf, err := page.compress(dataBuf, compressType)
+	if err != nil {
+		return nil, errors.Wrap(err, "page.compress")
+	}
 
 	//pageHeader/////////////////////////////////////
 	page.Header = parquet.NewPageHeader()
@@ -67,15 +100,80 @@ func (page *Page) DictPageCompress(compressType parquet.CompressionCodec, pT par
 	res = append(res, pageHeaderBuf...)
 	res = append(res, dataEncodeBuf...)
 	page.RawData = res
-	return res
+	return res, nil
 }
 
-//Convert a table to dict data pages
+//Convert a table to dict data pages, the errors of TableToDictDataPagesErr are ignored
 func TableToDictDataPages(dictRec *DictRecType, table *Table, pageSize int32, bitWidth int32, compressType parquet.CompressionCodec) ([]*Page, int64) {
+	pages, totSize, _ := TableToDictDataPagesErr(dictRec, table, pageSize, bitWidth, compressType)
+	return pages, totSize
+}
+
+//TableToDictDataPagesErr converts a table to dict data pages, it returns an error if the pages can't be compressed
+func TableToDictDataPagesErr(dictRec *DictRecType, table *Table, pageSize int32, bitWidth int32, compressType parquet.CompressionCodec) ([]*Page, int64, error) {
+	return TableToDictDataPagesVersionErr(dictRec, table, pageSize, bitWidth, compressType, 1)
+}
+
+//Indexes adds the values of a table to the dictionary and returns their indexes. It returns false if the
+//dictionary is full, the values aren't added then. It's safe for concurrent use.
+func (dictRec *DictRecType) Indexes(table *Table) ([]int32, bool) {
+	dictRec.mutex.Lock()
+	defer dictRec.mutex.Unlock()
+	if dictRec.Full {
+		return nil, false
+	}
+
+	funcTable := common.FindFuncTable(table.Schema.Type, table.Schema.ConvertedType, table.Schema.LogicalType)
+	numDictValues, size := len(dictRec.DictSlice), dictRec.Size
+	indexes := make([]int32, 0, len(table.Values))
+	for i, value := range table.Values {
+		if table.DefinitionLevels[i] != table.MaxDefinitionLevel {
+			continue
+		}
+		idx, ok := dictRec.DictMap[value]
+		if !ok {
+			_, _, elSize := funcTable.MinMaxSize(nil, nil, value)
+			if dictRec.SizeLimit > 0 && dictRec.Size+int64(elSize) > dictRec.SizeLimit {
+				//the values of the table are removed, so that it's written in PLAIN pages
+				for _, v := range dictRec.DictSlice[numDictValues:] {
+					delete(dictRec.DictMap, v)
+				}
+				dictRec.DictSlice, dictRec.Size, dictRec.Full = dictRec.DictSlice[:numDictValues], size, true
+				return nil, false
+			}
+			dictRec.DictSlice = append(dictRec.DictSlice, value)
+			dictRec.Size += int64(elSize)
+			idx = int32(len(dictRec.DictSlice) - 1)
+			dictRec.DictMap[value] = idx
+		}
+		indexes = append(indexes, idx)
+	}
+	return indexes, true
+}
+
+//Convert a table to dict data pages of a version, the errors of TableToDictDataPagesVersionErr are ignored
+func TableToDictDataPagesVersion(dictRec *DictRecType, table *Table, pageSize int32, bitWidth int32, compressType parquet.CompressionCodec, version int32) ([]*Page, int64) {
+	pages, totSize, _ := TableToDictDataPagesVersionErr(dictRec, table, pageSize, bitWidth, compressType, version)
+	return pages, totSize
+}
+
+//TableToDictDataPagesVersionErr converts a table to dict data pages of a version, 1 for DATA_PAGE and 2 for
+//DATA_PAGE_V2. The datapageversion of the tag of the column overrides the version. The table is converted
+//to PLAIN data pages if the dictionary is full.
+func TableToDictDataPagesVersionErr(dictRec *DictRecType, table *Table, pageSize int32, bitWidth int32, compressType parquet.CompressionCodec, version int32) ([]*Page, int64, error) {
+	indexes, ok := dictRec.Indexes(table)
+	if !ok {
+		plainTable := *table
+		info := *table.Info
+		info.Encoding = parquet.Encoding_PLAIN
+		plainTable.Info = &info
+		return TableToDataPagesVersionErr(&plainTable, pageSize, compressType, version)
+	}
+
 	var totSize int64 = 0
 	totalLn := len(table.Values)
 	res := make([]*Page, 0)
-	i := 0
+	i, k := 0, 0
 
 	pT, cT, logT, omitStats := table.Schema.Type, table.Schema.ConvertedType, table.Schema.LogicalType, table.Info.OmitStats
 
@@ -87,11 +185,13 @@ func TableToDictDataPages(dictRec *DictRecType, table *Table, pageSize int32, bi
 		var maxVal interface{} = table.Values[i]
 		var minVal interface{} = table.Values[i]
 		var nullCount int64 = 0
+		var numRows int64 = 0
 		values := make([]int32, 0)
 
 		funcTable := common.FindFuncTable(pT, cT, logT)
 
-		for j < totalLn && size < pageSize {
+		//a record is never split across pages, so the pages start at row boundaries
+		for j < totalLn && (size < pageSize || table.RepetitionLevels[j] != 0) {
 			if table.DefinitionLevels[j] == table.MaxDefinitionLevel {
 				numValues++
 				var elSize int32
@@ -101,23 +201,21 @@ func TableToDictDataPages(dictRec *DictRecType, table *Table, pageSize int32, bi
 					minVal, maxVal, elSize = funcTable.MinMaxSize(minVal, maxVal, table.Values[j])
 				}
 				size += elSize
-				if idx, ok := dictRec.DictMap[table.Values[j]]; ok {
-					values = append(values, idx)
-				} else {
-					dictRec.DictSlice = append(dictRec.DictSlice, table.Values[j])
-					idx := int32(len(dictRec.DictSlice) - 1)
-					dictRec.DictMap[table.Values[j]] = idx
-					values = append(values, idx)
-				}
+				values = append(values, indexes[k])
+				k++
 			}
-			if table.Values[i] == nil {
+			if table.Values[j] == nil {
 				nullCount++
 			}
+			if table.RepetitionLevels[j] == 0 {
+				numRows++
+			}
 			j++
 		}
 
 		page := NewDataPage()
 		page.PageSize = pageSize
+		page.NumRows = numRows
 		page.Header.DataPageHeader.NumValues = numValues
 		page.Header.Type = parquet.PageType_DATA_PAGE
 
@@ -139,20 +237,33 @@ func TableToDictDataPages(dictRec *DictRecType, table *Table, pageSize int32, bi
 		}
 		page.Schema = table.Schema
 		page.CompressType = compressType
+		page.Compressor = table.Compressor
 		page.Path = table.Path
 		page.Info = table.Info
 
-		page.DictDataPageCompress(compressType, bitWidth, values)
+		if dataPageVersion(table.Info, version) == 2 {
+			if _, err := page.DictDataPageV2CompressErr(compressType, bitWidth, values); err != nil {
+				return nil, 0, errors.Wrap(err, "page.DictDataPageV2CompressErr")
+			}
+		} else if _, err := page.DictDataPageCompressErr(compressType, bitWidth, values); err != nil {
+			return nil, 0, errors.Wrap(err, "page.DictDataPageCompressErr")
+		}
 
 		totSize += int64(len(page.RawData))
 		res = append(res, page)
 		i = j
 	}
-	return res, totSize
+	return res, totSize, nil
 }
 
-//Compress the data page to parquet file
+//Compress the data page to parquet file, the errors of DictDataPageCompressErr are ignored
 func (page *Page) DictDataPageCompress(compressType parquet.CompressionCodec, bitWidth int32, values []int32) []byte {
+	res, _ := page.DictDataPageCompressErr(compressType, bitWidth, values)
+	return res
+}
+
+//DictDataPageCompressErr compresses the data page to parquet file, it returns an error if it can't be compressed
+func (page *Page) DictDataPageCompressErr(compressType parquet.CompressionCodec, bitWidth int32, values []int32) ([]byte, error) {
 	//values////////////////////////////////////////////
 	valuesRawBuf := []byte{byte(bitWidth)}
 	valuesRawBuf = append(valuesRawBuf, encoding.WriteRLEInt32(values, bitWidth)...)
@@ -179,7 +290,10 @@ func (page *Page) DictDataPageCompress(compressType parquet.CompressionCodec, bi
 	dataBuf = append(dataBuf, definitionLevelBuf...)
 	dataBuf = append(dataBuf, valuesRawBuf...)
 
-	var dataEncodeBuf []byte = compress.Compress(dataBuf, compressType)
+	dataEncodeBuf, err := page.compress(dataBuf, compressType)
+	if err != nil {
+		return nil, errors.Wrap(err, "page.compress")
+	}
 
 	//pageHeader/////////////////////////////////////
 	page.Header = parquet.NewPageHeader()
@@ -221,5 +335,20 @@ func (page *Page) DictDataPageCompress(compressType parquet.CompressionCodec, bi
 	res = append(res, dataEncodeBuf...)
 	page.RawData = res
 
+	return res, nil
+}
+
+//Compress the data page v2 of the dictionary indexes to parquet file, the errors of DictDataPageV2CompressErr
+//are ignored
+func (page *Page) DictDataPageV2Compress(compressType parquet.CompressionCodec, bitWidth int32, values []int32) []byte {
+	res, _ := page.DictDataPageV2CompressErr(compressType, bitWidth, values)
 	return res
 }
+
+//DictDataPageV2CompressErr compresses the data page v2 of the dictionary indexes to parquet file, it returns an
+//error if it can't be compressed
+func (page *Page) DictDataPageV2CompressErr(compressType parquet.CompressionCodec, bitWidth int32, values []int32) ([]byte, error) {
+	valuesRawBuf := []byte{byte(bitWidth)}
+	valuesRawBuf = append(valuesRawBuf, encoding.WriteRLEInt32(values, bitWidth)...)
+	return page.dataPageV2Compress(compressType, valuesRawBuf, int32(len(values)), parquet.Encoding_RLE_DICTIONARY)
+}
@@ -0,0 +1,65 @@
+package layout
+
+import (
+	"github.com/sabey/parquet-go/common"
+	"github.com/sabey/parquet-go/parquet"
+)
+
+//DefaultDictSizeLimit is the size of the plain encoded values of a dictionary above which the next pages
+//of every dictionary encoded chunk fall back to PLAIN, it's the dictionary page size of parquet-mr
+const DefaultDictSizeLimit = 1024 * 1024
+
+//ChooseEncoding returns the encoding of the values of a table for the adaptive encoding:
+//PLAIN_DICTIONARY if the values repeat and their dictionary isn't larger than dictSizeLimit,
+//DELTA_BINARY_PACKED for monotonic integers, BYTE_STREAM_SPLIT for floating point numbers,
+//PLAIN otherwise.
+func ChooseEncoding(table *Table, dictSizeLimit int64) parquet.Encoding {
+	pT := table.Schema.GetType()
+	if pT == parquet.Type_BOOLEAN {
+		return parquet.Encoding_PLAIN
+	}
+
+	funcTable := common.FindFuncTable(table.Schema.Type, table.Schema.ConvertedType, table.Schema.LogicalType)
+	distinct := make(map[interface{}]struct{})
+	var dictSize int64 = 0
+	numValues := 0
+	increasing, decreasing := true, true
+	var last interface{}
+	for i, value := range table.Values {
+		if table.DefinitionLevels[i] != table.MaxDefinitionLevel || value == nil {
+			continue
+		}
+		numValues++
+		if _, ok := distinct[value]; !ok && dictSize <= dictSizeLimit {
+			distinct[value] = struct{}{}
+			_, _, size := funcTable.MinMaxSize(nil, nil, value)
+			dictSize += int64(size)
+		}
+		if last != nil {
+			switch v := value.(type) {
+			case int32:
+				increasing, decreasing = increasing && v >= last.(int32), decreasing && v <= last.(int32)
+			case int64:
+				increasing, decreasing = increasing && v >= last.(int64), decreasing && v <= last.(int64)
+			}
+		}
+		last = value
+	}
+	if numValues == 0 {
+		return parquet.Encoding_PLAIN
+	}
+
+	//the dictionary pays off when the values are repeated twice on average
+	if dictSize <= dictSizeLimit && 2*len(distinct) <= numValues {
+		return parquet.Encoding_PLAIN_DICTIONARY
+	}
+	switch pT {
+	case parquet.Type_INT32, parquet.Type_INT64:
+		if increasing || decreasing {
+			return parquet.Encoding_DELTA_BINARY_PACKED
+		}
+	case parquet.Type_FLOAT, parquet.Type_DOUBLE:
+		return parquet.Encoding_BYTE_STREAM_SPLIT
+	}
+	return parquet.Encoding_PLAIN
+}
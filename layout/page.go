@@ -11,6 +11,7 @@ import (
 	"github.com/sabey/parquet-go/common"
 	"github.com/sabey/parquet-go/compress"
 	"github.com/sabey/parquet-go/encoding"
+	"github.com/sabey/parquet-go/encryption"
 	"github.com/sabey/parquet-go/parquet"
 	"github.com/sabey/parquet-go/schema"
 )
@@ -35,8 +36,12 @@ type Page struct {
 	MinVal interface{}
 	//NullCount
 	NullCount *int64
+	//Number of rows which start in the page
+	NumRows int64
 	//Tag info
 	Info *common.Tag
+	//Compressor of the data, the registered compressor of CompressType is used if it's nil
+	Compressor *compress.Compressor
 
 	PageSize int32
 }
@@ -67,8 +72,26 @@ func NewDataPage() *Page {
 	return page
 }
 
-//Convert a table to data pages
+//Convert a table to data pages, the errors of TableToDataPagesErr are ignored
 func TableToDataPages(table *Table, pageSize int32, compressType parquet.CompressionCodec) ([]*Page, int64) {
+	pages, totSize, _ := TableToDataPagesErr(table, pageSize, compressType)
+	return pages, totSize
+}
+
+//TableToDataPagesErr converts a table to data pages, it returns an error if the pages can't be compressed
+func TableToDataPagesErr(table *Table, pageSize int32, compressType parquet.CompressionCodec) ([]*Page, int64, error) {
+	return TableToDataPagesVersionErr(table, pageSize, compressType, 1)
+}
+
+//Convert a table to data pages of a version, the errors of TableToDataPagesVersionErr are ignored
+func TableToDataPagesVersion(table *Table, pageSize int32, compressType parquet.CompressionCodec, version int32) ([]*Page, int64) {
+	pages, totSize, _ := TableToDataPagesVersionErr(table, pageSize, compressType, version)
+	return pages, totSize
+}
+
+//TableToDataPagesVersionErr converts a table to data pages of a version, 1 for DATA_PAGE and 2 for DATA_PAGE_V2.
+//The datapageversion of the tag of the column overrides the version.
+func TableToDataPagesVersionErr(table *Table, pageSize int32, compressType parquet.CompressionCodec, version int32) ([]*Page, int64, error) {
 	var totSize int64 = 0
 	totalLn := len(table.Values)
 	res := make([]*Page, 0)
@@ -83,10 +106,18 @@ func TableToDataPages(table *Table, pageSize int32, compressType parquet.Compres
 		var maxVal interface{} = table.Values[i]
 		var minVal interface{} = table.Values[i]
 		var nullCount = int64(0)
+		if table.Values[i] == nil {
+			nullCount++
+		}
+		var numRows = int64(0)
+		if table.RepetitionLevels[i] == 0 {
+			numRows++
+		}
 
 		funcTable := common.FindFuncTable(pT, cT, logT)
 
-		for j < totalLn && size < pageSize {
+		//a record is never split across pages, so the pages start at row boundaries
+		for j < totalLn && (size < pageSize || table.RepetitionLevels[j] != 0) {
 			if table.DefinitionLevels[j] == table.MaxDefinitionLevel {
 				numValues++
 				var elSize int32
@@ -100,11 +131,15 @@ func TableToDataPages(table *Table, pageSize int32, compressType parquet.Compres
 			if table.Values[j] == nil {
 				nullCount++
 			}
+			if table.RepetitionLevels[j] == 0 {
+				numRows++
+			}
 			j++
 		}
 
 		page := NewDataPage()
 		page.PageSize = pageSize
+		page.NumRows = numRows
 		page.Header.DataPageHeader.NumValues = numValues
 		page.Header.Type = parquet.PageType_DATA_PAGE
 
@@ -123,44 +158,73 @@ func TableToDataPages(table *Table, pageSize int32, compressType parquet.Compres
 		}
 		page.Schema = table.Schema
 		page.CompressType = compressType
+		page.Compressor = table.Compressor
 		page.Path = table.Path
 		page.Info = table.Info
 
-		page.DataPageCompress(compressType)
+		if dataPageVersion(table.Info, version) == 2 {
+			if _, err := page.DataPageV2CompressErr(compressType); err != nil {
+				return nil, 0, errors.Wrap(err, "page.DataPageV2CompressErr")
+			}
+		} else if _, err := page.DataPageCompressErr(compressType); err != nil {
+			return nil, 0, errors.Wrap(err, "page.DataPageCompressErr")
+		}
 
 		totSize += int64(len(page.RawData))
 		res = append(res, page)
 		i = j
 	}
-	return res, totSize
+	return res, totSize, nil
 }
 
-//Decode dict page
+//dataPageVersion returns the data page version of a column, its tag overrides the version of the writer
+func dataPageVersion(info *common.Tag, version int32) int32 {
+	if info != nil && info.DataPageVersion != 0 {
+		return info.DataPageVersion
+	}
+	return version
+}
+
+//Decode dict page, the errors of DecodeErr are ignored
 func (page *Page) Decode(dictPage *Page) {
-	if dictPage == nil || page == nil ||
+	page.DecodeErr(dictPage)
+}
+
+//DecodeErr decodes dict page, it returns an error if the dict page is missing or an index is out of it
+func (page *Page) DecodeErr(dictPage *Page) error {
+	if page == nil || page.Header == nil ||
 		(page.Header.DataPageHeader == nil && page.Header.DataPageHeaderV2 == nil) {
-		return
+		return nil
 	}
 
 	if page.Header.DataPageHeader != nil &&
 		(page.Header.DataPageHeader.Encoding != parquet.Encoding_RLE_DICTIONARY &&
 			page.Header.DataPageHeader.Encoding != parquet.Encoding_PLAIN_DICTIONARY) {
-		return
+		return nil
 	}
 
 	if page.Header.DataPageHeaderV2 != nil &&
 		(page.Header.DataPageHeaderV2.Encoding != parquet.Encoding_RLE_DICTIONARY &&
 			page.Header.DataPageHeaderV2.Encoding != parquet.Encoding_PLAIN_DICTIONARY) {
-		return
+		return nil
+	}
+
+	if dictPage == nil || dictPage.DataTable == nil {
+		return errors.New("dictionary page not found")
 	}
 
 	numValues := len(page.DataTable.Values)
+	numDictValues := int64(len(dictPage.DataTable.Values))
 	for i := 0; i < numValues; i++ {
 		if page.DataTable.Values[i] != nil {
-			index := page.DataTable.Values[i].(int64)
+			index, ok := page.DataTable.Values[i].(int64)
+			if !ok || index < 0 || index >= numDictValues {
+				return errors.Errorf("invalid dictionary index %v", page.DataTable.Values[i])
+			}
 			page.DataTable.Values[i] = dictPage.DataTable.Values[index]
 		}
 	}
+	return nil
 }
 
 //Encoding values
@@ -190,8 +254,14 @@ func (page *Page) EncodingValues(valuesBuf []interface{}) []byte {
 	}
 }
 
-//Compress the data page to parquet file
+//Compress the data page to parquet file, the errors of DataPageCompressErr are ignored
 func (page *Page) DataPageCompress(compressType parquet.CompressionCodec) []byte {
+	res, _ := page.DataPageCompressErr(compressType)
+	return res
+}
+
+//DataPageCompressErr compresses the data page to parquet file, it returns an error if it can't be compressed
+func (page *Page) DataPageCompressErr(compressType parquet.CompressionCodec) ([]byte, error) {
 	ln := len(page.DataTable.DefinitionLevels)
 
 	//values////////////////////////////////////////////
@@ -237,7 +307,10 @@ func (page *Page) DataPageCompress(compressType parquet.CompressionCodec) []byte
 	dataBuf = append(dataBuf, definitionLevelBuf...)
 	dataBuf = append(dataBuf, valuesRawBuf...)
 
-	var dataEncodeBuf []byte = compress.Compress(dataBuf, compressType)
+	dataEncodeBuf, err := page.compress(dataBuf, compressType)
+	if err != nil {
+		return nil, errors.Wrap(err, "page.compress")
+	}
 
 	//pageHeader/////////////////////////////////////
 	page.Header = parquet.NewPageHeader()
@@ -277,23 +350,36 @@ func (page *Page) DataPageCompress(compressType parquet.CompressionCodec) []byte
 	res := append(pageHeaderBuf, dataEncodeBuf...)
 	page.RawData = res
 
-	return res
+	return res, nil
 }
 
-//Compress data page v2 to parquet file
+//Compress data page v2 to parquet file, the errors of DataPageV2CompressErr are ignored
 func (page *Page) DataPageV2Compress(compressType parquet.CompressionCodec) []byte {
+	res, _ := page.DataPageV2CompressErr(compressType)
+	return res
+}
+
+//DataPageV2CompressErr compresses data page v2 to parquet file, it returns an error if it can't be compressed
+func (page *Page) DataPageV2CompressErr(compressType parquet.CompressionCodec) ([]byte, error) {
 	ln := len(page.DataTable.DefinitionLevels)
 
 	//values////////////////////////////////////////////
-	valuesBuf := make([]interface{}, 0)
+	valuesBuf := make([]interface{}, 0, ln)
 	for i := 0; i < ln; i++ {
 		if page.DataTable.DefinitionLevels[i] == page.DataTable.MaxDefinitionLevel {
 			valuesBuf = append(valuesBuf, page.DataTable.Values[i])
 		}
 	}
-	//valuesRawBuf := encoding.WritePlain(valuesBuf)
 	valuesRawBuf := page.EncodingValues(valuesBuf)
 
+	return page.dataPageV2Compress(compressType, valuesRawBuf, int32(len(valuesBuf)), page.Info.Encoding)
+}
+
+//dataPageV2Compress writes a data page v2 of the encoded values. The levels are never compressed,
+//the values are stored uncompressed when the compression doesn't make them smaller.
+func (page *Page) dataPageV2Compress(compressType parquet.CompressionCodec, valuesRawBuf []byte, numValues int32, encodingMethod parquet.Encoding) ([]byte, error) {
+	ln := len(page.DataTable.DefinitionLevels)
+
 	//definitionLevel//////////////////////////////////
 	var definitionLevelBuf []byte
 	if page.DataTable.MaxDefinitionLevel > 0 {
@@ -308,21 +394,33 @@ func (page *Page) DataPageV2Compress(compressType parquet.CompressionCodec) []by
 
 	//repetitionLevel/////////////////////////////////
 	r0Num := int32(0)
+	for i := 0; i < ln; i++ {
+		if page.DataTable.RepetitionLevels[i] == 0 {
+			r0Num++
+		}
+	}
 	var repetitionLevelBuf []byte
 	if page.DataTable.MaxRepetitionLevel > 0 {
 		numInterfaces := make([]interface{}, ln)
 		for i := 0; i < ln; i++ {
 			numInterfaces[i] = int64(page.DataTable.RepetitionLevels[i])
-			if page.DataTable.RepetitionLevels[i] == 0 {
-				r0Num++
-			}
 		}
 		repetitionLevelBuf = encoding.WriteRLE(numInterfaces,
 			int32(bits.Len32(uint32(page.DataTable.MaxRepetitionLevel))),
 			parquet.Type_INT64)
 	}
 
-	var dataEncodeBuf []byte = compress.Compress(valuesRawBuf, compressType)
+	isCompressed := false
+	dataEncodeBuf := valuesRawBuf
+	if compressType != parquet.CompressionCodec_UNCOMPRESSED {
+		buf, err := page.compress(valuesRawBuf, compressType)
+		if err != nil {
+			return nil, errors.Wrap(err, "page.compress")
+		}
+		if len(buf) < len(valuesRawBuf) {
+			isCompressed, dataEncodeBuf = true, buf
+		}
+	}
 
 	//pageHeader/////////////////////////////////////
 	page.Header = parquet.NewPageHeader()
@@ -330,15 +428,14 @@ func (page *Page) DataPageV2Compress(compressType parquet.CompressionCodec) []by
 	page.Header.CompressedPageSize = int32(len(dataEncodeBuf) + len(definitionLevelBuf) + len(repetitionLevelBuf))
 	page.Header.UncompressedPageSize = int32(len(valuesRawBuf) + len(definitionLevelBuf) + len(repetitionLevelBuf))
 	page.Header.DataPageHeaderV2 = parquet.NewDataPageHeaderV2()
-	page.Header.DataPageHeaderV2.NumValues = int32(len(page.DataTable.Values))
-	page.Header.DataPageHeaderV2.NumNulls = page.Header.DataPageHeaderV2.NumValues - int32(len(valuesBuf))
+	page.Header.DataPageHeaderV2.NumValues = int32(ln)
+	page.Header.DataPageHeaderV2.NumNulls = int32(ln) - numValues
 	page.Header.DataPageHeaderV2.NumRows = r0Num
-	//page.Header.DataPageHeaderV2.Encoding = parquet.Encoding_PLAIN
-	page.Header.DataPageHeaderV2.Encoding = page.Info.Encoding
+	page.Header.DataPageHeaderV2.Encoding = encodingMethod
 
 	page.Header.DataPageHeaderV2.DefinitionLevelsByteLength = int32(len(definitionLevelBuf))
 	page.Header.DataPageHeaderV2.RepetitionLevelsByteLength = int32(len(repetitionLevelBuf))
-	page.Header.DataPageHeaderV2.IsCompressed = true
+	page.Header.DataPageHeaderV2.IsCompressed = isCompressed
 
 	page.Header.DataPageHeaderV2.Statistics = parquet.NewStatistics()
 	if page.MaxVal != nil {
@@ -364,14 +461,67 @@ func (page *Page) DataPageV2Compress(compressType parquet.CompressionCodec) []by
 	ts.Protocol = thrift.NewTCompactProtocolFactory().GetProtocol(ts.Transport)
 	pageHeaderBuf, _ := ts.Write(context.TODO(), page.Header)
 
-	var res []byte
+	res := make([]byte, 0, len(pageHeaderBuf)+int(page.Header.CompressedPageSize))
 	res = append(res, pageHeaderBuf...)
 	res = append(res, repetitionLevelBuf...)
 	res = append(res, definitionLevelBuf...)
 	res = append(res, dataEncodeBuf...)
 	page.RawData = res
 
-	return res
+	return res, nil
+}
+
+//uncompressedDataSize returns the uncompressed size of the compressed data of a page,
+//without the levels of a DATA_PAGE_V2 which aren't compressed
+func uncompressedDataSize(header *parquet.PageHeader) int {
+	size := header.GetUncompressedPageSize()
+	if header.GetType() == parquet.PageType_DATA_PAGE_V2 {
+		size -= header.DataPageHeaderV2.GetRepetitionLevelsByteLength() + header.DataPageHeaderV2.GetDefinitionLevelsByteLength()
+	}
+	return int(size)
+}
+
+//compress compresses the data of the page with its compressor, or the registered compressor of the codec.
+//It returns an error if the codec isn't registered.
+func (page *Page) compress(buf []byte, compressType parquet.CompressionCodec) ([]byte, error) {
+	if page.Compressor != nil {
+		return page.Compressor.Compress(buf), nil
+	}
+	res, err := compress.Compress(buf, compressType)
+	if err != nil {
+		return nil, errors.Wrap(err, "compress.Compress")
+	}
+	return res, nil
+}
+
+//Encrypt the RawData of a compressed page: the header and the data are encrypted as two modules.
+//The page ordinal is the number of data pages before the page in its chunk.
+func (page *Page) Encrypt(chunkCipher *encryption.ChunkCipher, pageOrdinal int16) error {
+	headerType, dataType := encryption.DataPageHeader, encryption.DataPage
+	if page.Header.GetType() == parquet.PageType_DICTIONARY_PAGE {
+		headerType, dataType = encryption.DictionaryPageHeader, encryption.DictionaryPage
+	}
+
+	headerSize := len(page.RawData) - int(page.Header.CompressedPageSize)
+	dataBuf, err := chunkCipher.Encrypt(page.RawData[headerSize:], dataType, pageOrdinal)
+	if err != nil {
+		return errors.Wrap(err, "chunkCipher.Encrypt")
+	}
+
+	page.Header.CompressedPageSize = int32(len(dataBuf))
+	ts := thrift.NewTSerializer()
+	ts.Protocol = thrift.NewTCompactProtocolFactory().GetProtocol(ts.Transport)
+	pageHeaderBuf, err := ts.Write(context.TODO(), page.Header)
+	if err != nil {
+		return errors.Wrap(err, "ts.Write")
+	}
+	headerBuf, err := chunkCipher.Encrypt(pageHeaderBuf, headerType, pageOrdinal)
+	if err != nil {
+		return errors.Wrap(err, "chunkCipher.Encrypt")
+	}
+
+	page.RawData = append(headerBuf, dataBuf...)
+	return nil
 }
 
 //This is a test function
@@ -393,9 +543,14 @@ func ReadPage2(thriftReader *thrift.TBufferedTransport, schemaHandler *schema.Sc
 
 //Read page RawData
 func ReadPageRawData(thriftReader *thrift.TBufferedTransport, schemaHandler *schema.SchemaHandler, colMetaData *parquet.ColumnMetaData) (*Page, error) {
-	pageHeader, err := ReadPageHeader(thriftReader)
+	return ReadPageRawDataContext(context.TODO(), thriftReader, schemaHandler, colMetaData)
+}
+
+//Read page RawData, ctx is passed to the thrift protocol
+func ReadPageRawDataContext(ctx context.Context, thriftReader *thrift.TBufferedTransport, schemaHandler *schema.SchemaHandler, colMetaData *parquet.ColumnMetaData) (*Page, error) {
+	pageHeader, err := ReadPageHeaderContext(ctx, thriftReader)
 	if err != nil {
-		return nil, errors.Wrap(err, "ReadPageHeader")
+		return nil, errors.Wrap(err, "ReadPageHeaderContext")
 	}
 
 	var page *Page
@@ -419,6 +574,9 @@ func ReadPageRawData(thriftReader *thrift.TBufferedTransport, schemaHandler *sch
 	page.Path = make([]string, 0)
 	page.Path = append(page.Path, schemaHandler.GetRootInName())
 	page.Path = append(page.Path, colMetaData.GetPathInSchema()...)
+	if err = verifyChecksum(pageHeader, page.Path, buf); err != nil {
+		return nil, errors.Wrap(err, "verifyChecksum")
+	}
 	pathIndex := schemaHandler.MapIndex[common.PathToStr(page.Path)]
 	schema := schemaHandler.SchemaElements[pathIndex]
 	page.Schema = schema
@@ -456,7 +614,7 @@ func (p *Page) GetRLDLFromRawData(schemaHandler *schema.SchemaHandler) (int64, i
 		buf = append(buf, dataBuf...)
 
 	} else {
-		if buf, err = compress.Uncompress(p.RawData, p.CompressType); err != nil {
+		if buf, err = compress.UncompressWithSize(p.RawData, p.CompressType, uncompressedDataSize(p.Header)); err != nil {
 			return 0, 0, errors.Errorf("Unsupported compress method")
 		}
 	}
@@ -570,13 +728,17 @@ func (p *Page) GetValueFromRawData(schemaHandler *schema.SchemaHandler) error {
 			return errors.Wrap(err, "encoding.ReadPlain")
 		}
 	case parquet.PageType_DATA_PAGE_V2:
-		if p.RawData, err = compress.Uncompress(p.RawData, p.CompressType); err != nil {
-			return errors.Wrap(err, "compress.Uncompress")
+		if len(p.RawData) > 0 && p.Header.DataPageHeaderV2.GetIsCompressed() {
+			if p.RawData, err = compress.UncompressWithSize(p.RawData, p.CompressType, uncompressedDataSize(p.Header)); err != nil {
+				return errors.Wrap(err, "compress.UncompressWithSize")
+			}
 		}
-		encodingType = p.Header.DataPageHeader.GetEncoding()
+		encodingType = p.Header.DataPageHeaderV2.GetEncoding()
 		fallthrough
 	case parquet.PageType_DATA_PAGE:
-		encodingType = p.Header.DataPageHeader.GetEncoding()
+		if p.Header.GetType() == parquet.PageType_DATA_PAGE {
+			encodingType = p.Header.DataPageHeader.GetEncoding()
+		}
 		bytesReader := bytes.NewReader(p.RawData)
 
 		var numNulls uint64 = 0
@@ -619,9 +781,17 @@ func (p *Page) GetValueFromRawData(schemaHandler *schema.SchemaHandler) error {
 
 //Read page header
 func ReadPageHeader(thriftReader *thrift.TBufferedTransport) (*parquet.PageHeader, error) {
+	return ReadPageHeaderContext(context.TODO(), thriftReader)
+}
+
+//Read page header, ctx is passed to the thrift protocol
+func ReadPageHeaderContext(ctx context.Context, thriftReader *thrift.TBufferedTransport) (*parquet.PageHeader, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, errors.Wrap(err, "ctx.Err")
+	}
 	protocol := thrift.NewTCompactProtocol(thriftReader)
 	pageHeader := parquet.NewPageHeader()
-	err := pageHeader.Read(context.TODO(), protocol)
+	err := pageHeader.Read(ctx, protocol)
 	if err != nil {
 		return pageHeader, errors.Wrap(err, "pageHeader.Read")
 	}
@@ -737,12 +907,20 @@ func ReadDataPageValues(bytesReader *bytes.Reader, encodingMethod parquet.Encodi
 
 //Read page from parquet file
 func ReadPage(thriftReader *thrift.TBufferedTransport, schemaHandler *schema.SchemaHandler, colMetaData *parquet.ColumnMetaData) (*Page, int64, int64, error) {
-	pageHeader, err := ReadPageHeader(thriftReader)
+	return ReadPageContext(context.TODO(), thriftReader, schemaHandler, colMetaData)
+}
+
+//Read page from parquet file, ctx is passed to the thrift protocol
+func ReadPageContext(ctx context.Context, thriftReader *thrift.TBufferedTransport, schemaHandler *schema.SchemaHandler, colMetaData *parquet.ColumnMetaData) (*Page, int64, int64, error) {
+	pageHeader, err := ReadPageHeaderContext(ctx, thriftReader)
 	if err != nil {
-		return nil, 0, 0, errors.Wrap(err, "ReadPageHeader")
+		return nil, 0, 0, errors.Wrap(err, "ReadPageHeaderContext")
 	}
 
 	buf := make([]byte, 0)
+	path := make([]string, 0)
+	path = append(path, schemaHandler.GetRootInName())
+	path = append(path, colMetaData.GetPathInSchema()...)
 
 	var page *Page
 	compressedPageSize := pageHeader.GetCompressedPageSize()
@@ -763,11 +941,14 @@ func ReadPage(thriftReader *thrift.TBufferedTransport, schemaHandler *schema.Sch
 		if _, err = io.ReadFull(thriftReader, dataBuf); err != nil {
 			return nil, 0, 0, errors.Wrap(err, "io.ReadFull")
 		}
+		if err = verifyChecksum(pageHeader, path, repetitionLevelsBuf, definitionLevelsBuf, dataBuf); err != nil {
+			return nil, 0, 0, errors.Wrap(err, "verifyChecksum")
+		}
 
 		codec := colMetaData.GetCodec()
-		if len(dataBuf) > 0 {
-			if dataBuf, err = compress.Uncompress(dataBuf, codec); err != nil {
-				return nil, 0, 0, errors.Wrap(err, "compress.Uncompress")
+		if len(dataBuf) > 0 && pageHeader.DataPageHeaderV2.GetIsCompressed() {
+			if dataBuf, err = compress.UncompressWithSize(dataBuf, codec, uncompressedDataSize(pageHeader)); err != nil {
+				return nil, 0, 0, errors.Wrap(err, "compress.UncompressWithSize")
 			}
 		}
 
@@ -791,16 +972,16 @@ func ReadPage(thriftReader *thrift.TBufferedTransport, schemaHandler *schema.Sch
 		if _, err = io.ReadFull(thriftReader, buf); err != nil {
 			return nil, 0, 0, errors.Wrap(err, "io.ReadFull")
 		}
+		if err = verifyChecksum(pageHeader, path, buf); err != nil {
+			return nil, 0, 0, errors.Wrap(err, "verifyChecksum")
+		}
 		codec := colMetaData.GetCodec()
-		if buf, err = compress.Uncompress(buf, codec); err != nil {
-			return nil, 0, 0, errors.Wrap(err, "compress.Uncompress")
+		if buf, err = compress.UncompressWithSize(buf, codec, uncompressedDataSize(pageHeader)); err != nil {
+			return nil, 0, 0, errors.Wrap(err, "compress.UncompressWithSize")
 		}
 	}
 
 	bytesReader := bytes.NewReader(buf)
-	path := make([]string, 0)
-	path = append(path, schemaHandler.GetRootInName())
-	path = append(path, colMetaData.GetPathInSchema()...)
 	name := common.PathToStr(path)
 
 	if pageHeader.GetType() == parquet.PageType_DICTIONARY_PAGE {
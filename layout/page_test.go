@@ -0,0 +1,74 @@
+package layout
+
+import (
+	"testing"
+
+	"github.com/sabey/parquet-go/common"
+	"github.com/sabey/parquet-go/parquet"
+)
+
+func TestPagesUnknownCodec(t *testing.T) {
+	pT := parquet.Type_INT32
+	table := &Table{
+		Schema:           &parquet.SchemaElement{Type: &pT},
+		Info:             common.NewTag(),
+		Values:           []interface{}{int32(1), int32(2)},
+		DefinitionLevels: []int32{0, 0},
+		RepetitionLevels: []int32{0, 0},
+	}
+
+	if _, _, err := TableToDataPagesErr(table, 1024, parquet.CompressionCodec_LZO); err == nil {
+		t.Error("TableToDataPagesErr should fail with an unknown codec")
+	}
+	dictRec := NewDictRec(pT)
+	if _, _, err := TableToDictDataPagesErr(dictRec, table, 1024, 32, parquet.CompressionCodec_LZO); err == nil {
+		t.Error("TableToDictDataPagesErr should fail with an unknown codec")
+	}
+	if _, _, err := DictRecToDictPageErr(dictRec, 1024, parquet.CompressionCodec_LZO); err == nil {
+		t.Error("DictRecToDictPageErr should fail with an unknown codec")
+	}
+
+	if pages, _, err := TableToDataPagesErr(table, 1024, parquet.CompressionCodec_UNCOMPRESSED); err != nil || len(pages) != 1 {
+		t.Errorf("TableToDataPagesErr: expect 1 page, get %v %v", len(pages), err)
+	}
+
+	//the builders without error return no page
+	if pages, _ := TableToDataPages(table, 1024, parquet.CompressionCodec_LZO); len(pages) != 0 {
+		t.Errorf("TableToDataPages: expect no page, get %v", len(pages))
+	}
+	if pages, _ := TableToDataPages(table, 1024, parquet.CompressionCodec_UNCOMPRESSED); len(pages) != 1 {
+		t.Errorf("TableToDataPages: expect 1 page, get %v", len(pages))
+	}
+}
+
+func TestPageDecode(t *testing.T) {
+	newPage := func() *Page {
+		page := NewDataPage()
+		page.Header.DataPageHeader = parquet.NewDataPageHeader()
+		page.Header.DataPageHeader.Encoding = parquet.Encoding_RLE_DICTIONARY
+		page.DataTable = &Table{Values: []interface{}{int64(1), nil, int64(0)}}
+		return page
+	}
+	dictPage := NewDictPage()
+	dictPage.DataTable = &Table{Values: []interface{}{"a", "b"}}
+
+	page := newPage()
+	if err := page.DecodeErr(dictPage); err != nil {
+		t.Fatal(err)
+	}
+	if page.DataTable.Values[0] != "b" || page.DataTable.Values[1] != nil || page.DataTable.Values[2] != "a" {
+		t.Errorf("DecodeErr: wrong values %v", page.DataTable.Values)
+	}
+
+	if err := newPage().DecodeErr(nil); err == nil {
+		t.Error("DecodeErr should fail without dictionary page")
+	}
+	page = newPage()
+	page.DataTable.Values[2] = int64(2)
+	if err := page.DecodeErr(dictPage); err == nil {
+		t.Error("DecodeErr should fail with an index out of the dictionary")
+	}
+	//Decode ignores the errors
+	page.Decode(dictPage)
+	newPage().Decode(nil)
+}
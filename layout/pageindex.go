@@ -0,0 +1,69 @@
+package layout
+
+import (
+	"context"
+	"io"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/pkg/errors"
+	"github.com/sabey/parquet-go/encryption"
+	"github.com/sabey/parquet-go/parquet"
+	"github.com/sabey/parquet-go/source"
+)
+
+//Read the ColumnIndex of a column chunk, nil is returned if the chunk has none.
+//chunkCipher decrypts the ColumnIndex of an encrypted chunk, it's nil for plaintext chunks.
+func ReadColumnIndex(pFile source.ParquetFile, columnChunk *parquet.ColumnChunk, chunkCipher *encryption.ChunkCipher) (*parquet.ColumnIndex, error) {
+	if !columnChunk.IsSetColumnIndexOffset() || !columnChunk.IsSetColumnIndexLength() {
+		return nil, nil
+	}
+
+	columnIndex := parquet.NewColumnIndex()
+	err := readIndex(pFile, columnChunk.GetColumnIndexOffset(), columnChunk.GetColumnIndexLength(), columnIndex, chunkCipher, encryption.ColumnIndex)
+	if err != nil {
+		return nil, errors.Wrap(err, "readIndex")
+	}
+	return columnIndex, nil
+}
+
+//Read the OffsetIndex of a column chunk, nil is returned if the chunk has none.
+//chunkCipher decrypts the OffsetIndex of an encrypted chunk, it's nil for plaintext chunks.
+func ReadOffsetIndex(pFile source.ParquetFile, columnChunk *parquet.ColumnChunk, chunkCipher *encryption.ChunkCipher) (*parquet.OffsetIndex, error) {
+	if !columnChunk.IsSetOffsetIndexOffset() || !columnChunk.IsSetOffsetIndexLength() {
+		return nil, nil
+	}
+
+	offsetIndex := parquet.NewOffsetIndex()
+	err := readIndex(pFile, columnChunk.GetOffsetIndexOffset(), columnChunk.GetOffsetIndexLength(), offsetIndex, chunkCipher, encryption.OffsetIndex)
+	if err != nil {
+		return nil, errors.Wrap(err, "readIndex")
+	}
+	return offsetIndex, nil
+}
+
+//readIndex reads a ColumnIndex or an OffsetIndex to index
+func readIndex(pFile source.ParquetFile, offset int64, length int32, index thrift.TStruct, chunkCipher *encryption.ChunkCipher, moduleType encryption.ModuleType) error {
+	if length < 0 {
+		return errors.Errorf("invalid index length: %v", length)
+	}
+	if _, err := pFile.Seek(offset, io.SeekStart); err != nil {
+		return errors.Wrap(err, "pFile.Seek")
+	}
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(pFile, buf); err != nil {
+		return errors.Wrap(err, "io.ReadFull")
+	}
+
+	if chunkCipher != nil {
+		var err error
+		if buf, err = chunkCipher.Decrypt(buf, moduleType, 0); err != nil {
+			return errors.Wrap(err, "chunkCipher.Decrypt")
+		}
+	}
+	td := thrift.NewTDeserializer()
+	td.Protocol = thrift.NewTCompactProtocolFactory().GetProtocol(td.Transport)
+	if err := td.Read(context.TODO(), index, buf); err != nil {
+		return errors.Wrap(err, "td.Read")
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package layout
 
 import (
 	"github.com/sabey/parquet-go/common"
+	"github.com/sabey/parquet-go/compress"
 	"github.com/sabey/parquet-go/parquet"
 )
 
@@ -15,6 +16,7 @@ func NewTableFromTable(src *Table) *Table {
 	table.MaxDefinitionLevel = 0
 	table.MaxRepetitionLevel = 0
 	table.Info = src.Info
+	table.Compressor = src.Compressor
 	return table
 }
 
@@ -46,6 +48,8 @@ type Table struct {
 
 	//Tag info
 	Info *common.Tag
+	//Compressor of the pages, the registered compressor of the codec is used if it's nil
+	Compressor *compress.Compressor
 }
 
 //Merge several tables to one table(the first table)
@@ -0,0 +1,401 @@
+package layout
+
+import (
+	"bytes"
+	"math/bits"
+
+	"github.com/pkg/errors"
+	"github.com/sabey/parquet-go/compress"
+	"github.com/sabey/parquet-go/encoding"
+	"github.com/sabey/parquet-go/parquet"
+	"github.com/sabey/parquet-go/schema"
+)
+
+//TypedValues stores values in the slice of their physical type. The values of
+//BYTE_ARRAY, FIXED_LEN_BYTE_ARRAY and INT96 are stored in ByteArrays.
+type TypedValues struct {
+	Type       parquet.Type
+	Booleans   []bool
+	Int32s     []int32
+	Int64s     []int64
+	Float32s   []float32
+	Float64s   []float64
+	ByteArrays [][]byte
+}
+
+//Create typed values of a physical type
+func NewTypedValues(dataType parquet.Type, n int) *TypedValues {
+	values := &TypedValues{Type: dataType}
+	switch dataType {
+	case parquet.Type_BOOLEAN:
+		values.Booleans = make([]bool, n)
+	case parquet.Type_INT32:
+		values.Int32s = make([]int32, n)
+	case parquet.Type_INT64:
+		values.Int64s = make([]int64, n)
+	case parquet.Type_FLOAT:
+		values.Float32s = make([]float32, n)
+	case parquet.Type_DOUBLE:
+		values.Float64s = make([]float64, n)
+	default:
+		values.ByteArrays = make([][]byte, n)
+	}
+	return values
+}
+
+//Number of values
+func (values *TypedValues) Len() int {
+	switch values.Type {
+	case parquet.Type_BOOLEAN:
+		return len(values.Booleans)
+	case parquet.Type_INT32:
+		return len(values.Int32s)
+	case parquet.Type_INT64:
+		return len(values.Int64s)
+	case parquet.Type_FLOAT:
+		return len(values.Float32s)
+	case parquet.Type_DOUBLE:
+		return len(values.Float64s)
+	default:
+		return len(values.ByteArrays)
+	}
+}
+
+//Value returns the value i as it's stored in a Table
+func (values *TypedValues) Value(i int) interface{} {
+	switch values.Type {
+	case parquet.Type_BOOLEAN:
+		return values.Booleans[i]
+	case parquet.Type_INT32:
+		return values.Int32s[i]
+	case parquet.Type_INT64:
+		return values.Int64s[i]
+	case parquet.Type_FLOAT:
+		return values.Float32s[i]
+	case parquet.Type_DOUBLE:
+		return values.Float64s[i]
+	default:
+		return string(values.ByteArrays[i])
+	}
+}
+
+//SetValue sets the value i from a value stored in a Table
+func (values *TypedValues) SetValue(i int, value interface{}) error {
+	var ok bool
+	switch values.Type {
+	case parquet.Type_BOOLEAN:
+		values.Booleans[i], ok = value.(bool)
+	case parquet.Type_INT32:
+		values.Int32s[i], ok = value.(int32)
+	case parquet.Type_INT64:
+		values.Int64s[i], ok = value.(int64)
+	case parquet.Type_FLOAT:
+		values.Float32s[i], ok = value.(float32)
+	case parquet.Type_DOUBLE:
+		values.Float64s[i], ok = value.(float64)
+	default:
+		var s string
+		s, ok = value.(string)
+		values.ByteArrays[i] = []byte(s)
+	}
+	if !ok {
+		return errors.Errorf("value %v is not %v", value, values.Type)
+	}
+	return nil
+}
+
+//Copy copies the values from:to of src to the position dst, the values must have the same type
+func (values *TypedValues) Copy(dst int, src *TypedValues, from int, to int) {
+	switch values.Type {
+	case parquet.Type_BOOLEAN:
+		copy(values.Booleans[dst:], src.Booleans[from:to])
+	case parquet.Type_INT32:
+		copy(values.Int32s[dst:], src.Int32s[from:to])
+	case parquet.Type_INT64:
+		copy(values.Int64s[dst:], src.Int64s[from:to])
+	case parquet.Type_FLOAT:
+		copy(values.Float32s[dst:], src.Float32s[from:to])
+	case parquet.Type_DOUBLE:
+		copy(values.Float64s[dst:], src.Float64s[from:to])
+	default:
+		copy(values.ByteArrays[dst:], src.ByteArrays[from:to])
+	}
+}
+
+//Gather sets the values to the dictionary values of the indexes
+func (values *TypedValues) Gather(dict *TypedValues, indexes []int32) error {
+	ln := int32(dict.Len())
+	for i, index := range indexes {
+		if index < 0 || index >= ln {
+			return errors.Errorf("dictionary index %v out of range %v", index, ln)
+		}
+		switch values.Type {
+		case parquet.Type_BOOLEAN:
+			values.Booleans[i] = dict.Booleans[index]
+		case parquet.Type_INT32:
+			values.Int32s[i] = dict.Int32s[index]
+		case parquet.Type_INT64:
+			values.Int64s[i] = dict.Int64s[index]
+		case parquet.Type_FLOAT:
+			values.Float32s[i] = dict.Float32s[index]
+		case parquet.Type_DOUBLE:
+			values.Float64s[i] = dict.Float64s[index]
+		default:
+			values.ByteArrays[i] = dict.ByteArrays[index]
+		}
+	}
+	return nil
+}
+
+//ReadTypedValues decodes cnt values of a page. The values of dictionary encoded pages are taken from dict.
+//The encodings without a typed decoder are decoded by ReadDataPageValues and converted.
+func ReadTypedValues(bytesReader *bytes.Reader, encodingMethod parquet.Encoding, dataType parquet.Type, convertedType parquet.ConvertedType, cnt uint64, bitWidth uint64, dict *TypedValues) (*TypedValues, error) {
+	var err error
+	values := NewTypedValues(dataType, int(cnt))
+	if cnt <= 0 {
+		return values, nil
+	}
+
+	switch {
+	case encodingMethod == parquet.Encoding_PLAIN:
+		switch dataType {
+		case parquet.Type_BOOLEAN:
+			err = encoding.ReadPlainBOOLEANs(bytesReader, values.Booleans)
+		case parquet.Type_INT32:
+			err = encoding.ReadPlainINT32s(bytesReader, values.Int32s)
+		case parquet.Type_INT64:
+			err = encoding.ReadPlainINT64s(bytesReader, values.Int64s)
+		case parquet.Type_INT96:
+			err = encoding.ReadPlainINT96s(bytesReader, values.ByteArrays)
+		case parquet.Type_FLOAT:
+			err = encoding.ReadPlainFLOATs(bytesReader, values.Float32s)
+		case parquet.Type_DOUBLE:
+			err = encoding.ReadPlainDOUBLEs(bytesReader, values.Float64s)
+		case parquet.Type_BYTE_ARRAY:
+			err = encoding.ReadPlainBYTE_ARRAYs(bytesReader, values.ByteArrays)
+		case parquet.Type_FIXED_LEN_BYTE_ARRAY:
+			err = encoding.ReadPlainFIXED_LEN_BYTE_ARRAYs(bytesReader, values.ByteArrays, bitWidth)
+		default:
+			return nil, errors.Errorf("Unknown parquet type")
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "encoding.ReadPlain")
+		}
+		return values, nil
+
+	case encodingMethod == parquet.Encoding_PLAIN_DICTIONARY || encodingMethod == parquet.Encoding_RLE_DICTIONARY:
+		if dict == nil {
+			return nil, errors.Errorf("no dictionary page")
+		}
+		b, err := bytesReader.ReadByte()
+		if err != nil {
+			return nil, errors.Wrap(err, "bytesReader.ReadByte")
+		}
+		indexes := make([]int32, cnt)
+		if err = encoding.ReadRLEBitPackedHybridINT32s(bytesReader, uint64(b), uint64(bytesReader.Len()), indexes); err != nil {
+			return nil, errors.Wrap(err, "encoding.ReadRLEBitPackedHybridINT32s")
+		}
+		if err = values.Gather(dict, indexes); err != nil {
+			return nil, errors.Wrap(err, "values.Gather")
+		}
+		return values, nil
+
+	case encodingMethod == parquet.Encoding_RLE && dataType == parquet.Type_BOOLEAN:
+		buf := make([]int32, cnt)
+		if err = encoding.ReadRLEBitPackedHybridINT32s(bytesReader, 1, 0, buf); err != nil {
+			return nil, errors.Wrap(err, "encoding.ReadRLEBitPackedHybridINT32s")
+		}
+		for i, v := range buf {
+			values.Booleans[i] = v > 0
+		}
+		return values, nil
+
+	default:
+		vs, err := ReadDataPageValues(bytesReader, encodingMethod, dataType, convertedType, cnt, bitWidth)
+		if err != nil {
+			return nil, errors.Wrap(err, "ReadDataPageValues")
+		}
+		if len(vs) < int(cnt) {
+			return nil, errors.Errorf("%v values expected, %v decoded", cnt, len(vs))
+		}
+		for i := 0; i < int(cnt); i++ {
+			if err = values.SetValue(i, vs[i]); err != nil {
+				return nil, errors.Wrap(err, "values.SetValue")
+			}
+		}
+		return values, nil
+	}
+}
+
+//TypedPage is a data page decoded without boxing its values
+type TypedPage struct {
+	RepetitionLevels []int32
+	DefinitionLevels []int32
+	//Values of the levels equal to the maximum definition level
+	Values *TypedValues
+	//Number of rows which start in the page
+	NumRows int64
+}
+
+//Create a typed page from the values of a table
+func NewTypedPageFromTable(table *Table, dataType parquet.Type, maxDefinitionLevel int32) (*TypedPage, error) {
+	page := &TypedPage{
+		RepetitionLevels: append([]int32{}, table.RepetitionLevels...),
+		DefinitionLevels: append([]int32{}, table.DefinitionLevels...),
+	}
+	numValues := 0
+	for i, dl := range table.DefinitionLevels {
+		if dl == maxDefinitionLevel {
+			numValues++
+		}
+		if table.RepetitionLevels[i] == 0 {
+			page.NumRows++
+		}
+	}
+
+	page.Values = NewTypedValues(dataType, numValues)
+	j := 0
+	for i, dl := range table.DefinitionLevels {
+		if dl == maxDefinitionLevel {
+			if err := page.Values.SetValue(j, table.Values[i]); err != nil {
+				return nil, errors.Wrap(err, "page.Values.SetValue")
+			}
+			j++
+		}
+	}
+	return page, nil
+}
+
+//AppendTo appends the levels and the values of the page to a table, from the level levelIndex
+//whose value, if it's not null, is the value valueIndex
+func (page *TypedPage) AppendTo(table *Table, levelIndex int, valueIndex int, maxDefinitionLevel int32) {
+	for i := levelIndex; i < len(page.DefinitionLevels); i++ {
+		dl := page.DefinitionLevels[i]
+		var value interface{}
+		if dl == maxDefinitionLevel {
+			value = page.Values.Value(valueIndex)
+			valueIndex++
+		}
+		table.Values = append(table.Values, value)
+		table.DefinitionLevels = append(table.DefinitionLevels, dl)
+		table.RepetitionLevels = append(table.RepetitionLevels, page.RepetitionLevels[i])
+	}
+}
+
+//DecodeTypedDict decodes the values of a dictionary page read by ReadPageRawData
+func (p *Page) DecodeTypedDict() (*TypedValues, error) {
+	if p.Header.GetType() != parquet.PageType_DICTIONARY_PAGE {
+		return nil, errors.Errorf("Not a dictionary page")
+	}
+	buf, err := compress.UncompressWithSize(p.RawData, p.CompressType, uncompressedDataSize(p.Header))
+	if err != nil {
+		return nil, errors.Wrap(err, "compress.UncompressWithSize")
+	}
+	values, err := ReadTypedValues(bytes.NewReader(buf),
+		parquet.Encoding_PLAIN,
+		p.Schema.GetType(),
+		-1,
+		uint64(p.Header.DictionaryPageHeader.GetNumValues()),
+		uint64(p.Schema.GetTypeLength()),
+		nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "ReadTypedValues")
+	}
+	return values, nil
+}
+
+//DecodeTyped decodes a data page read by ReadPageRawData. The values of dictionary encoded
+//pages are taken from dict.
+func (p *Page) DecodeTyped(schemaHandler *schema.SchemaHandler, dict *TypedValues) (*TypedPage, error) {
+	var (
+		err                                      error
+		numValues                                int
+		encodingType                             parquet.Encoding
+		repetitionLevelsBuf, definitionLevelsBuf []byte
+		rll, dll                                 uint64
+		dataBuf                                  []byte
+	)
+
+	switch p.Header.GetType() {
+	case parquet.PageType_DATA_PAGE:
+		numValues = int(p.Header.DataPageHeader.GetNumValues())
+		encodingType = p.Header.DataPageHeader.GetEncoding()
+		if dataBuf, err = compress.UncompressWithSize(p.RawData, p.CompressType, uncompressedDataSize(p.Header)); err != nil {
+			return nil, errors.Wrap(err, "compress.UncompressWithSize")
+		}
+		repetitionLevelsBuf, definitionLevelsBuf = dataBuf, dataBuf
+
+	case parquet.PageType_DATA_PAGE_V2:
+		header := p.Header.DataPageHeaderV2
+		numValues = int(header.GetNumValues())
+		encodingType = header.GetEncoding()
+		rll, dll = uint64(header.GetRepetitionLevelsByteLength()), uint64(header.GetDefinitionLevelsByteLength())
+		if rll+dll > uint64(len(p.RawData)) {
+			return nil, errors.Errorf("invalid level lengths of the page")
+		}
+		repetitionLevelsBuf, definitionLevelsBuf = p.RawData[:rll], p.RawData[rll:rll+dll]
+		dataBuf = p.RawData[rll+dll:]
+		if len(dataBuf) > 0 && header.GetIsCompressed() {
+			if dataBuf, err = compress.UncompressWithSize(dataBuf, p.CompressType, uncompressedDataSize(p.Header)); err != nil {
+				return nil, errors.Wrap(err, "compress.UncompressWithSize")
+			}
+		}
+
+	default:
+		return nil, errors.Errorf("Unsupported page type")
+	}
+
+	maxDefinitionLevel, _ := schemaHandler.MaxDefinitionLevel(p.Path)
+	maxRepetitionLevel, _ := schemaHandler.MaxRepetitionLevel(p.Path)
+	page := &TypedPage{
+		RepetitionLevels: make([]int32, numValues),
+		DefinitionLevels: make([]int32, numValues),
+	}
+
+	//the levels of V1 pages are before the values, they are prefixed with their length
+	bytesReader := bytes.NewReader(repetitionLevelsBuf)
+	if maxRepetitionLevel > 0 && (p.Header.GetType() == parquet.PageType_DATA_PAGE || rll > 0) {
+		bitWidth := uint64(bits.Len32(uint32(maxRepetitionLevel)))
+		if err = encoding.ReadRLEBitPackedHybridINT32s(bytesReader, bitWidth, rll, page.RepetitionLevels); err != nil {
+			return nil, errors.Wrap(err, "encoding.ReadRLEBitPackedHybridINT32s")
+		}
+	}
+	if p.Header.GetType() == parquet.PageType_DATA_PAGE_V2 {
+		bytesReader = bytes.NewReader(definitionLevelsBuf)
+	}
+	if maxDefinitionLevel > 0 && (p.Header.GetType() == parquet.PageType_DATA_PAGE || dll > 0) {
+		bitWidth := uint64(bits.Len32(uint32(maxDefinitionLevel)))
+		if err = encoding.ReadRLEBitPackedHybridINT32s(bytesReader, bitWidth, dll, page.DefinitionLevels); err != nil {
+			return nil, errors.Wrap(err, "encoding.ReadRLEBitPackedHybridINT32s")
+		}
+	}
+	if p.Header.GetType() == parquet.PageType_DATA_PAGE_V2 {
+		bytesReader = bytes.NewReader(dataBuf)
+	}
+
+	numNotNull := 0
+	for i, dl := range page.DefinitionLevels {
+		if dl == maxDefinitionLevel {
+			numNotNull++
+		}
+		if page.RepetitionLevels[i] == 0 {
+			page.NumRows++
+		}
+	}
+
+	var ct parquet.ConvertedType = -1
+	if p.Schema.IsSetConvertedType() {
+		ct = p.Schema.GetConvertedType()
+	}
+	page.Values, err = ReadTypedValues(bytesReader,
+		encodingType,
+		p.Schema.GetType(),
+		ct,
+		uint64(numNotNull),
+		uint64(p.Schema.GetTypeLength()),
+		dict)
+	if err != nil {
+		return nil, errors.Wrap(err, "ReadTypedValues")
+	}
+	return page, nil
+}
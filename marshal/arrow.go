@@ -1,6 +1,7 @@
 package marshal
 
 import (
+	"github.com/pkg/errors"
 	"github.com/sabey/parquet-go/common"
 	"github.com/sabey/parquet-go/layout"
 	"github.com/sabey/parquet-go/parquet"
@@ -12,6 +13,9 @@ import (
 // column by column since the wrapper ParquetWriter uses the number of rows
 // to execute intermediate flush depending on the size of the objects,
 // determined by row, which are currently written.
+//
+// A row holds a value for each field of the root, with the layout of
+// common.ArrowNestedColToParquetCol.
 func MarshalArrow(recs []interface{}, schemaHandler *schema.SchemaHandler) (
 	tb *map[string]*layout.Table, err error) {
 	res := make(map[string]*layout.Table)
@@ -19,27 +23,121 @@ func MarshalArrow(recs []interface{}, schemaHandler *schema.SchemaHandler) (
 		return &res, nil
 	}
 
-	for i := 0; i < len(recs[0].([]interface{})); i++ {
-		pathStr := schemaHandler.GetRootInName() + common.PAR_GO_PATH_DELIMITER +
-			schemaHandler.Infos[i+1].InName
+	for i := 0; i < len(schemaHandler.SchemaElements); i++ {
+		element := schemaHandler.SchemaElements[i]
+		if element.GetNumChildren() > 0 {
+			continue
+		}
+		pathStr := schemaHandler.IndexMap[int32(i)]
 		table := layout.NewEmptyTable()
 		res[pathStr] = table
 		table.Path = common.StrToPath(pathStr)
-		table.MaxDefinitionLevel = 0
-		table.MaxRepetitionLevel = 0
-		table.RepetitionType = parquet.FieldRepetitionType_REQUIRED
-		table.Schema =
-			schemaHandler.SchemaElements[schemaHandler.MapIndex[pathStr]]
-		table.Info = schemaHandler.Infos[i+1]
+		table.MaxDefinitionLevel, _ = schemaHandler.MaxDefinitionLevel(table.Path)
+		table.MaxRepetitionLevel, _ = schemaHandler.MaxRepetitionLevel(table.Path)
+		table.RepetitionType = element.GetRepetitionType()
+		table.Schema = element
+		table.Info = schemaHandler.Infos[i]
 		// Pre-allocate these arrays for efficiency
 		table.Values = make([]interface{}, 0, len(recs))
-		table.RepetitionLevels = make([]int32, len(recs))
-		table.DefinitionLevels = make([]int32, len(recs))
+		table.RepetitionLevels = make([]int32, 0, len(recs))
+		table.DefinitionLevels = make([]int32, 0, len(recs))
+	}
 
-		for j := 0; j < len(recs); j++ {
-			rec := recs[j].([]interface{})[i]
-			table.Values = append(table.Values, rec)
+	m := &arrowMarshaler{schemaHandler: schemaHandler, tables: res}
+	for _, rec := range recs {
+		if err = m.marshalGroup(0, rec, 0, 0, 0); err != nil {
+			return nil, err
 		}
 	}
 	return &res, nil
 }
+
+// arrowMarshaler appends the values of the rows to the tables of the leaves
+type arrowMarshaler struct {
+	schemaHandler *schema.SchemaHandler
+	tables        map[string]*layout.Table
+}
+
+// marshalField appends the value of the field of schema element index.
+// dl and rl are the levels of the value of the parent, depth the number of
+// repeated fields of its path.
+func (m *arrowMarshaler) marshalField(index int, value interface{}, dl, rl, depth int32) error {
+	switch m.schemaHandler.SchemaElements[index].GetRepetitionType() {
+	case parquet.FieldRepetitionType_OPTIONAL:
+		if value == nil {
+			m.marshalNull(index, dl, rl)
+			return nil
+		}
+		return m.marshalGroup(index, value, dl+1, rl, depth)
+
+	case parquet.FieldRepetitionType_REPEATED:
+		values, ok := value.([]interface{})
+		if !ok {
+			return errors.Errorf("invalid values %v of repeated field %v",
+				value, m.schemaHandler.IndexMap[int32(index)])
+		}
+		if len(values) == 0 {
+			m.marshalNull(index, dl, rl)
+			return nil
+		}
+		for i, v := range values {
+			if i > 0 {
+				rl = depth + 1
+			}
+			if err := m.marshalGroup(index, v, dl+1, rl, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if value == nil && m.schemaHandler.SchemaElements[index].GetNumChildren() > 0 {
+		return errors.Errorf("null value of required field %v",
+			m.schemaHandler.IndexMap[int32(index)])
+	}
+	return m.marshalGroup(index, value, dl, rl, depth)
+}
+
+// marshalGroup appends a value which is set, the value of a leaf or the
+// values of the fields of a group
+func (m *arrowMarshaler) marshalGroup(index int, value interface{}, dl, rl, depth int32) error {
+	elements := m.schemaHandler.SchemaElements
+	numChildren := int(elements[index].GetNumChildren())
+	if numChildren == 0 {
+		table := m.tables[m.schemaHandler.IndexMap[int32(index)]]
+		table.Values = append(table.Values, value)
+		table.DefinitionLevels = append(table.DefinitionLevels, dl)
+		table.RepetitionLevels = append(table.RepetitionLevels, rl)
+		return nil
+	}
+
+	values, ok := value.([]interface{})
+	if !ok || len(values) != numChildren {
+		return errors.Errorf("invalid values %v of group %v",
+			value, m.schemaHandler.IndexMap[int32(index)])
+	}
+	child := index + 1
+	for _, v := range values {
+		if err := m.marshalField(child, v, dl, rl, depth); err != nil {
+			return err
+		}
+		child += common.SchemaSize(elements[child:])
+	}
+	return nil
+}
+
+// marshalNull appends a null value to the leaves of the field of schema
+// element index
+func (m *arrowMarshaler) marshalNull(index int, dl, rl int32) {
+	elements := m.schemaHandler.SchemaElements
+	end := index + common.SchemaSize(elements[index:])
+	for i := index; i < end; i++ {
+		if elements[i].GetNumChildren() > 0 {
+			continue
+		}
+		table := m.tables[m.schemaHandler.IndexMap[int32(i)]]
+		table.Values = append(table.Values, nil)
+		table.DefinitionLevels = append(table.DefinitionLevels, dl)
+		table.RepetitionLevels = append(table.RepetitionLevels, rl)
+	}
+}
@@ -282,21 +282,24 @@ func Marshal(srcInterface []interface{}, schemaHandler *schema.SchemaHandler) (t
 			node := stack[ln-1]
 			stack = stack[:ln-1]
 
+			schemaIndex := schemaHandler.MapIndex[node.PathMap.Path]
+
 			tk := reflect.Interface
+			native, marshaler := false, false
 			if node.Val.IsValid() {
 				tk = node.Val.Type().Kind()
+				native = types.IsNativeType(node.Val.Type()) || types.IsByteSliceColumn(node.Val.Type(), schemaHandler.SchemaElements[schemaIndex])
+				marshaler = types.IsMarshalerColumn(node.Val.Type(), schemaHandler.SchemaElements[schemaIndex])
 			}
 			var m Marshaler
 
-			schemaIndex := schemaHandler.MapIndex[node.PathMap.Path]
-
 			if tk == reflect.Ptr {
 				m = &ParquetPtr{}
-			} else if tk == reflect.Struct {
+			} else if tk == reflect.Struct && !native && !marshaler {
 				m = &ParquetStruct{}
-			} else if tk == reflect.Slice {
+			} else if tk == reflect.Slice && !native && !marshaler {
 				m = &ParquetSlice{schemaHandler: schemaHandler}
-			} else if tk == reflect.Map {
+			} else if tk == reflect.Map && !marshaler {
 				schemaIndex := schemaHandler.MapIndex[node.PathMap.Path]
 				sele := schemaHandler.SchemaElements[schemaIndex]
 				if !sele.IsSetConvertedType() {
@@ -311,7 +314,18 @@ func Marshal(srcInterface []interface{}, schemaHandler *schema.SchemaHandler) (t
 				if node.Val.IsValid() {
 					v = node.Val.Interface()
 				}
-				table.Values = append(table.Values, types.InterfaceToParquetType(v, schema.Type))
+				if native {
+					if v, err = types.NativeToParquetType(v, schema); err != nil {
+						return nil, errors.Wrap(err, "types.NativeToParquetType")
+					}
+				} else if marshaler {
+					if v, err = types.MarshalerToParquetType(v, schema); err != nil {
+						return nil, errors.Wrap(err, "types.MarshalerToParquetType")
+					}
+				} else {
+					v = types.InterfaceToParquetType(v, schema.Type)
+				}
+				table.Values = append(table.Values, v)
 				table.DefinitionLevels = append(table.DefinitionLevels, node.DL)
 				table.RepetitionLevels = append(table.RepetitionLevels, node.RL)
 				continue
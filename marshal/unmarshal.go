@@ -9,6 +9,7 @@ import (
 	"github.com/sabey/parquet-go/layout"
 	"github.com/sabey/parquet-go/parquet"
 	"github.com/sabey/parquet-go/schema"
+	"github.com/sabey/parquet-go/types"
 )
 
 //Record Map KeyValue pair
@@ -121,6 +122,20 @@ func Unmarshal(tableMap *map[string]*layout.Table, bgn int, end int, dstInterfac
 				_, cT := schemaHandler.SchemaElements[schemaIndex].Type, schemaHandler.SchemaElements[schemaIndex].ConvertedType
 
 				poType := po.Type()
+				if types.IsNativeType(poType) || types.IsByteSliceColumn(poType, schemaHandler.SchemaElements[schemaIndex]) {
+					value, err := types.ParquetTypeToNative(val, schemaHandler.SchemaElements[schemaIndex], poType)
+					if err != nil {
+						return errors.Wrap(err, "types.ParquetTypeToNative")
+					}
+					po.Set(value)
+					break OuterLoop
+				} else if types.IsMarshalerColumn(poType, schemaHandler.SchemaElements[schemaIndex]) {
+					if err := types.ParquetTypeToUnmarshaler(val, schemaHandler.SchemaElements[schemaIndex], po); err != nil {
+						return errors.Wrap(err, "types.ParquetTypeToUnmarshaler")
+					}
+					break OuterLoop
+				}
+
 				switch poType.Kind() {
 				case reflect.Slice:
 					cTIsList := cT != nil && *cT == parquet.ConvertedType_LIST
@@ -236,7 +251,7 @@ func Unmarshal(tableMap *map[string]*layout.Table, bgn int, end int, dstInterfac
 
 				default:
 					value := reflect.ValueOf(val)
-					if po.Kind() != value.Kind() {
+					if poType != value.Type() {
 						value = value.Convert(poType)
 					}
 					po.Set(value)
@@ -2,8 +2,13 @@ package marshal
 
 import (
 	"fmt"
+	"math/big"
+	"net"
+	"reflect"
 	"testing"
+	"time"
 
+	"github.com/sabey/parquet-go/parquet"
 	. "github.com/sabey/parquet-go/schema"
 )
 
@@ -119,3 +124,271 @@ func TestMarshalUnmarshal(t *testing.T) {
 	}
 
 }
+
+type Native struct {
+	Millis   time.Time     `parquet:"name=millis, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+	Micros   time.Time     `parquet:"name=micros, type=INT64, logicaltype=TIMESTAMP, logicaltype.isadjustedtoutc=true, logicaltype.unit=MICROS"`
+	Nanos    *time.Time    `parquet:"name=nanos, type=INT64, logicaltype=TIMESTAMP, logicaltype.isadjustedtoutc=true, logicaltype.unit=NANOS"`
+	Int96    time.Time     `parquet:"name=int96, type=INT96"`
+	Date     time.Time     `parquet:"name=date, type=INT32, convertedtype=DATE"`
+	Time     time.Time     `parquet:"name=time, type=INT32, convertedtype=TIME_MILLIS"`
+	Duration time.Duration `parquet:"name=duration, type=INT64, logicaltype=TIME, logicaltype.isadjustedtoutc=true, logicaltype.unit=MICROS"`
+	Int32    *big.Int      `parquet:"name=int32, type=INT32, convertedtype=DECIMAL, precision=9, scale=2"`
+	Int64    big.Int       `parquet:"name=int64, type=INT64, convertedtype=DECIMAL, precision=18, scale=3"`
+	Fixed    *big.Rat      `parquet:"name=fixed, type=FIXED_LEN_BYTE_ARRAY, length=12, logicaltype=DECIMAL, logicaltype.precision=20, logicaltype.scale=4"`
+	Bytes    *big.Rat      `parquet:"name=bytes, type=BYTE_ARRAY, convertedtype=DECIMAL, precision=30, scale=2"`
+	UUID     [16]byte      `parquet:"name=uuid, type=FIXED_LEN_BYTE_ARRAY, length=16, logicaltype=UUID"`
+	Nil      *big.Int      `parquet:"name=nil, type=INT64, convertedtype=DECIMAL, precision=18, scale=0"`
+}
+
+func TestMarshalUnmarshalNative(t *testing.T) {
+	schemaHandler, err := NewSchemaHandlerFromStruct(new(Native))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2021, 3, 4, 5, 6, 7, 891234567, time.UTC)
+	fixed, _ := new(big.Rat).SetString("-1234567890.1234")
+	bytes, _ := new(big.Rat).SetString("98765432109876543210.5")
+	src := Native{
+		Millis:   now.Truncate(time.Millisecond),
+		Micros:   now.Truncate(time.Microsecond),
+		Nanos:    &now,
+		Int96:    now.Truncate(time.Microsecond),
+		Date:     now.Truncate(24 * time.Hour),
+		Time:     time.Date(1970, 1, 1, 5, 6, 7, 891000000, time.UTC),
+		Duration: 90*time.Minute + time.Microsecond,
+		Int32:    big.NewInt(-12345),
+		Int64:    *big.NewInt(1234567890123),
+		Fixed:    fixed,
+		Bytes:    bytes,
+		UUID:     [16]byte{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3, 0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00},
+	}
+
+	tables, err := Marshal([]interface{}{src}, schemaHandler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := make([]Native, 0)
+	if err = Unmarshal(tables, 0, 1, &dst, schemaHandler, ""); err != nil {
+		t.Fatal(err)
+	}
+	if len(dst) != 1 {
+		t.Fatalf("Fail expect 1 row, get %d", len(dst))
+	}
+	res := dst[0]
+
+	times := [][2]time.Time{
+		{src.Millis, res.Millis}, {src.Micros, res.Micros}, {*src.Nanos, *res.Nanos},
+		{src.Int96, res.Int96}, {src.Date, res.Date}, {src.Time, res.Time},
+	}
+	for i, ts := range times {
+		if !ts[0].Equal(ts[1]) {
+			t.Errorf("Fail time %d expect %v, get %v", i, ts[0], ts[1])
+		}
+	}
+	if src.Duration != res.Duration {
+		t.Errorf("Fail expect %v, get %v", src.Duration, res.Duration)
+	}
+	if src.Int32.Cmp(res.Int32) != 0 || src.Int64.Cmp(&res.Int64) != 0 {
+		t.Errorf("Fail expect %v %v, get %v %v", src.Int32, &src.Int64, res.Int32, &res.Int64)
+	}
+	if src.Fixed.Cmp(res.Fixed) != 0 || src.Bytes.Cmp(res.Bytes) != 0 {
+		t.Errorf("Fail expect %v %v, get %v %v", src.Fixed, src.Bytes, res.Fixed, res.Bytes)
+	}
+	if src.UUID != res.UUID {
+		t.Errorf("Fail expect %v, get %v", src.UUID, res.UUID)
+	}
+	if res.Nil != nil {
+		t.Errorf("Fail expect nil, get %v", res.Nil)
+	}
+
+	//the values not fitting in the decimal are errors
+	src.Int32 = big.NewInt(1 << 40)
+	if _, err = Marshal([]interface{}{src}, schemaHandler); err == nil {
+		t.Error("Marshal should fail on decimal overflow")
+	}
+}
+
+type Money struct {
+	Cents int64
+}
+
+func (m Money) MarshalParquet(schema *parquet.SchemaElement) (interface{}, error) {
+	return m.Cents, nil
+}
+
+func (m *Money) UnmarshalParquet(src interface{}, schema *parquet.SchemaElement) error {
+	m.Cents = src.(int64)
+	return nil
+}
+
+type Level int
+
+var levelNames = []string{"debug", "info", "error"}
+
+func (l Level) MarshalText() ([]byte, error) {
+	if int(l) >= len(levelNames) {
+		return nil, fmt.Errorf("unknown level %d", l)
+	}
+	return []byte(levelNames[l]), nil
+}
+
+func (l *Level) UnmarshalText(text []byte) error {
+	for i, name := range levelNames {
+		if name == string(text) {
+			*l = Level(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown level %s", text)
+}
+
+type Point struct {
+	X, Y byte
+}
+
+func (p Point) MarshalBinary() ([]byte, error) {
+	return []byte{p.X, p.Y}, nil
+}
+
+func (p *Point) UnmarshalBinary(data []byte) error {
+	p.X, p.Y = data[0], data[1]
+	return nil
+}
+
+type Marshalers struct {
+	Price    Money            `parquet:"name=price, type=INT64, convertedtype=DECIMAL, precision=18, scale=2"`
+	Discount *Money           `parquet:"name=discount, type=INT64"`
+	IP       net.IP           `parquet:"name=ip"`
+	Level    Level            `parquet:"name=level"`
+	Levels   []Level          `parquet:"name=levels"`
+	Counts   map[Level]int32  `parquet:"name=counts, valuetype=INT32"`
+	Point    Point            `parquet:"name=point, type=BYTE_ARRAY"`
+	Points   map[string]Point `parquet:"name=points, keytype=BYTE_ARRAY, keyconvertedtype=UTF8, valuetype=BYTE_ARRAY"`
+}
+
+func TestMarshalUnmarshalMarshalers(t *testing.T) {
+	schemaHandler, err := NewSchemaHandlerFromStruct(new(Marshalers))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ip := schemaHandler.SchemaElements[schemaHandler.MapIndex["Parquet_go_root\x01IP"]]
+	if ip.GetType() != parquet.Type_BYTE_ARRAY || ip.GetConvertedType() != parquet.ConvertedType_UTF8 {
+		t.Errorf("Fail expect BYTE_ARRAY UTF8, get %v %v", ip.GetType(), ip.GetConvertedType())
+	}
+	point := schemaHandler.SchemaElements[schemaHandler.MapIndex["Parquet_go_root\x01Point"]]
+	if point.GetType() != parquet.Type_BYTE_ARRAY || point.ConvertedType != nil {
+		t.Errorf("Fail expect BYTE_ARRAY, get %v %v", point.GetType(), point.ConvertedType)
+	}
+
+	src := []interface{}{
+		Marshalers{
+			Price:    Money{Cents: 1999},
+			Discount: &Money{Cents: 500},
+			IP:       net.ParseIP("192.168.0.1"),
+			Level:    2,
+			Levels:   []Level{0, 1},
+			Counts:   map[Level]int32{1: 10},
+			Point:    Point{X: 1, Y: 2},
+			Points:   map[string]Point{"a": {X: 3, Y: 4}},
+		},
+		Marshalers{
+			IP:     net.ParseIP("::1"),
+			Level:  1,
+			Levels: []Level{},
+			Counts: map[Level]int32{},
+			Points: map[string]Point{},
+		},
+	}
+	tables, err := Marshal(src, schemaHandler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := (*tables)["Parquet_go_root\x01IP"].Values[0]; v != "192.168.0.1" {
+		t.Errorf("Fail expect 192.168.0.1, get %v", v)
+	}
+
+	dst := make([]Marshalers, 0)
+	if err = Unmarshal(tables, 0, len(src), &dst, schemaHandler, ""); err != nil {
+		t.Fatal(err)
+	}
+	for i := range src {
+		if !reflect.DeepEqual(src[i], dst[i]) {
+			t.Errorf("Fail expect %+v, get %+v", src[i], dst[i])
+		}
+	}
+
+	//the errors of the marshalers are returned
+	if _, err = Marshal([]interface{}{Marshalers{Level: 3}}, schemaHandler); err == nil {
+		t.Error("Marshal should fail on the errors of MarshalText")
+	}
+}
+
+type Color int32
+
+func (c Color) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("color-%d", c)), nil
+}
+
+func (c *Color) UnmarshalText(text []byte) error {
+	_, err := fmt.Sscanf(string(text), "color-%d", c)
+	return err
+}
+
+type Version struct {
+	Major int32 `parquet:"name=major, type=INT32"`
+}
+
+func (v Version) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("v%d", v.Major)), nil
+}
+
+type TypedMarshalers struct {
+	Color   Color   `parquet:"name=color, type=INT32"`
+	Colors  []Color `parquet:"name=colors, type=INT32, repetitiontype=REPEATED"`
+	Name    Color   `parquet:"name=name"`
+	Version Version `parquet:"name=version"`
+}
+
+func TestMarshalUnmarshalTypedMarshalers(t *testing.T) {
+	schemaHandler, err := NewSchemaHandlerFromStruct(new(TypedMarshalers))
+	if err != nil {
+		t.Fatal(err)
+	}
+	//the text marshalers are only used for the byte array columns, the structs stay groups
+	if color := schemaHandler.SchemaElements[schemaHandler.MapIndex["Parquet_go_root\x01Color"]]; color.GetType() != parquet.Type_INT32 {
+		t.Errorf("Fail expect INT32, get %v", color.GetType())
+	}
+	if name := schemaHandler.SchemaElements[schemaHandler.MapIndex["Parquet_go_root\x01Name"]]; name.GetType() != parquet.Type_BYTE_ARRAY {
+		t.Errorf("Fail expect BYTE_ARRAY, get %v", name.GetType())
+	}
+	if _, ok := schemaHandler.MapIndex["Parquet_go_root\x01Version\x01Major"]; !ok {
+		t.Error("Fail expect the group version")
+	}
+
+	src := []interface{}{
+		TypedMarshalers{Color: 2, Colors: []Color{3, 4}, Name: 5, Version: Version{Major: 6}},
+	}
+	tables, err := Marshal(src, schemaHandler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := (*tables)["Parquet_go_root\x01Color"].Values[0]; v != int32(2) {
+		t.Errorf("Fail expect 2, get %v", v)
+	}
+	if v := (*tables)["Parquet_go_root\x01Name"].Values[0]; v != "color-5" {
+		t.Errorf("Fail expect color-5, get %v", v)
+	}
+	if v := (*tables)["Parquet_go_root\x01Version\x01Major"].Values[0]; v != int32(6) {
+		t.Errorf("Fail expect 6, get %v", v)
+	}
+
+	dst := make([]TypedMarshalers, 0)
+	if err = Unmarshal(tables, 0, len(src), &dst, schemaHandler, ""); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(src[0], dst[0]) {
+		t.Errorf("Fail expect %+v, get %+v", src[0], dst[0])
+	}
+}
@@ -7449,6 +7449,11 @@ func (p *PageEncodingStats) String() string {
 // This information can be used to determine if all data pages are
 // dictionary encoded for example *
 //  - BloomFilterOffset: Byte offset from beginning of file to Bloom filter data. *
+//  - BloomFilterLength: Size of Bloom filter data including the serialized header, in bytes.
+// Added in 2.10 so readers may not read this field from old files and
+// it can be obtained after the BloomFilterHeader has been deserialized.
+// Writers should write this field so readers can read the bloom filter
+// in a single I/O.
 type ColumnMetaData struct {
 	Type                  Type                 `thrift:"type,1,required" db:"type" json:"type"`
 	Encodings             []Encoding           `thrift:"encodings,2,required" db:"encodings" json:"encodings"`
@@ -7464,6 +7469,7 @@ type ColumnMetaData struct {
 	Statistics            *Statistics          `thrift:"statistics,12" db:"statistics" json:"statistics,omitempty"`
 	EncodingStats         []*PageEncodingStats `thrift:"encoding_stats,13" db:"encoding_stats" json:"encoding_stats,omitempty"`
 	BloomFilterOffset     *int64               `thrift:"bloom_filter_offset,14" db:"bloom_filter_offset" json:"bloom_filter_offset,omitempty"`
+	BloomFilterLength     *int32               `thrift:"bloom_filter_length,15" db:"bloom_filter_length" json:"bloom_filter_length,omitempty"`
 }
 
 func NewColumnMetaData() *ColumnMetaData {
@@ -7549,6 +7555,15 @@ func (p *ColumnMetaData) GetBloomFilterOffset() int64 {
 	}
 	return *p.BloomFilterOffset
 }
+
+var ColumnMetaData_BloomFilterLength_DEFAULT int32
+
+func (p *ColumnMetaData) GetBloomFilterLength() int32 {
+	if !p.IsSetBloomFilterLength() {
+		return ColumnMetaData_BloomFilterLength_DEFAULT
+	}
+	return *p.BloomFilterLength
+}
 func (p *ColumnMetaData) IsSetKeyValueMetadata() bool {
 	return p.KeyValueMetadata != nil
 }
@@ -7573,6 +7588,10 @@ func (p *ColumnMetaData) IsSetBloomFilterOffset() bool {
 	return p.BloomFilterOffset != nil
 }
 
+func (p *ColumnMetaData) IsSetBloomFilterLength() bool {
+	return p.BloomFilterLength != nil
+}
+
 func (p *ColumnMetaData) Read(ctx context.Context, iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(ctx); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
@@ -7744,6 +7763,16 @@ func (p *ColumnMetaData) Read(ctx context.Context, iprot thrift.TProtocol) error
 					return err
 				}
 			}
+		case 15:
+			if fieldTypeId == thrift.I32 {
+				if err := p.ReadField15(ctx, iprot); err != nil {
+					return err
+				}
+			} else {
+				if err := iprot.Skip(ctx, fieldTypeId); err != nil {
+					return err
+				}
+			}
 		default:
 			if err := iprot.Skip(ctx, fieldTypeId); err != nil {
 				return err
@@ -7959,6 +7988,15 @@ func (p *ColumnMetaData) ReadField14(ctx context.Context, iprot thrift.TProtocol
 	return nil
 }
 
+func (p *ColumnMetaData) ReadField15(ctx context.Context, iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI32(ctx); err != nil {
+		return thrift.PrependError("error reading field 15: ", err)
+	} else {
+		p.BloomFilterLength = &v
+	}
+	return nil
+}
+
 func (p *ColumnMetaData) Write(ctx context.Context, oprot thrift.TProtocol) error {
 	if err := oprot.WriteStructBegin(ctx, "ColumnMetaData"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
@@ -8006,6 +8044,9 @@ func (p *ColumnMetaData) Write(ctx context.Context, oprot thrift.TProtocol) erro
 		if err := p.writeField14(ctx, oprot); err != nil {
 			return err
 		}
+		if err := p.writeField15(ctx, oprot); err != nil {
+			return err
+		}
 	}
 	if err := oprot.WriteFieldStop(ctx); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -8242,6 +8283,21 @@ func (p *ColumnMetaData) writeField14(ctx context.Context, oprot thrift.TProtoco
 	return err
 }
 
+func (p *ColumnMetaData) writeField15(ctx context.Context, oprot thrift.TProtocol) (err error) {
+	if p.IsSetBloomFilterLength() {
+		if err := oprot.WriteFieldBegin(ctx, "bloom_filter_length", thrift.I32, 15); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 15:bloom_filter_length: ", p), err)
+		}
+		if err := oprot.WriteI32(ctx, int32(*p.BloomFilterLength)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.bloom_filter_length (15) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(ctx); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 15:bloom_filter_length: ", p), err)
+		}
+	}
+	return err
+}
+
 func (p *ColumnMetaData) Equals(other *ColumnMetaData) bool {
 	if p == other {
 		return true
@@ -8329,6 +8385,14 @@ func (p *ColumnMetaData) Equals(other *ColumnMetaData) bool {
 			return false
 		}
 	}
+	if p.BloomFilterLength != other.BloomFilterLength {
+		if p.BloomFilterLength == nil || other.BloomFilterLength == nil {
+			return false
+		}
+		if (*p.BloomFilterLength) != (*other.BloomFilterLength) {
+			return false
+		}
+	}
 	return true
 }
 
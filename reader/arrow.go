@@ -0,0 +1,304 @@
+package reader
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+
+	"github.com/apache/arrow/go/arrow"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/decimal128"
+	"github.com/apache/arrow/go/arrow/float16"
+	"github.com/apache/arrow/go/arrow/memory"
+	"github.com/pkg/errors"
+	"github.com/sabey/parquet-go/common"
+	"github.com/sabey/parquet-go/layout"
+	"github.com/sabey/parquet-go/parquet"
+	"github.com/sabey/parquet-go/schema"
+	"github.com/sabey/parquet-go/source"
+)
+
+//ArrowReader reads the rows of a parquet file as arrow records
+type ArrowReader struct {
+	*ParquetReader
+	//Arrow schema of the records, the fields are the columns of the file
+	ArrowSchema *arrow.Schema
+	//Allocator of the arrays, memory.DefaultAllocator by default
+	Allocator memory.Allocator
+
+	nodes []*arrowNode
+}
+
+//NewArrowReader creates a reader of the rows of a parquet file as arrow records,
+//np is the number of fields read in parallel. The nested fields are read with the row reads.
+func NewArrowReader(pFile source.ParquetFile, np int64, opts ...ReaderOption) (*ArrowReader, error) {
+	pr, err := NewParquetColumnReader(pFile, np, opts...)
+	if err != nil {
+		return nil, errors.Wrap(err, "NewParquetColumnReader")
+	}
+	res := &ArrowReader{ParquetReader: pr, Allocator: memory.DefaultAllocator}
+	if res.ArrowSchema, err = schema.ConvertParquetToArrowSchema(res.SchemaHandler); err != nil {
+		return nil, errors.Wrap(err, "schema.ConvertParquetToArrowSchema")
+	}
+	if arrowSchema := res.storedArrowSchema(); arrowSchema != nil {
+		res.ArrowSchema = arrowSchema
+	}
+	if res.nodes, err = newArrowNodes(res.SchemaHandler, res.ArrowSchema); err != nil {
+		return nil, errors.Wrap(err, "newArrowNodes")
+	}
+	return res, nil
+}
+
+//storedArrowSchema returns the arrow schema stored in the metadata of the file by the arrow writers.
+//It returns nil if there is none, or if its fields don't match the elements of the file.
+func (r *ArrowReader) storedArrowSchema() *arrow.Schema {
+	for _, kv := range r.Footer.KeyValueMetadata {
+		if kv.Key != schema.ArrowSchemaKey || kv.Value == nil {
+			continue
+		}
+		arrowSchema, err := schema.DeserializeArrowSchema(*kv.Value)
+		if err != nil || len(arrowSchema.Fields()) != len(r.ArrowSchema.Fields()) {
+			return nil
+		}
+		schemaHandler, err := schema.NewSchemaHandlerFromArrow(arrowSchema)
+		if err != nil || len(schemaHandler.SchemaElements) != len(r.SchemaHandler.SchemaElements) {
+			return nil
+		}
+		for i, field := range arrowSchema.Fields() {
+			if field.Name != r.ArrowSchema.Field(i).Name {
+				return nil
+			}
+		}
+		for i := 1; i < len(schemaHandler.SchemaElements); i++ {
+			element, fileElement := schemaHandler.SchemaElements[i], r.SchemaHandler.SchemaElements[i]
+			if schemaHandler.Infos[i].ExName != r.SchemaHandler.Infos[i].ExName ||
+				element.GetNumChildren() != fileElement.GetNumChildren() || element.GetType() != fileElement.GetType() ||
+				element.GetRepetitionType() != fileElement.GetRepetitionType() {
+				return nil
+			}
+		}
+		return arrowSchema
+	}
+	return nil
+}
+
+//ReadArrow reads a record of at most num rows, it returns io.EOF when all the rows are read.
+//The record must be released by the caller.
+func (r *ArrowReader) ReadArrow(num int) (array.Record, error) {
+	return r.ReadArrowContext(context.Background(), num)
+}
+
+//ReadArrowContext reads a record like ReadArrow, it returns ctx.Err() when ctx is done before
+//the rows are read. The reader can't be used after the read is cancelled.
+func (r *ArrowReader) ReadArrowContext(ctx context.Context, num int) (array.Record, error) {
+	var err error
+	if num <= 0 {
+		return nil, errors.Errorf("invalid number of rows %v", num)
+	}
+	if err = ctx.Err(); err != nil {
+		return nil, errors.Wrap(err, "ctx.Err")
+	}
+
+	paths := r.SchemaHandler.ValueColumns
+	for _, pathStr := range paths {
+		if _, ok := r.ColumnBuffers[pathStr]; !ok {
+			if r.ColumnBuffers[pathStr], err = r.newColumnBuffer(pathStr); err != nil {
+				return nil, errors.Wrap(err, "newColumnBuffer")
+			}
+		}
+	}
+
+	columns := make([]array.Interface, len(r.nodes))
+	rows := make([]int, len(r.nodes))
+	defer func() {
+		if err != nil {
+			for _, column := range columns {
+				if column != nil {
+					column.Release()
+				}
+			}
+		}
+	}()
+
+	doneChan := make(chan error, len(r.nodes))
+	taskChan := make(chan int, len(r.nodes))
+	stopChan := make(chan struct{})
+	defer close(stopChan)
+	workerCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for i := int64(0); i < r.NP; i++ {
+		r.workers.Add(1)
+		go func() {
+			defer r.workers.Done()
+			for {
+				select {
+				case <-stopChan:
+					return
+				case index := <-taskChan:
+					var err error
+					if node := r.nodes[index]; node.path != "" {
+						columns[index], rows[index], err = r.readArrowColumn(workerCtx, node.path, r.ArrowSchema.Field(index), num)
+					} else {
+						columns[index], rows[index], err = r.readArrowField(workerCtx, node, num)
+					}
+					doneChan <- err
+				}
+			}
+		}()
+	}
+
+	for i := range r.nodes {
+		taskChan <- i
+	}
+	if err = waitColumns(ctx, cancel, doneChan, len(r.nodes)); err != nil {
+		return nil, errors.Wrap(err, "waitColumns")
+	}
+
+	for i := range rows {
+		if rows[i] != rows[0] {
+			err = errors.Errorf("%v rows in field %v, %v expected", rows[i], r.ArrowSchema.Field(i).Name, rows[0])
+			return nil, err
+		}
+	}
+	if len(rows) == 0 || rows[0] == 0 {
+		err = io.EOF
+		return nil, err
+	}
+
+	record := array.NewRecord(r.ArrowSchema, columns, int64(rows[0]))
+	for _, column := range columns {
+		column.Release()
+	}
+	return record, nil
+}
+
+//readArrowColumn reads the values of num rows of a column with the typed batch reads, and builds their array
+func (r *ArrowReader) readArrowColumn(ctx context.Context, pathStr string, field arrow.Field, num int) (array.Interface, int, error) {
+	cb := r.ColumnBuffers[pathStr]
+	cb.ctx = ctx
+	defer func() { cb.ctx = nil }()
+
+	index := r.SchemaHandler.MapIndex[pathStr]
+	values := layout.NewTypedValues(r.SchemaHandler.SchemaElements[index].GetType(), num)
+	maxDefinitionLevel, _ := r.SchemaHandler.MaxDefinitionLevel(common.StrToPath(pathStr))
+	var defLevels []int16
+	if maxDefinitionLevel > 0 {
+		defLevels = make([]int16, num)
+	}
+
+	numLevels, numValues := 0, 0
+	for numLevels < num {
+		var levels []int16
+		if defLevels != nil {
+			levels = defLevels[numLevels:]
+		}
+		offset := numValues
+		n, m, err := cb.ReadBatch(levels, nil, num-numValues, func(src *layout.TypedValues, from int, to int, dst int) {
+			values.Copy(offset+dst, src, from, to)
+		})
+		if err != nil {
+			return nil, 0, errors.Wrap(err, "cb.ReadBatch")
+		}
+		if n == 0 {
+			break
+		}
+		numLevels, numValues = numLevels+n, numValues+m
+	}
+
+	builder := array.NewBuilder(r.Allocator, field.Type)
+	defer builder.Release()
+	builder.Reserve(numLevels)
+	for i, j := 0, 0; i < numLevels; i++ {
+		if defLevels != nil && int32(defLevels[i]) < maxDefinitionLevel {
+			builder.AppendNull()
+			continue
+		}
+		if err := appendArrowValue(builder, field.Type, values, j); err != nil {
+			return nil, 0, errors.Wrap(err, "appendArrowValue")
+		}
+		j++
+	}
+	return builder.NewArray(), numLevels, nil
+}
+
+//appendArrowValue appends the value i to the builder of its arrow type. The values written by the arrow
+//writers in another unit or type are converted back.
+func appendArrowValue(builder array.Builder, dataType arrow.DataType, values *layout.TypedValues, i int) error {
+	switch b := builder.(type) {
+	case *array.BooleanBuilder:
+		b.Append(values.Booleans[i])
+	case *array.Int8Builder:
+		b.Append(int8(values.Int32s[i]))
+	case *array.Int16Builder:
+		b.Append(int16(values.Int32s[i]))
+	case *array.Int32Builder:
+		b.Append(values.Int32s[i])
+	case *array.Uint8Builder:
+		b.Append(uint8(values.Int32s[i]))
+	case *array.Uint16Builder:
+		b.Append(uint16(values.Int32s[i]))
+	case *array.Uint32Builder:
+		b.Append(uint32(values.Int32s[i]))
+	case *array.Int64Builder:
+		b.Append(values.Int64s[i])
+	case *array.Uint64Builder:
+		b.Append(uint64(values.Int64s[i]))
+	case *array.Float32Builder:
+		b.Append(values.Float32s[i])
+	case *array.Float64Builder:
+		b.Append(values.Float64s[i])
+	case *array.Float16Builder:
+		b.Append(float16.New(values.Float32s[i]))
+	case *array.Date32Builder:
+		b.Append(arrow.Date32(values.Int32s[i]))
+	case *array.Date64Builder:
+		b.Append(arrow.Date64(int64(values.Int32s[i]) * common.MillisecondsPerDay))
+	case *array.Time32Builder:
+		value := values.Int32s[i]
+		if dataType.(*arrow.Time32Type).Unit == arrow.Second {
+			value /= 1000
+		}
+		b.Append(arrow.Time32(value))
+	case *array.Time64Builder:
+		b.Append(arrow.Time64(values.Int64s[i]))
+	case *array.TimestampBuilder:
+		value := values.Int64s[i]
+		if dataType.(*arrow.TimestampType).Unit == arrow.Second {
+			value /= 1000
+		}
+		b.Append(arrow.Timestamp(value))
+	case *array.DurationBuilder:
+		b.Append(arrow.Duration(values.Int64s[i]))
+	case *array.StringBuilder:
+		b.Append(string(values.ByteArrays[i]))
+	case *array.BinaryBuilder:
+		b.Append(values.ByteArrays[i])
+	case *array.FixedSizeBinaryBuilder:
+		b.Append(values.ByteArrays[i])
+	case *array.Decimal128Builder:
+		switch values.Type {
+		case parquet.Type_INT32:
+			b.Append(decimal128.FromI64(int64(values.Int32s[i])))
+		case parquet.Type_INT64:
+			b.Append(decimal128.FromI64(values.Int64s[i]))
+		default:
+			b.Append(decimalFromBytes(values.ByteArrays[i]))
+		}
+	default:
+		return errors.Errorf("unsupported arrow builder %T", builder)
+	}
+	return nil
+}
+
+//decimalFromBytes returns the decimal of a big-endian two's complement integer of at most 16 bytes
+func decimalFromBytes(b []byte) decimal128.Num {
+	buf := make([]byte, 16)
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		for i := range buf {
+			buf[i] = 0xff
+		}
+	}
+	copy(buf[16-len(b):], b)
+	return decimal128.New(int64(binary.BigEndian.Uint64(buf)), binary.BigEndian.Uint64(buf[8:]))
+}
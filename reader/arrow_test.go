@@ -0,0 +1,369 @@
+package reader
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/apache/arrow/go/arrow"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/decimal128"
+	"github.com/apache/arrow/go/arrow/float16"
+	"github.com/apache/arrow/go/arrow/memory"
+	"github.com/sabey/parquet-go-source/buffer"
+	"github.com/sabey/parquet-go-source/writerfile"
+	"github.com/sabey/parquet-go/schema"
+	"github.com/sabey/parquet-go/writer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArrowReader(t *testing.T) {
+	buf := filterFile.write(t)
+
+	for _, batchSize := range []int{1, 7, 10, 100} {
+		ar, err := NewArrowReader(buffer.NewBufferFileFromBytes(buf), 2)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"id", "name", "note"}, []string{
+			ar.ArrowSchema.Field(0).Name, ar.ArrowSchema.Field(1).Name, ar.ArrowSchema.Field(2).Name})
+		assert.True(t, ar.ArrowSchema.Field(2).Nullable)
+
+		id := int64(0)
+		for {
+			record, err := ar.ReadArrow(batchSize)
+			if err == io.EOF {
+				break
+			}
+			assert.Nil(t, err)
+			ids := record.Column(0).(*array.Int64)
+			names := record.Column(1).(*array.String)
+			notes := record.Column(2).(*array.String)
+			for i := 0; i < int(record.NumRows()); i++ {
+				assert.Equal(t, id, ids.Value(i))
+				assert.Equal(t, string(rune('a'+id/10)), names.Value(i))
+				if id < 30 {
+					assert.True(t, notes.IsNull(i))
+				} else {
+					assert.Equal(t, "note", notes.Value(i))
+				}
+				id++
+			}
+			record.Release()
+		}
+		assert.Equal(t, int64(40), id)
+		ar.ReadStop()
+	}
+}
+
+func TestArrowReaderRoundTrip(t *testing.T) {
+	mem := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer mem.AssertSize(t, 0)
+
+	arrowSchema := arrow.NewSchema([]arrow.Field{
+		{Name: "int8", Type: arrow.PrimitiveTypes.Int8},
+		{Name: "uint32", Type: arrow.PrimitiveTypes.Uint32},
+		{Name: "float64", Type: arrow.PrimitiveTypes.Float64},
+		{Name: "bool", Type: arrow.FixedWidthTypes.Boolean},
+		{Name: "date", Type: arrow.FixedWidthTypes.Date32},
+		{Name: "string", Type: arrow.BinaryTypes.String},
+		{Name: "date64", Type: arrow.FixedWidthTypes.Date64},
+	}, nil)
+	b := array.NewRecordBuilder(mem, arrowSchema)
+	defer b.Release()
+	for i := 0; i < 50; i++ {
+		b.Field(0).(*array.Int8Builder).Append(int8(i - 25))
+		b.Field(1).(*array.Uint32Builder).Append(uint32(i) << 20)
+		b.Field(2).(*array.Float64Builder).Append(float64(i) / 2)
+		b.Field(3).(*array.BooleanBuilder).Append(i%2 == 0)
+		b.Field(4).(*array.Date32Builder).Append(arrow.Date32(18000 + i))
+		b.Field(5).(*array.StringBuilder).Append(string(rune('a' + i%26)))
+		//days around 2020-09-13, down to 1952
+		b.Field(6).(*array.Date64Builder).Append(arrow.Date64(1599955200000 + int64(i-25)*86400000*1000))
+	}
+	expected := b.NewRecord()
+	defer expected.Release()
+
+	out := new(bytes.Buffer)
+	aw, err := writer.NewArrowWriter(arrowSchema, writerfile.NewWriterFile(out), 1)
+	assert.Nil(t, err)
+	assert.Nil(t, aw.WriteArrow(expected))
+	assert.Nil(t, aw.WriteStop())
+
+	ar, err := NewArrowReader(buffer.NewBufferFileFromBytes(out.Bytes()), 1)
+	assert.Nil(t, err)
+	ar.Allocator = mem
+	record, err := ar.ReadArrow(100)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(50), record.NumRows())
+	for i := 0; i < int(expected.NumCols()); i++ {
+		assert.True(t, arrow.TypeEqual(expected.Column(i).DataType(), record.Column(i).DataType()), expected.ColumnName(i))
+		assert.True(t, array.ArrayEqual(expected.Column(i), record.Column(i)), expected.ColumnName(i))
+	}
+	record.Release()
+	_, err = ar.ReadArrow(100)
+	assert.Equal(t, io.EOF, err)
+	ar.ReadStop()
+}
+
+func TestArrowReaderLogicalTypes(t *testing.T) {
+	mem := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer mem.AssertSize(t, 0)
+
+	uuidMetadata := arrow.NewMetadata([]string{schema.ExtensionNameKey}, []string{schema.UUIDExtensionName})
+	arrowSchema := arrow.NewSchema([]arrow.Field{
+		{Name: "t64ns", Type: arrow.FixedWidthTypes.Time64ns},
+		{Name: "tsus", Type: arrow.FixedWidthTypes.Timestamp_us},
+		{Name: "tsns-local", Type: &arrow.TimestampType{Unit: arrow.Nanosecond}},
+		{Name: "dec9", Type: &arrow.Decimal128Type{Precision: 9, Scale: 2}},
+		{Name: "dec18", Type: &arrow.Decimal128Type{Precision: 18, Scale: 3}},
+		{Name: "dec38", Type: &arrow.Decimal128Type{Precision: 38, Scale: 4}},
+		{Name: "uuid", Type: &arrow.FixedSizeBinaryType{ByteWidth: 16}, Metadata: uuidMetadata},
+	}, nil)
+	b := array.NewRecordBuilder(mem, arrowSchema)
+	defer b.Release()
+	for i := 0; i < 20; i++ {
+		n := int64(i - 10)
+		b.Field(0).(*array.Time64Builder).Append(arrow.Time64(i * 1000001))
+		b.Field(1).(*array.TimestampBuilder).Append(arrow.Timestamp(n * 1000001))
+		b.Field(2).(*array.TimestampBuilder).Append(arrow.Timestamp(n * 1000000001))
+		b.Field(3).(*array.Decimal128Builder).Append(decimal128.FromI64(n * 12345))
+		b.Field(4).(*array.Decimal128Builder).Append(decimal128.FromI64(n * 123456789012))
+		b.Field(5).(*array.Decimal128Builder).Append(decimal128.New(n, uint64(i)))
+		b.Field(6).(*array.FixedSizeBinaryBuilder).Append(bytes.Repeat([]byte{byte(i)}, 16))
+	}
+	expected := b.NewRecord()
+	defer expected.Release()
+
+	out := new(bytes.Buffer)
+	aw, err := writer.NewArrowWriter(arrowSchema, writerfile.NewWriterFile(out), 1)
+	assert.Nil(t, err)
+	assert.Nil(t, aw.WriteArrow(expected))
+	assert.Nil(t, aw.WriteStop())
+
+	ar, err := NewArrowReader(buffer.NewBufferFileFromBytes(out.Bytes()), 1)
+	assert.Nil(t, err)
+	ar.Allocator = mem
+	assert.True(t, ar.ArrowSchema.Equal(arrowSchema), ar.ArrowSchema.String())
+	record, err := ar.ReadArrow(100)
+	assert.Nil(t, err)
+	for i := 0; i < int(expected.NumCols()); i++ {
+		assert.True(t, array.ArrayEqual(expected.Column(i), record.Column(i)), expected.ColumnName(i))
+	}
+	record.Release()
+	ar.ReadStop()
+}
+
+func TestArrowReaderStoredSchema(t *testing.T) {
+	mem := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer mem.AssertSize(t, 0)
+
+	metadata := arrow.NewMetadata([]string{"source"}, []string{"test"})
+	arrowSchema := arrow.NewSchema([]arrow.Field{
+		{Name: "tss", Type: &arrow.TimestampType{Unit: arrow.Second, TimeZone: "Europe/Paris"}},
+		{Name: "t32s", Type: arrow.FixedWidthTypes.Time32s, Nullable: true},
+		{Name: "f16", Type: arrow.FixedWidthTypes.Float16},
+		{Name: "dur", Type: arrow.FixedWidthTypes.Duration_us,
+			Metadata: arrow.NewMetadata([]string{"unit"}, []string{"us"})},
+	}, &metadata)
+	b := array.NewRecordBuilder(mem, arrowSchema)
+	defer b.Release()
+	for i := 0; i < 20; i++ {
+		b.Field(0).(*array.TimestampBuilder).Append(arrow.Timestamp(1600000000 + i))
+		if i%4 == 0 {
+			b.Field(1).(*array.Time32Builder).AppendNull()
+		} else {
+			b.Field(1).(*array.Time32Builder).Append(arrow.Time32(i * 60))
+		}
+		b.Field(2).(*array.Float16Builder).Append(float16.New(float32(i) / 4))
+		b.Field(3).(*array.DurationBuilder).Append(arrow.Duration(i * 1000))
+	}
+	expected := b.NewRecord()
+	defer expected.Release()
+
+	out := new(bytes.Buffer)
+	aw, err := writer.NewArrowWriter(arrowSchema, writerfile.NewWriterFile(out), 1)
+	assert.Nil(t, err)
+	assert.Nil(t, aw.WriteArrow(expected))
+	assert.Nil(t, aw.WriteStop())
+
+	ar, err := NewArrowReader(buffer.NewBufferFileFromBytes(out.Bytes()), 1)
+	assert.Nil(t, err)
+	assert.Equal(t, schema.ArrowSchemaKey, ar.Footer.KeyValueMetadata[0].Key)
+	assert.True(t, ar.ArrowSchema.Equal(arrowSchema), ar.ArrowSchema.String())
+	assert.Equal(t, arrowSchema.Metadata(), ar.ArrowSchema.Metadata())
+	assert.Equal(t, arrowSchema.Field(3).Metadata, ar.ArrowSchema.Field(3).Metadata)
+	ar.Allocator = mem
+	record, err := ar.ReadArrow(100)
+	assert.Nil(t, err)
+	for i := 0; i < int(expected.NumCols()); i++ {
+		assert.True(t, array.ArrayEqual(expected.Column(i), record.Column(i)), expected.ColumnName(i))
+	}
+	record.Release()
+	ar.ReadStop()
+}
+
+func TestArrowReaderNested(t *testing.T) {
+	mem := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer mem.AssertSize(t, 0)
+
+	pointType := arrow.StructOf(
+		arrow.Field{Name: "x", Type: arrow.PrimitiveTypes.Int32},
+		arrow.Field{Name: "label", Type: arrow.BinaryTypes.String, Nullable: true},
+	)
+	arrowSchema := arrow.NewSchema([]arrow.Field{
+		{Name: "id", Type: arrow.PrimitiveTypes.Int64},
+		{Name: "scores", Type: arrow.ListOf(arrow.PrimitiveTypes.Int32)},
+		{Name: "tags", Type: arrow.ListOf(arrow.BinaryTypes.String), Nullable: true},
+		{Name: "point", Type: pointType, Nullable: true},
+		{Name: "points", Type: arrow.ListOf(pointType)},
+		{Name: "pair", Type: arrow.FixedSizeListOf(2, arrow.PrimitiveTypes.Float64), Nullable: true},
+		{Name: "matrix", Type: arrow.ListOf(arrow.ListOf(arrow.PrimitiveTypes.Int64))},
+	}, nil)
+	b := array.NewRecordBuilder(mem, arrowSchema)
+	defer b.Release()
+	for i := 0; i < 30; i++ {
+		b.Field(0).(*array.Int64Builder).Append(int64(i))
+
+		scores := b.Field(1).(*array.ListBuilder)
+		scores.Append(true)
+		for k := 0; k < i%4; k++ {
+			scores.ValueBuilder().(*array.Int32Builder).Append(int32(i*10 + k))
+		}
+
+		tags := b.Field(2).(*array.ListBuilder)
+		switch i % 3 {
+		case 0:
+			tags.AppendNull()
+		case 1:
+			tags.Append(true)
+		default:
+			tags.Append(true)
+			tags.ValueBuilder().(*array.StringBuilder).Append("tag")
+			tags.ValueBuilder().(*array.StringBuilder).AppendNull()
+		}
+
+		point := b.Field(3).(*array.StructBuilder)
+		if i%5 == 0 {
+			point.AppendNull()
+		} else {
+			point.Append(true)
+			point.FieldBuilder(0).(*array.Int32Builder).Append(int32(i))
+			if i%2 == 0 {
+				point.FieldBuilder(1).(*array.StringBuilder).AppendNull()
+			} else {
+				point.FieldBuilder(1).(*array.StringBuilder).Append("p")
+			}
+		}
+
+		points := b.Field(4).(*array.ListBuilder)
+		points.Append(true)
+		for k := 0; k < i%3; k++ {
+			elem := points.ValueBuilder().(*array.StructBuilder)
+			elem.Append(true)
+			elem.FieldBuilder(0).(*array.Int32Builder).Append(int32(k))
+			elem.FieldBuilder(1).(*array.StringBuilder).Append("q")
+		}
+
+		pair := b.Field(5).(*array.FixedSizeListBuilder)
+		if i%7 == 0 {
+			pair.AppendNull()
+			pair.ValueBuilder().(*array.Float64Builder).AppendNull()
+			pair.ValueBuilder().(*array.Float64Builder).AppendNull()
+		} else {
+			pair.Append(true)
+			pair.ValueBuilder().(*array.Float64Builder).Append(float64(i))
+			pair.ValueBuilder().(*array.Float64Builder).Append(float64(-i))
+		}
+
+		matrix := b.Field(6).(*array.ListBuilder)
+		matrix.Append(true)
+		for k := 0; k < i%3; k++ {
+			row := matrix.ValueBuilder().(*array.ListBuilder)
+			row.Append(true)
+			for l := 0; l < k; l++ {
+				row.ValueBuilder().(*array.Int64Builder).Append(int64(i + l))
+			}
+		}
+	}
+	expected := b.NewRecord()
+	defer expected.Release()
+
+	out := new(bytes.Buffer)
+	aw, err := writer.NewArrowWriter(arrowSchema, writerfile.NewWriterFile(out), 1)
+	assert.Nil(t, err)
+	assert.Nil(t, aw.WriteArrow(expected))
+	assert.Nil(t, aw.WriteStop())
+
+	for _, batchSize := range []int{7, 100} {
+		ar, err := NewArrowReader(buffer.NewBufferFileFromBytes(out.Bytes()), 2)
+		assert.Nil(t, err)
+		assert.True(t, ar.ArrowSchema.Equal(arrowSchema), ar.ArrowSchema.String())
+		ar.Allocator = mem
+		for offset := int64(0); ; {
+			record, err := ar.ReadArrow(batchSize)
+			if err == io.EOF {
+				break
+			}
+			assert.Nil(t, err)
+			slice := expected.NewSlice(offset, offset+record.NumRows())
+			for i := 0; i < int(expected.NumCols()); i++ {
+				assert.True(t, array.ArrayEqual(slice.Column(i), record.Column(i)), expected.ColumnName(i))
+			}
+			offset += record.NumRows()
+			slice.Release()
+			record.Release()
+		}
+		ar.ReadStop()
+	}
+}
+
+type arrowMapRecord struct {
+	ID     int64            `parquet:"name=id, type=INT64"`
+	Names  []string         `parquet:"name=names, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=REPEATED"`
+	Counts map[string]int32 `parquet:"name=counts, type=MAP, convertedtype=MAP, keytype=BYTE_ARRAY, keyconvertedtype=UTF8, valuetype=INT32"`
+}
+
+func TestArrowReaderRepeatedAndMap(t *testing.T) {
+	buf := testFile{
+		Schema: new(arrowMapRecord),
+		Record: func(i int) interface{} {
+			record := arrowMapRecord{ID: int64(i), Counts: map[string]int32{}}
+			for k := 0; k < i%3; k++ {
+				record.Names = append(record.Names, string(rune('a'+k)))
+			}
+			if i%2 == 1 {
+				record.Counts["n"] = int32(i)
+			}
+			return record
+		},
+		NumRows: 10,
+	}.write(t)
+
+	ar, err := NewArrowReader(buffer.NewBufferFileFromBytes(buf), 1)
+	assert.Nil(t, err)
+	entryType := arrow.StructOf(
+		arrow.Field{Name: "key", Type: arrow.BinaryTypes.String},
+		arrow.Field{Name: "value", Type: arrow.PrimitiveTypes.Int32},
+	)
+	assert.True(t, arrow.TypeEqual(arrow.ListOf(arrow.BinaryTypes.String), ar.ArrowSchema.Field(1).Type), ar.ArrowSchema.String())
+	assert.True(t, arrow.TypeEqual(arrow.ListOf(entryType), ar.ArrowSchema.Field(2).Type), ar.ArrowSchema.String())
+
+	record, err := ar.ReadArrow(10)
+	assert.Nil(t, err)
+	names := record.Column(1).(*array.List)
+	counts := record.Column(2).(*array.List)
+	entries := counts.ListValues().(*array.Struct)
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, int32(i%3), names.Offsets()[i+1]-names.Offsets()[i])
+		if i%2 == 1 {
+			assert.Equal(t, int32(1), counts.Offsets()[i+1]-counts.Offsets()[i])
+			j := int(counts.Offsets()[i])
+			assert.Equal(t, "n", entries.Field(0).(*array.String).Value(j))
+			assert.Equal(t, int32(i), entries.Field(1).(*array.Int32).Value(j))
+		} else {
+			assert.Equal(t, int32(0), counts.Offsets()[i+1]-counts.Offsets()[i])
+		}
+	}
+	assert.Equal(t, "b", names.ListValues().(*array.String).Value(int(names.Offsets()[2])+1))
+	record.Release()
+	ar.ReadStop()
+}
@@ -0,0 +1,267 @@
+package reader
+
+import (
+	"context"
+
+	"github.com/apache/arrow/go/arrow"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/pkg/errors"
+	"github.com/sabey/parquet-go/common"
+	"github.com/sabey/parquet-go/layout"
+	"github.com/sabey/parquet-go/parquet"
+	"github.com/sabey/parquet-go/schema"
+)
+
+//arrowNode is a field of the arrow records, or a value of a field, with the levels of its element
+type arrowNode struct {
+	dataType arrow.DataType
+	//path of the column of a primitive value
+	path string
+	//a nullable value is null below defLevel
+	nullable bool
+	defLevel int32
+	//the elements of a list have the repetition level repLevel, the list is empty below elemDefLevel
+	repLevel     int32
+	elemDefLevel int32
+	//element of a list or fields of a struct
+	children []*arrowNode
+	//paths of the columns of the value
+	paths []string
+}
+
+//arrowLeaf holds the rows of a column read for a nested field
+type arrowLeaf struct {
+	table  *layout.Table
+	values *layout.TypedValues
+	pos    int
+}
+
+//arrowListBuilder is the builder of the lists and of the fixed size lists
+type arrowListBuilder interface {
+	array.Builder
+	Append(v bool)
+	ValueBuilder() array.Builder
+}
+
+//newArrowNodes returns the nodes of the fields of an arrow schema matching the schema of the file
+func newArrowNodes(schemaHandler *schema.SchemaHandler, arrowSchema *arrow.Schema) ([]*arrowNode, error) {
+	nodes := make([]*arrowNode, len(arrowSchema.Fields()))
+	next := 1
+	for k, field := range arrowSchema.Fields() {
+		if next >= len(schemaHandler.SchemaElements) {
+			return nil, errors.Errorf("no column of field %v", field.Name)
+		}
+		var err error
+		if nodes[k], next, err = newArrowNode(schemaHandler, next, field.Type); err != nil {
+			return nil, errors.Wrapf(err, "field %v", field.Name)
+		}
+	}
+	return nodes, nil
+}
+
+//newArrowNode returns the node of the element i of the schema and the index of the element after its
+//descendants. A repeated element is a list of required values.
+func newArrowNode(schemaHandler *schema.SchemaHandler, i int, dataType arrow.DataType) (*arrowNode, int, error) {
+	element := schemaHandler.SchemaElements[i]
+	path := common.StrToPath(schemaHandler.IndexMap[int32(i)])
+	defLevel, err := schemaHandler.MaxDefinitionLevel(path)
+	if err != nil {
+		return nil, i, errors.Wrap(err, "schemaHandler.MaxDefinitionLevel")
+	}
+	if element.GetRepetitionType() != parquet.FieldRepetitionType_REPEATED {
+		return newArrowValueNode(schemaHandler, i, dataType, defLevel,
+			element.GetRepetitionType() == parquet.FieldRepetitionType_OPTIONAL)
+	}
+
+	repLevel, err := schemaHandler.MaxRepetitionLevel(path)
+	if err != nil {
+		return nil, i, errors.Wrap(err, "schemaHandler.MaxRepetitionLevel")
+	}
+	elemType, err := arrowElemType(dataType)
+	if err != nil {
+		return nil, i, err
+	}
+	elem, next, err := newArrowValueNode(schemaHandler, i, elemType, defLevel, false)
+	if err != nil {
+		return nil, next, err
+	}
+	return &arrowNode{
+		dataType:     dataType,
+		defLevel:     defLevel - 1,
+		repLevel:     repLevel,
+		elemDefLevel: defLevel,
+		children:     []*arrowNode{elem},
+		paths:        elem.paths,
+	}, next, nil
+}
+
+//newArrowValueNode returns the node of the values of the element i of the schema, whatever its
+//repetition, and the index of the element after its descendants
+func newArrowValueNode(schemaHandler *schema.SchemaHandler, i int, dataType arrow.DataType, defLevel int32, nullable bool) (*arrowNode, int, error) {
+	element := schemaHandler.SchemaElements[i]
+	node := &arrowNode{dataType: dataType, nullable: nullable, defLevel: defLevel}
+	if element.GetNumChildren() == 0 {
+		node.path = schemaHandler.IndexMap[int32(i)]
+		node.paths = []string{node.path}
+		return node, i + 1, nil
+	}
+
+	if repeated, elem, ok := schema.ArrowListElement(schemaHandler, i); ok {
+		elemType, err := arrowElemType(dataType)
+		if err != nil {
+			return nil, i, err
+		}
+		path := common.StrToPath(schemaHandler.IndexMap[int32(repeated)])
+		if node.elemDefLevel, err = schemaHandler.MaxDefinitionLevel(path); err != nil {
+			return nil, i, errors.Wrap(err, "schemaHandler.MaxDefinitionLevel")
+		}
+		if node.repLevel, err = schemaHandler.MaxRepetitionLevel(path); err != nil {
+			return nil, i, errors.Wrap(err, "schemaHandler.MaxRepetitionLevel")
+		}
+		var child *arrowNode
+		var next int
+		if elem == repeated {
+			child, next, err = newArrowValueNode(schemaHandler, elem, elemType, node.elemDefLevel, false)
+		} else {
+			child, next, err = newArrowNode(schemaHandler, elem, elemType)
+		}
+		if err != nil {
+			return nil, next, err
+		}
+		node.children, node.paths = []*arrowNode{child}, child.paths
+		return node, next, nil
+	}
+
+	structType, ok := dataType.(*arrow.StructType)
+	if !ok || len(structType.Fields()) != int(element.GetNumChildren()) {
+		return nil, i, errors.Errorf("arrow type %v of the group %v", dataType, schemaHandler.Infos[i].ExName)
+	}
+	next := i + 1
+	for _, field := range structType.Fields() {
+		child, n, err := newArrowNode(schemaHandler, next, field.Type)
+		if err != nil {
+			return nil, n, err
+		}
+		node.children = append(node.children, child)
+		node.paths = append(node.paths, child.paths...)
+		next = n
+	}
+	return node, next, nil
+}
+
+//arrowElemType returns the type of the elements of a list
+func arrowElemType(dataType arrow.DataType) (arrow.DataType, error) {
+	switch listType := dataType.(type) {
+	case *arrow.ListType:
+		return listType.Elem(), nil
+	case *arrow.FixedSizeListType:
+		return listType.Elem(), nil
+	}
+	return nil, errors.Errorf("arrow type %v of a list", dataType)
+}
+
+//readArrowField reads num rows of the columns of a nested field with the row reads, and builds its array
+//from their levels
+func (r *ArrowReader) readArrowField(ctx context.Context, node *arrowNode, num int) (array.Interface, int, error) {
+	leaves := make(map[string]*arrowLeaf, len(node.paths))
+	rows := -1
+	for _, pathStr := range node.paths {
+		table, read, err := r.ColumnBuffers[pathStr].ReadRowsContext(ctx, int64(num))
+		if err != nil {
+			return nil, 0, errors.Wrap(err, "ReadRowsContext")
+		}
+		if rows >= 0 && int(read) != rows {
+			return nil, 0, errors.Errorf("%v rows in column %v, %v expected", read, pathStr, rows)
+		}
+		rows = int(read)
+
+		index := r.SchemaHandler.MapIndex[pathStr]
+		values := layout.NewTypedValues(r.SchemaHandler.SchemaElements[index].GetType(), len(table.Values))
+		for i, value := range table.Values {
+			if value == nil {
+				continue
+			}
+			if err = values.SetValue(i, value); err != nil {
+				return nil, 0, errors.Wrap(err, "values.SetValue")
+			}
+		}
+		leaves[pathStr] = &arrowLeaf{table: table, values: values}
+	}
+
+	builder := array.NewBuilder(r.Allocator, node.dataType)
+	defer builder.Release()
+	for i := 0; i < rows; i++ {
+		if err := appendArrowNode(node, builder, leaves); err != nil {
+			return nil, 0, errors.Wrap(err, "appendArrowNode")
+		}
+	}
+	return builder.NewArray(), rows, nil
+}
+
+//appendArrowNode appends the next value of a node to its builder, and moves the columns of the node
+//after its levels
+func appendArrowNode(node *arrowNode, builder array.Builder, leaves map[string]*arrowLeaf) error {
+	first := leaves[node.paths[0]]
+	if first.pos >= len(first.table.DefinitionLevels) {
+		return errors.Errorf("missing levels in column %v", node.paths[0])
+	}
+	defLevel := first.table.DefinitionLevels[first.pos]
+	if node.nullable && defLevel < node.defLevel {
+		appendArrowNull(node.dataType, builder)
+		node.skip(leaves)
+		return nil
+	}
+
+	if node.path != "" {
+		leaf := leaves[node.path]
+		if err := appendArrowValue(builder, node.dataType, leaf.values, leaf.pos); err != nil {
+			return errors.Wrap(err, "appendArrowValue")
+		}
+		leaf.pos++
+		return nil
+	}
+
+	switch b := builder.(type) {
+	case *array.StructBuilder:
+		b.Append(true)
+		for k, child := range node.children {
+			if err := appendArrowNode(child, b.FieldBuilder(k), leaves); err != nil {
+				return err
+			}
+		}
+		return nil
+	case arrowListBuilder:
+		b.Append(true)
+		if defLevel < node.elemDefLevel {
+			node.skip(leaves)
+			return nil
+		}
+		for {
+			if err := appendArrowNode(node.children[0], b.ValueBuilder(), leaves); err != nil {
+				return err
+			}
+			if first.pos >= len(first.table.RepetitionLevels) || first.table.RepetitionLevels[first.pos] < node.repLevel {
+				return nil
+			}
+		}
+	}
+	return errors.Errorf("unsupported arrow builder %T", builder)
+}
+
+//appendArrowNull appends a null value, with the null elements of a fixed size list
+func appendArrowNull(dataType arrow.DataType, builder array.Builder) {
+	builder.AppendNull()
+	if b, ok := builder.(*array.FixedSizeListBuilder); ok {
+		listType := dataType.(*arrow.FixedSizeListType)
+		for k := int32(0); k < listType.Len(); k++ {
+			appendArrowNull(listType.Elem(), b.ValueBuilder())
+		}
+	}
+}
+
+//skip moves the columns of a null or empty value after its level
+func (node *arrowNode) skip(leaves map[string]*arrowLeaf) {
+	for _, pathStr := range node.paths {
+		leaves[pathStr].pos++
+	}
+}
@@ -0,0 +1,82 @@
+package reader
+
+import (
+	"github.com/pkg/errors"
+	"github.com/sabey/parquet-go/bloomfilter"
+	"github.com/sabey/parquet-go/common"
+	"github.com/sabey/parquet-go/layout"
+	"github.com/sabey/parquet-go/parquet"
+)
+
+//columnChunk returns the chunk of the column in a row group by its internal path, or nil if not found
+func (pr *ParquetReader) columnChunk(rowGroup *parquet.RowGroup, pathStr string) *parquet.ColumnChunk {
+	for _, chunk := range rowGroup.GetColumns() {
+		if chunk.MetaData == nil {
+			continue
+		}
+		path := append([]string{pr.SchemaHandler.GetRootInName()}, chunk.MetaData.GetPathInSchema()...)
+		if common.PathToStr(path) == pathStr {
+			return chunk
+		}
+	}
+	return nil
+}
+
+//readBloomFilter reads the Bloom filter of a column in a row group, nil is returned if it has none
+func (pr *ParquetReader) readBloomFilter(rowGroup *parquet.RowGroup, pathStr string) (*bloomfilter.Filter, error) {
+	chunk := pr.columnChunk(rowGroup, pathStr)
+	if chunk == nil || !chunk.MetaData.IsSetBloomFilterOffset() {
+		return nil, nil
+	}
+	//the Bloom filters of the columns promoted to another type hash the values of the file
+	if se := pr.SchemaHandler.SchemaElements[pr.SchemaHandler.MapIndex[pathStr]]; chunk.MetaData.GetType() != se.GetType() {
+		return nil, nil
+	}
+
+	//the Bloom filters of the encrypted columns without keys are ignored
+	chunkCipher, err := pr.chunkCipher(rowGroup, chunk)
+	if err != nil {
+		return nil, nil
+	}
+
+	pFile := pr.PFile
+	if chunk.FilePath != nil {
+		if pFile, err = pr.PFile.Open(*chunk.FilePath); err != nil {
+			return nil, errors.Wrap(err, "pr.PFile.Open")
+		}
+		defer pFile.Close()
+	}
+	bloomFilter, err := layout.ReadBloomFilter(pFile, chunk, chunkCipher)
+	if err != nil {
+		return nil, errors.Wrap(err, "layout.ReadBloomFilter")
+	}
+	return bloomFilter, nil
+}
+
+//MightContain reports whether value may be in the column at path, using the Bloom filters
+//of the row groups. It's false only if every row group has a Bloom filter without the value.
+func (pr *ParquetReader) MightContain(path string, value interface{}) (bool, error) {
+	c := &column{Path: path}
+	if err := c.bind(pr.SchemaHandler); err != nil {
+		return false, errors.Wrap(err, "c.bind")
+	}
+	v, err := toParquetValue(value, c.Type, c.Unsigned)
+	if err != nil {
+		return false, errors.Wrap(err, "toParquetValue")
+	}
+	hash, err := bloomfilter.Hash(v)
+	if err != nil {
+		return false, errors.Wrap(err, "bloomfilter.Hash")
+	}
+
+	for _, rowGroup := range pr.Footer.RowGroups {
+		bloomFilter, err := pr.readBloomFilter(rowGroup, c.PathStr)
+		if err != nil {
+			return false, errors.Wrap(err, "pr.readBloomFilter")
+		}
+		if bloomFilter == nil || bloomFilter.Check(hash) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
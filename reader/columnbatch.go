@@ -0,0 +1,232 @@
+package reader
+
+import (
+	"io"
+	"math"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/sabey/parquet-go/common"
+	"github.com/sabey/parquet-go/layout"
+	"github.com/sabey/parquet-go/parquet"
+)
+
+//ReadBatch reads the levels of the column into defLevels and repLevels, and passes the values of the
+//levels which are not null, at most maxValues, to copyValues as the values from:to of the page, which
+//are copied to the position dst. The number of levels is limited by the level slices which are not nil,
+//a slice can only be nil if the column has no such levels. The rows may be split over several batches.
+func (cbt *ColumnBufferType) ReadBatch(defLevels []int16, repLevels []int16, maxValues int,
+	copyValues func(values *layout.TypedValues, from int, to int, dst int)) (int, int, error) {
+	path := common.StrToPath(cbt.PathStr)
+	maxDefinitionLevel, _ := cbt.SchemaHandler.MaxDefinitionLevel(path)
+	maxRepetitionLevel, _ := cbt.SchemaHandler.MaxRepetitionLevel(path)
+	if maxDefinitionLevel > 0 && defLevels == nil {
+		return 0, 0, errors.Errorf("definition levels are needed to read %v", cbt.PathStr)
+	}
+	if maxRepetitionLevel > 0 && repLevels == nil {
+		return 0, 0, errors.Errorf("repetition levels are needed to read %v", cbt.PathStr)
+	}
+
+	maxLevels := math.MaxInt32
+	if defLevels != nil {
+		maxLevels = len(defLevels)
+	}
+	if repLevels != nil && len(repLevels) < maxLevels {
+		maxLevels = len(repLevels)
+	}
+
+	numLevels, numValues := 0, 0
+	for numLevels < maxLevels {
+		page := cbt.TypedPage
+		if page == nil || cbt.TypedLevelIndex >= len(page.DefinitionLevels) {
+			if err := cbt.nextTypedPage(maxDefinitionLevel); err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return numLevels, numValues, cbt.columnError(errors.Wrap(err, "cbt.nextTypedPage"))
+			}
+			continue
+		}
+
+		i, valueIndex := cbt.TypedLevelIndex, cbt.TypedValueIndex
+		from, dst := valueIndex, numValues
+		for ; i < len(page.DefinitionLevels) && numLevels < maxLevels; i++ {
+			rl, dl := page.RepetitionLevels[i], page.DefinitionLevels[i]
+			if rl == 0 && cbt.RowRanges != nil && !cbt.rowInRanges(cbt.RowIndex) {
+				copyValues(page.Values, from, valueIndex, dst)
+				for ; i+1 < len(page.DefinitionLevels) && page.RepetitionLevels[i+1] != 0; i++ {
+					if page.DefinitionLevels[i] == maxDefinitionLevel {
+						valueIndex++
+					}
+				}
+				if page.DefinitionLevels[i] == maxDefinitionLevel {
+					valueIndex++
+				}
+				cbt.RowIndex++
+				from, dst = valueIndex, numValues
+				continue
+			}
+			if dl == maxDefinitionLevel {
+				if numValues >= maxValues {
+					break
+				}
+				valueIndex++
+				numValues++
+			}
+			if rl == 0 {
+				cbt.RowIndex++
+			}
+			if defLevels != nil {
+				defLevels[numLevels] = int16(dl)
+			}
+			if repLevels != nil {
+				repLevels[numLevels] = int16(rl)
+			}
+			numLevels++
+		}
+		copyValues(page.Values, from, valueIndex, dst)
+		cbt.TypedLevelIndex, cbt.TypedValueIndex = i, valueIndex
+		if i < len(page.DefinitionLevels) {
+			break
+		}
+	}
+	return numLevels, numValues, nil
+}
+
+//rowInRanges reports whether a row is in RowRanges
+func (cbt *ColumnBufferType) rowInRanges(row int64) bool {
+	i := sort.Search(len(cbt.RowRanges), func(i int) bool {
+		return cbt.RowRanges[i].End > row
+	})
+	return i < len(cbt.RowRanges) && cbt.RowRanges[i].Start <= row
+}
+
+//nextTypedPage reads the next page of the typed batch reads. The rows buffered by the row reads
+//are read first, and the rows before the next range of RowRanges are skipped.
+func (cbt *ColumnBufferType) nextTypedPage(maxDefinitionLevel int32) error {
+	var err error
+	cbt.TypedPage, cbt.TypedLevelIndex, cbt.TypedValueIndex = nil, 0, 0
+
+	if cbt.RowRanges != nil && !cbt.hasBufferedRows() {
+		i := sort.Search(len(cbt.RowRanges), func(i int) bool {
+			return cbt.RowRanges[i].End > cbt.RowIndex
+		})
+		if i >= len(cbt.RowRanges) {
+			return errors.Wrap(io.EOF, "io.EOF")
+		}
+		if start := cbt.RowRanges[i].Start; cbt.RowIndex < start {
+			if _, err = cbt.skipRows(start - cbt.RowIndex); err != nil {
+				return errors.Wrap(err, "cbt.skipRows")
+			}
+		}
+	}
+
+	if cbt.hasBufferedRows() {
+		if cbt.TypedPage, err = layout.NewTypedPageFromTable(cbt.DataTable, cbt.ChunkHeader.MetaData.GetType(), maxDefinitionLevel); err != nil {
+			return errors.Wrap(err, "layout.NewTypedPageFromTable")
+		}
+		cbt.DataTable = layout.NewTableFromTable(cbt.DataTable)
+		cbt.DataTableNumRows = -1
+		return nil
+	}
+
+	for {
+		if !cbt.chunkHasPages() {
+			if cbt.RowGroupIndex >= int64(len(cbt.Footer.GetRowGroups())) {
+				return errors.Wrap(io.EOF, "io.EOF")
+			}
+			if err = cbt.NextRowGroup(); err != nil {
+				return errors.Wrap(err, "cbt.NextRowGroup")
+			}
+			continue
+		}
+
+		page, err := layout.ReadPageRawDataContext(cbt.context(), cbt.ThriftReader, cbt.SchemaHandler, cbt.ChunkHeader.MetaData)
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return errors.Wrap(err, "layout.ReadPageRawDataContext")
+		}
+		if page.Header.GetType() == parquet.PageType_DICTIONARY_PAGE {
+			if cbt.TypedDict, err = page.DecodeTypedDict(); err != nil {
+				return errors.Wrap(err, "page.DecodeTypedDict")
+			}
+			cbt.DictPage = nil
+			continue
+		}
+
+		typedPage, err := page.DecodeTyped(cbt.SchemaHandler, cbt.typedDict())
+		if err != nil {
+			return errors.Wrap(err, "page.DecodeTyped")
+		}
+		cbt.ChunkReadValues += int64(len(typedPage.DefinitionLevels))
+		cbt.ChunkReadRows += typedPage.NumRows
+		cbt.PageIndex++
+		cbt.TypedPage = typedPage
+		return nil
+	}
+}
+
+//hasBufferedRows reports whether the DataTable holds rows which are not read
+func (cbt *ColumnBufferType) hasBufferedRows() bool {
+	return cbt.DataTable != nil && len(cbt.DataTable.Values) > 0
+}
+
+//unreadTypedPage moves the rest of the page of the typed batch reads to the DataTable for the row reads
+func (cbt *ColumnBufferType) unreadTypedPage() {
+	page := cbt.TypedPage
+	if page == nil {
+		return
+	}
+	cbt.TypedPage = nil
+	if cbt.TypedLevelIndex >= len(page.DefinitionLevels) {
+		return
+	}
+
+	if cbt.DataTable == nil {
+		index := cbt.SchemaHandler.MapIndex[cbt.PathStr]
+		cbt.DataTable = layout.NewEmptyTable()
+		cbt.DataTable.Schema = cbt.SchemaHandler.SchemaElements[index]
+		cbt.DataTable.Path = common.StrToPath(cbt.PathStr)
+	}
+	maxDefinitionLevel, _ := cbt.SchemaHandler.MaxDefinitionLevel(common.StrToPath(cbt.PathStr))
+	page.AppendTo(cbt.DataTable, cbt.TypedLevelIndex, cbt.TypedValueIndex, maxDefinitionLevel)
+
+	cbt.DataTableNumRows = -1
+	for _, rl := range cbt.DataTable.RepetitionLevels {
+		if rl == 0 {
+			cbt.DataTableNumRows++
+		}
+	}
+}
+
+//dictPage returns the dictionary page of the current chunk for the row reads,
+//it's created from the dictionary read by the typed batch reads
+func (cbt *ColumnBufferType) dictPage() *layout.Page {
+	if cbt.DictPage == nil && cbt.TypedDict != nil {
+		page := layout.NewDictPage()
+		page.DataTable = layout.NewEmptyTable()
+		page.DataTable.Values = make([]interface{}, cbt.TypedDict.Len())
+		for i := range page.DataTable.Values {
+			page.DataTable.Values[i] = cbt.TypedDict.Value(i)
+		}
+		cbt.DictPage = page
+	}
+	return cbt.DictPage
+}
+
+//typedDict returns the dictionary of the current chunk for the typed batch reads,
+//it's created from the dictionary page read by the row reads
+func (cbt *ColumnBufferType) typedDict() *layout.TypedValues {
+	if cbt.TypedDict == nil && cbt.DictPage != nil && cbt.DictPage.DataTable != nil {
+		values := layout.NewTypedValues(cbt.ChunkHeader.MetaData.GetType(), len(cbt.DictPage.DataTable.Values))
+		for i, value := range cbt.DictPage.DataTable.Values {
+			if err := values.SetValue(i, value); err != nil {
+				return nil
+			}
+		}
+		cbt.TypedDict = values
+	}
+	return cbt.TypedDict
+}
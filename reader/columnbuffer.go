@@ -1,11 +1,14 @@
 package reader
 
 import (
+	"context"
 	"io"
+	"sort"
 
 	"github.com/apache/thrift/lib/go/thrift"
 	"github.com/pkg/errors"
 	"github.com/sabey/parquet-go/common"
+	"github.com/sabey/parquet-go/encryption"
 	"github.com/sabey/parquet-go/layout"
 	"github.com/sabey/parquet-go/parquet"
 	"github.com/sabey/parquet-go/schema"
@@ -24,14 +27,52 @@ type ColumnBufferType struct {
 	ChunkHeader   *parquet.ColumnChunk
 
 	ChunkReadValues int64
+	//Rows which start in the pages read from the current chunk
+	ChunkReadRows int64
+	//Page locations of the current chunk, it's loaded when pages are skipped
+	OffsetIndex *parquet.OffsetIndex
+	//Index of the next data page of the current chunk
+	PageIndex int64
 
 	DictPage *layout.Page
 
 	DataTable        *layout.Table
 	DataTableNumRows int64
+
+	//Index of the next row, counted over all the row groups
+	RowIndex int64
+	//Only the rows in the ranges are read, nil means all rows
+	RowRanges []RowRange
+
+	//Decryptor of an encrypted file and the cipher of the current chunk, nil if it isn't encrypted
+	FileDecryptor *encryption.FileDecryptor
+	ChunkCipher   *encryption.ChunkCipher
+
+	//Dictionary of the current chunk and the page read by the typed batch reads,
+	//the levels and the values before the indexes are read
+	TypedDict       *layout.TypedValues
+	TypedPage       *layout.TypedPage
+	TypedLevelIndex int
+	TypedValueIndex int
+
+	//Context of the current read, nil out of the reads with a context
+	ctx context.Context
+	//The OffsetIndex of the current chunk can't be used to skip its pages
+	offsetIndexUnusable bool
+	//Conversion of the rows to the schema of the reader, nil if they are read as in the file
+	evolution *columnEvolution
+	//Error of SkipRows or ReadRows, the next reads with a context return it
+	err error
 }
 
 func NewColumnBuffer(pFile source.ParquetFile, footer *parquet.FileMetaData, schemaHandler *schema.SchemaHandler, pathStr string) (*ColumnBufferType, error) {
+	return openColumnBuffer(pFile, footer, schemaHandler, pathStr, nil, nil, nil)
+}
+
+//openColumnBuffer creates a column buffer which decrypts the chunks with fileDecryptor and reads the rows in rowRanges,
+//they are converted to the schema of the reader by evolution
+func openColumnBuffer(pFile source.ParquetFile, footer *parquet.FileMetaData, schemaHandler *schema.SchemaHandler, pathStr string,
+	fileDecryptor *encryption.FileDecryptor, rowRanges []RowRange, evolution *columnEvolution) (*ColumnBufferType, error) {
 	newPFile, err := pFile.Open("")
 	if err != nil {
 		return nil, errors.Wrap(err, "pFile.Open")
@@ -42,6 +83,9 @@ func NewColumnBuffer(pFile source.ParquetFile, footer *parquet.FileMetaData, sch
 		SchemaHandler:    schemaHandler,
 		PathStr:          pathStr,
 		DataTableNumRows: -1,
+		RowRanges:        rowRanges,
+		FileDecryptor:    fileDecryptor,
+		evolution:        evolution,
 	}
 
 	if err = res.NextRowGroup(); errors.Is(err, io.EOF) {
@@ -63,6 +107,17 @@ func (cbt *ColumnBufferType) NextRowGroup() error {
 	}
 
 	cbt.RowGroupIndex++
+	if cbt.evolution.isMissing() {
+		//the column isn't in the file, the chunks are read as chunks without pages
+		cbt.ChunkHeader = &parquet.ColumnChunk{MetaData: &parquet.ColumnMetaData{
+			Type:         cbt.evolution.schema.GetType(),
+			PathInSchema: common.StrToPath(cbt.PathStr)[1:],
+			NumValues:    rowGroups[cbt.RowGroupIndex-1].GetNumRows(),
+		}}
+		cbt.ChunkCipher = nil
+		cbt.resetChunk()
+		return nil
+	}
 
 	columnChunks := rowGroups[cbt.RowGroupIndex-1].GetColumns()
 	i := int64(0)
@@ -82,6 +137,9 @@ func (cbt *ColumnBufferType) NextRowGroup() error {
 	}
 
 	cbt.ChunkHeader = columnChunks[i]
+	if cbt.ChunkCipher, err = newChunkCipher(cbt.FileDecryptor, rowGroups[cbt.RowGroupIndex-1], int(i)); err != nil {
+		return errors.Wrap(err, "newChunkCipher")
+	}
 	if columnChunks[i].FilePath != nil {
 		cbt.PFile.Close()
 		if cbt.PFile, err = cbt.PFile.Open(*columnChunks[i].FilePath); err != nil {
@@ -100,37 +158,159 @@ func (cbt *ColumnBufferType) NextRowGroup() error {
 		cbt.ThriftReader.Close()
 	}
 
-	cbt.ThriftReader = source.ConvertToThriftReader(cbt.PFile, offset, size)
+	cbt.ThriftReader = cbt.newThriftReader(offset, size, 0, columnChunks[i].MetaData.DictionaryPageOffset != nil)
+	cbt.resetChunk()
+	return nil
+}
+
+//resetChunk resets the reads of the current chunk
+func (cbt *ColumnBufferType) resetChunk() {
 	cbt.ChunkReadValues = 0
+	cbt.ChunkReadRows = 0
+	cbt.PageIndex = 0
+	cbt.OffsetIndex = nil
+	cbt.offsetIndexUnusable = false
 	cbt.DictPage = nil
-	return nil
+	cbt.TypedDict = nil
+}
+
+//newThriftReader reads the pages of the current chunk from offset, they are decrypted if the chunk is encrypted.
+//The page ordinal is the number of data pages before offset, dictionary tells whether a dictionary page is at offset.
+func (cbt *ColumnBufferType) newThriftReader(offset int64, size int64, pageOrdinal int16, dictionary bool) *thrift.TBufferedTransport {
+	if cbt.ChunkCipher == nil {
+		return source.ConvertToThriftReader(cbt.PFile, offset, size)
+	}
+	cbt.PFile.Seek(offset, io.SeekStart)
+	pageReader := encryption.NewPageReader(cbt.PFile, cbt.ChunkCipher, pageOrdinal, dictionary)
+	return thrift.NewTBufferedTransport(thrift.NewStreamTransportR(pageReader), int(size))
+}
+
+//chunkNumRows returns the number of rows in the current chunk
+func (cbt *ColumnBufferType) chunkNumRows() int64 {
+	if cbt.RowGroupIndex <= 0 {
+		return 0
+	}
+	return cbt.Footer.RowGroups[cbt.RowGroupIndex-1].GetNumRows()
+}
+
+//chunkHasPages reports whether there are unread pages in the current chunk
+func (cbt *ColumnBufferType) chunkHasPages() bool {
+	if cbt.ChunkHeader == nil || cbt.ChunkHeader.MetaData == nil {
+		return false
+	}
+	//values of the pages skipped by seekToRow are not counted
+	if cbt.OffsetIndex != nil {
+		return cbt.ChunkReadRows < cbt.chunkNumRows()
+	}
+	return cbt.ChunkReadValues < cbt.ChunkHeader.MetaData.NumValues
+}
+
+//offsetIndexUsable reports whether the pages of a chunk with numRows rows can be found by row with its OffsetIndex.
+//The FirstRowIndex must start at 0, increase and stay in the chunk. The indexes of the repeated columns written
+//by the legacy writer are never used, since their pages don't start at repetition level 0.
+func offsetIndexUsable(footer *parquet.FileMetaData, offsetIndex *parquet.OffsetIndex, numRows int64, repeated bool) bool {
+	if repeated && footer.GetCreatedBy() == common.LegacyCreatedBy {
+		return false
+	}
+	locations := offsetIndex.GetPageLocations()
+	if len(locations) == 0 || locations[0].FirstRowIndex != 0 {
+		return false
+	}
+	for i := 1; i < len(locations); i++ {
+		if locations[i].FirstRowIndex <= locations[i-1].FirstRowIndex {
+			return false
+		}
+	}
+	return locations[len(locations)-1].FirstRowIndex < numRows
+}
+
+//seekable reports whether the pages of the current chunk can be skipped with its OffsetIndex,
+//it's loaded the first time. The chunks without a usable OffsetIndex are read page by page.
+func (cbt *ColumnBufferType) seekable() (bool, error) {
+	if cbt.OffsetIndex != nil {
+		return true, nil
+	}
+	if cbt.offsetIndexUnusable || !cbt.ChunkHeader.IsSetOffsetIndexOffset() {
+		return false, nil
+	}
+
+	//the pages are read from the current position of PFile if the OffsetIndex isn't used
+	pos, err := cbt.PFile.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return false, errors.Wrap(err, "cbt.PFile.Seek")
+	}
+	offsetIndex, err := layout.ReadOffsetIndex(cbt.PFile, cbt.ChunkHeader, cbt.ChunkCipher)
+	if err != nil {
+		return false, errors.Wrap(err, "layout.ReadOffsetIndex")
+	}
+	if _, err = cbt.PFile.Seek(pos, io.SeekStart); err != nil {
+		return false, errors.Wrap(err, "cbt.PFile.Seek")
+	}
+	maxRL, _ := cbt.SchemaHandler.MaxRepetitionLevel(common.StrToPath(cbt.PathStr))
+	if offsetIndex == nil || !offsetIndexUsable(cbt.Footer, offsetIndex, cbt.chunkNumRows(), maxRL > 0) {
+		cbt.offsetIndexUnusable = true
+		return false, nil
+	}
+	cbt.OffsetIndex = offsetIndex
+	return true, nil
+}
+
+//seekToRow moves the reader to the page holding the row of the current chunk,
+//using the OffsetIndex loaded by seekable. The pages before it are not read and
+//the number of their rows is returned. The DataTable must be empty.
+func (cbt *ColumnBufferType) seekToRow(row int64) (int64, error) {
+	var err error
+	if cbt.OffsetIndex == nil {
+		return 0, errors.Errorf("[seekToRow] no offset index: %v", cbt.PathStr)
+	}
+
+	locations := cbt.OffsetIndex.GetPageLocations()
+	i := sort.Search(len(locations), func(i int) bool {
+		return locations[i].FirstRowIndex > row
+	}) - 1
+	if i < 0 || locations[i].FirstRowIndex < cbt.ChunkReadRows {
+		return 0, errors.Errorf("[seekToRow] invalid offset index: %v", cbt.PathStr)
+	}
+	location := locations[i]
+
+	metaData := cbt.ChunkHeader.MetaData
+	chunkOffset := metaData.DataPageOffset
+	if metaData.DictionaryPageOffset != nil {
+		chunkOffset = *metaData.DictionaryPageOffset
+		if cbt.DictPage == nil && cbt.TypedDict == nil {
+			thriftReader := cbt.newThriftReader(chunkOffset, location.Offset-chunkOffset, 0, true)
+			if cbt.DictPage, _, _, err = layout.ReadPageContext(cbt.context(), thriftReader, cbt.SchemaHandler, metaData); err != nil {
+				return 0, errors.Wrap(err, "layout.ReadPageContext")
+			}
+		}
+	}
+
+	size := chunkOffset + metaData.GetTotalCompressedSize() - location.Offset
+	cbt.ThriftReader = cbt.newThriftReader(location.Offset, size, int16(i), false)
+	skipped := location.FirstRowIndex - cbt.ChunkReadRows
+	cbt.ChunkReadRows = location.FirstRowIndex
+	cbt.PageIndex = int64(i)
+	return skipped, nil
 }
 
 func (cbt *ColumnBufferType) ReadPage() error {
-	if cbt.ChunkHeader != nil && cbt.ChunkHeader.MetaData != nil && cbt.ChunkReadValues < cbt.ChunkHeader.MetaData.NumValues {
-		page, numValues, numRows, err := layout.ReadPage(cbt.ThriftReader, cbt.SchemaHandler, cbt.ChunkHeader.MetaData)
+	if cbt.chunkHasPages() {
+		if cbt.evolution.isMissing() {
+			cbt.readEmptyChunk()
+			return nil
+		}
+		page, numValues, numRows, err := layout.ReadPageContext(cbt.context(), cbt.ThriftReader, cbt.SchemaHandler, cbt.ChunkHeader.MetaData)
 		if err != nil {
 			//data is nil and rl/dl=0, no pages in file
+			if errors.Is(err, io.EOF) && cbt.ChunkReadValues == 0 && cbt.PageIndex == 0 {
+				cbt.readEmptyChunk()
+				return nil
+			}
 			if errors.Is(err, io.EOF) {
-				if cbt.DataTable == nil {
-					index := cbt.SchemaHandler.MapIndex[cbt.PathStr]
-					cbt.DataTable = layout.NewEmptyTable()
-					cbt.DataTable.Schema = cbt.SchemaHandler.SchemaElements[index]
-					cbt.DataTable.Path = common.StrToPath(cbt.PathStr)
-
-				}
-
-				cbt.DataTableNumRows = cbt.ChunkHeader.MetaData.NumValues
-
-				for cbt.ChunkReadValues < cbt.ChunkHeader.MetaData.NumValues {
-					cbt.DataTable.Values = append(cbt.DataTable.Values, nil)
-					cbt.DataTable.RepetitionLevels = append(cbt.DataTable.RepetitionLevels, int32(0))
-					cbt.DataTable.DefinitionLevels = append(cbt.DataTable.DefinitionLevels, int32(0))
-					cbt.ChunkReadValues++
-				}
+				err = io.ErrUnexpectedEOF
 			}
 
-			return errors.Wrap(err, "layout.ReadPage")
+			return errors.Wrap(err, "layout.ReadPageContext")
 		}
 
 		if page.Header.GetType() == parquet.PageType_DICTIONARY_PAGE {
@@ -138,7 +318,9 @@ func (cbt *ColumnBufferType) ReadPage() error {
 			return nil
 		}
 
-		page.Decode(cbt.DictPage)
+		if err = page.DecodeErr(cbt.dictPage()); err != nil {
+			return errors.Wrap(err, "page.DecodeErr")
+		}
 
 		if cbt.DataTable == nil {
 			cbt.DataTable = layout.NewTableFromTable(page.DataTable)
@@ -146,6 +328,8 @@ func (cbt *ColumnBufferType) ReadPage() error {
 
 		cbt.DataTable.Merge(page.DataTable)
 		cbt.ChunkReadValues += numValues
+		cbt.ChunkReadRows += numRows
+		cbt.PageIndex++
 
 		cbt.DataTableNumRows += numRows
 	} else {
@@ -162,11 +346,39 @@ func (cbt *ColumnBufferType) ReadPage() error {
 	return nil
 }
 
+//readEmptyChunk reads the values of a chunk without pages, they are null
+func (cbt *ColumnBufferType) readEmptyChunk() {
+	if cbt.DataTable == nil {
+		index := cbt.SchemaHandler.MapIndex[cbt.PathStr]
+		cbt.DataTable = layout.NewEmptyTable()
+		cbt.DataTable.Schema = cbt.SchemaHandler.SchemaElements[index]
+		cbt.DataTable.Path = common.StrToPath(cbt.PathStr)
+
+	}
+
+	cbt.DataTableNumRows += cbt.ChunkHeader.MetaData.NumValues
+
+	for cbt.ChunkReadValues < cbt.ChunkHeader.MetaData.NumValues {
+		cbt.DataTable.Values = append(cbt.DataTable.Values, nil)
+		cbt.DataTable.RepetitionLevels = append(cbt.DataTable.RepetitionLevels, int32(0))
+		cbt.DataTable.DefinitionLevels = append(cbt.DataTable.DefinitionLevels, int32(0))
+		cbt.ChunkReadValues++
+	}
+	cbt.ChunkReadRows = cbt.chunkNumRows()
+}
+
 func (cbt *ColumnBufferType) ReadPageForSkip() (*layout.Page, error) {
-	if cbt.ChunkHeader != nil && cbt.ChunkHeader.MetaData != nil && cbt.ChunkReadValues < cbt.ChunkHeader.MetaData.NumValues {
-		page, err := layout.ReadPageRawData(cbt.ThriftReader, cbt.SchemaHandler, cbt.ChunkHeader.MetaData)
+	if cbt.chunkHasPages() {
+		if cbt.evolution.isMissing() {
+			cbt.readEmptyChunk()
+			return nil, nil
+		}
+		page, err := layout.ReadPageRawDataContext(cbt.context(), cbt.ThriftReader, cbt.SchemaHandler, cbt.ChunkHeader.MetaData)
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
 		if err != nil {
-			return nil, errors.Wrap(err, "layout.ReadPageRawData")
+			return nil, errors.Wrap(err, "layout.ReadPageRawDataContext")
 		}
 
 		numValues, numRows, err := page.GetRLDLFromRawData(cbt.SchemaHandler)
@@ -186,7 +398,9 @@ func (cbt *ColumnBufferType) ReadPageForSkip() (*layout.Page, error) {
 
 		cbt.DataTable.Merge(page.DataTable)
 		cbt.ChunkReadValues += numValues
+		cbt.ChunkReadRows += numRows
 		cbt.DataTableNumRows += numRows
+		cbt.PageIndex++
 		return page, nil
 
 	} else {
@@ -202,26 +416,266 @@ func (cbt *ColumnBufferType) ReadPageForSkip() (*layout.Page, error) {
 	}
 }
 
+//context returns the context of the current read
+func (cbt *ColumnBufferType) context() context.Context {
+	if cbt.ctx == nil {
+		return context.TODO()
+	}
+	return cbt.ctx
+}
+
+//columnError adds the path of the column, the row group and the offset of the page to err
+func (cbt *ColumnBufferType) columnError(err error) error {
+	var columnErr *ColumnError
+	if err == nil || errors.As(err, &columnErr) {
+		return err
+	}
+	return &ColumnError{
+		Path:       cbt.PathStr,
+		RowGroup:   cbt.RowGroupIndex - 1,
+		PageOffset: cbt.pageOffset(),
+		Err:        err,
+	}
+}
+
+//pageOffset returns the offset of the next page of the current chunk, it's found with the OffsetIndex
+//after the first data page. It returns -1 if it's unknown.
+func (cbt *ColumnBufferType) pageOffset() int64 {
+	if cbt.ChunkHeader == nil || cbt.ChunkHeader.MetaData == nil {
+		return -1
+	}
+	metaData := cbt.ChunkHeader.MetaData
+	if cbt.PageIndex == 0 {
+		if metaData.DictionaryPageOffset != nil && cbt.DictPage == nil && cbt.TypedDict == nil {
+			return *metaData.DictionaryPageOffset
+		}
+		return metaData.DataPageOffset
+	}
+
+	offsetIndex := cbt.OffsetIndex
+	if offsetIndex == nil && cbt.ChunkHeader.IsSetOffsetIndexOffset() {
+		offsetIndex, _ = layout.ReadOffsetIndex(cbt.PFile, cbt.ChunkHeader, cbt.ChunkCipher)
+	}
+	if offsetIndex == nil || cbt.PageIndex >= int64(len(offsetIndex.GetPageLocations())) {
+		return -1
+	}
+	return offsetIndex.GetPageLocations()[cbt.PageIndex].Offset
+}
+
+//SkipRows skips num rows. With RowRanges only the rows in the ranges are counted.
+//An error is kept by the buffer, it's returned by Err and by the next reads with a context.
+//
+//Deprecated: use SkipRowsContext, which returns the errors.
 func (cbt *ColumnBufferType) SkipRows(num int64) int64 {
+	skipped, err := cbt.SkipRowsContext(context.TODO(), num)
+	if err != nil {
+		cbt.err = err
+	}
+	return skipped
+}
+
+//Err returns the error of SkipRows or ReadRows, nil if they didn't fail
+func (cbt *ColumnBufferType) Err() error {
+	return cbt.err
+}
+
+//SkipRowsContext skips num rows like SkipRows, it stops reading the pages when ctx is done.
+//The errors are *ColumnError, an error of SkipRows or ReadRows is returned without skipping any row.
+func (cbt *ColumnBufferType) SkipRowsContext(ctx context.Context, num int64) (int64, error) {
+	if cbt.err != nil {
+		return 0, cbt.err
+	}
+	cbt.ctx = ctx
+	defer func() { cbt.ctx = nil }()
+	cbt.unreadTypedPage()
+	if cbt.RowRanges == nil {
+		skipped, err := cbt.skipRows(num)
+		return skipped, cbt.columnError(err)
+	}
+
+	var skipped int64
+	for skipped < num {
+		n, ok, err := cbt.nextRowRange(num - skipped)
+		if err != nil {
+			return skipped, cbt.columnError(err)
+		}
+		if !ok {
+			break
+		}
+		k, err := cbt.skipRows(n)
+		skipped += k
+		if err != nil {
+			return skipped, cbt.columnError(err)
+		}
+		if k < n {
+			break
+		}
+	}
+	return skipped, nil
+}
+
+//ReadRows reads num rows. With RowRanges only the rows in the ranges are read.
+//An error is kept by the buffer, it's returned by Err and by the next reads with a context.
+//
+//Deprecated: use ReadRowsContext, which returns the errors.
+func (cbt *ColumnBufferType) ReadRows(num int64) (*layout.Table, int64) {
+	table, read, err := cbt.ReadRowsContext(context.TODO(), num)
+	if err != nil {
+		cbt.err = err
+	}
+	return table, read
+}
+
+//ReadRowsContext reads num rows like ReadRows, it stops reading the pages when ctx is done.
+//The errors are *ColumnError, the rows read before an error are returned with it. An error of SkipRows
+//or ReadRows is returned without reading any row.
+func (cbt *ColumnBufferType) ReadRowsContext(ctx context.Context, num int64) (*layout.Table, int64, error) {
+	if cbt.err != nil {
+		return nil, 0, cbt.err
+	}
+	cbt.ctx = ctx
+	defer func() { cbt.ctx = nil }()
+	cbt.unreadTypedPage()
+	if cbt.RowRanges == nil {
+		table, read, err := cbt.readRows(num)
+		return table, read, cbt.columnError(err)
+	}
+
 	var (
+		res  *layout.Table
+		read int64
 		err  error
-		page *layout.Page
 	)
+	for read < num {
+		var (
+			n     int64
+			ok    bool
+			table *layout.Table
+			k     int64
+		)
+		if n, ok, err = cbt.nextRowRange(num - read); err != nil || !ok {
+			break
+		}
+		table, k, err = cbt.readRows(n)
+		if res == nil {
+			res = table
+		} else {
+			res.Merge(table)
+		}
+		read += k
+		if err != nil || k < n {
+			break
+		}
+	}
+	if res == nil && err == nil {
+		res, _, err = cbt.readRows(0)
+	} else if res == nil {
+		res = layout.NewEmptyTable()
+	}
+	return res, read, cbt.columnError(err)
+}
+
+//nextRowRange skips the rows before the next range of RowRanges and returns
+//the number of rows, at most num, which can be read from the range
+func (cbt *ColumnBufferType) nextRowRange(num int64) (int64, bool, error) {
+	i := sort.Search(len(cbt.RowRanges), func(i int) bool {
+		return cbt.RowRanges[i].End > cbt.RowIndex
+	})
+	if i >= len(cbt.RowRanges) {
+		return 0, false, nil
+	}
+
+	rowRange := cbt.RowRanges[i]
+	if cbt.RowIndex < rowRange.Start {
+		if _, err := cbt.skipRows(rowRange.Start - cbt.RowIndex); err != nil {
+			return 0, false, errors.Wrap(err, "cbt.skipRows")
+		}
+		if cbt.RowIndex < rowRange.Start {
+			return 0, false, nil
+		}
+	}
+
+	if n := rowRange.End - cbt.RowIndex; n < num {
+		num = n
+	}
+	return num, true, nil
+}
+
+//skipRows skips num rows. Whole row groups are skipped without reading them,
+//and so are whole pages of the chunks with an OffsetIndex.
+func (cbt *ColumnBufferType) skipRows(num int64) (int64, error) {
+	var (
+		err     error
+		page    *layout.Page
+		skipped int64
+	)
+
+	//the buffered rows are complete when the pages start at row boundaries,
+	//which is required for the chunks with a usable OffsetIndex
+	rowGroupNum := int64(len(cbt.Footer.GetRowGroups()))
+	for buffered := cbt.DataTableNumRows + 1; num > buffered && (cbt.chunkHasPages() || cbt.RowGroupIndex < rowGroupNum); buffered = cbt.DataTableNumRows + 1 {
+		chunkRows := cbt.chunkNumRows() - cbt.ChunkReadRows
+		rest := num - buffered
+		nextRowGroup := rest >= chunkRows && cbt.RowGroupIndex < rowGroupNum
+		seek := false
+		if rest < chunkRows {
+			if seek, err = cbt.seekable(); err != nil {
+				cbt.RowIndex += skipped
+				return skipped, errors.Wrap(err, "cbt.seekable")
+			}
+		}
+		if !nextRowGroup && !seek {
+			break
+		}
+
+		cbt.DataTable = layout.NewTableFromTable(cbt.DataTable)
+		cbt.DataTableNumRows = -1
+		skipped, num = skipped+buffered, rest
+
+		if nextRowGroup {
+			if err = cbt.NextRowGroup(); err != nil {
+				cbt.RowIndex += skipped
+				return skipped, errors.Wrap(err, "cbt.NextRowGroup")
+			}
+			skipped, num = skipped+chunkRows, num-chunkRows
+			continue
+		}
+
+		n, err := cbt.seekToRow(cbt.ChunkReadRows + rest)
+		if err != nil {
+			cbt.RowIndex += skipped
+			return skipped, errors.Wrap(err, "cbt.seekToRow")
+		}
+		skipped, num = skipped+n, num-n
+		break
+	}
 
 	for cbt.DataTableNumRows < num && err == nil {
 		page, err = cbt.ReadPageForSkip()
 	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		cbt.RowIndex += skipped
+		return skipped, errors.Wrap(err, "cbt.ReadPageForSkip")
+	}
 
 	if num > cbt.DataTableNumRows {
 		num = cbt.DataTableNumRows
 	}
+	if cbt.DataTable == nil || num < 0 {
+		cbt.RowIndex += skipped
+		return skipped, nil
+	}
 
 	if page != nil {
 		if err = page.GetValueFromRawData(cbt.SchemaHandler); err != nil {
-			return 0
+			cbt.RowIndex += skipped
+			return skipped, errors.Wrap(err, "page.GetValueFromRawData")
 		}
 
-		page.Decode(cbt.DictPage)
+		if err = page.DecodeErr(cbt.dictPage()); err != nil {
+			cbt.RowIndex += skipped
+			return skipped, errors.Wrap(err, "page.DecodeErr")
+		}
 		i, j := len(cbt.DataTable.Values)-1, len(page.DataTable.Values)-1
 		for i >= 0 && j >= 0 {
 			cbt.DataTable.Values[i] = page.DataTable.Values[j]
@@ -237,15 +691,24 @@ func (cbt *ColumnBufferType) SkipRows(num int64) int64 {
 		cbt.DataTable.Merge(tmp)
 	}
 
-	return num
+	skipped += num
+	cbt.RowIndex += skipped
+	return skipped, nil
 }
 
-func (cbt *ColumnBufferType) ReadRows(num int64) (*layout.Table, int64) {
+func (cbt *ColumnBufferType) readRows(num int64) (*layout.Table, int64, error) {
 	var err error
 
 	for cbt.DataTableNumRows < num && err == nil {
 		err = cbt.ReadPage()
 	}
+	if errors.Is(err, io.EOF) {
+		err = nil
+		//every read at the end of the column counts the last row, there is none left if the table is empty
+		if cbt.DataTable == nil || len(cbt.DataTable.RepetitionLevels) == 0 {
+			cbt.DataTableNumRows = 0
+		}
+	}
 
 	if cbt.DataTableNumRows < 0 {
 		cbt.DataTableNumRows = 0
@@ -258,12 +721,19 @@ func (cbt *ColumnBufferType) ReadRows(num int64) (*layout.Table, int64) {
 
 	res := cbt.DataTable.Pop(num)
 	cbt.DataTableNumRows -= num
+	if cbt.evolution != nil {
+		cbt.evolution.convert(res)
+	}
 
 	if cbt.DataTableNumRows <= 0 { //release previous slice memory
 		tmp := cbt.DataTable
 		cbt.DataTable = layout.NewTableFromTable(tmp)
 		cbt.DataTable.Merge(tmp)
 	}
-	return res, num
+	cbt.RowIndex += num
+	if err != nil {
+		return res, num, errors.Wrap(err, "cbt.ReadPage")
+	}
+	return res, num, nil
 
 }
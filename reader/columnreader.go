@@ -1,22 +1,35 @@
 package reader
 
 import (
+	"context"
+
 	"github.com/pkg/errors"
+	"github.com/sabey/parquet-go/layout"
+	"github.com/sabey/parquet-go/parquet"
 	"github.com/sabey/parquet-go/schema"
 	"github.com/sabey/parquet-go/source"
 )
 
 // NewParquetColumnReader creates a parquet column reader
-func NewParquetColumnReader(pFile source.ParquetFile, np int64) (*ParquetReader, error) {
+func NewParquetColumnReader(pFile source.ParquetFile, np int64, opts ...ReaderOption) (*ParquetReader, error) {
 	res := new(ParquetReader)
 	res.NP = np
 	res.PFile = pFile
+	for _, opt := range opts {
+		opt(res)
+	}
 	if err := res.ReadFooter(); err != nil {
 		return nil, errors.Wrap(err, "res.ReadFooter")
 	}
 	res.ColumnBuffers = make(map[string]*ColumnBufferType)
 	res.SchemaHandler = schema.NewSchemaHandlerFromSchemaList(res.Footer.GetSchema())
 	res.RenameSchema()
+	if err := res.FilterRowGroups(); err != nil {
+		return res, errors.Wrap(err, "res.FilterRowGroups")
+	}
+	if err := res.FilterPages(); err != nil {
+		return res, errors.Wrap(err, "res.FilterPages")
+	}
 
 	return res, nil
 }
@@ -35,13 +48,15 @@ func (pr *ParquetReader) SkipRowsByPath(pathStr string, num int64) error {
 
 	if _, ok := pr.ColumnBuffers[pathStr]; !ok {
 		var err error
-		if pr.ColumnBuffers[pathStr], err = NewColumnBuffer(pr.PFile, pr.Footer, pr.SchemaHandler, pathStr); err != nil {
-			return errors.Wrap(err, "NewColumnBuffer")
+		if pr.ColumnBuffers[pathStr], err = pr.newColumnBuffer(pathStr); err != nil {
+			return errors.Wrap(err, "newColumnBuffer")
 		}
 	}
 
 	if cb, ok := pr.ColumnBuffers[pathStr]; ok {
-		cb.SkipRows(int64(num))
+		if _, err = cb.SkipRowsContext(context.Background(), int64(num)); err != nil {
+			return errors.Wrap(err, "cb.SkipRowsContext")
+		}
 
 	} else {
 		return errors.Wrap(errPathNotFound, "errPathNotFound")
@@ -73,13 +88,16 @@ func (pr *ParquetReader) ReadColumnByPath(pathStr string, num int64) (values []i
 
 	if _, ok := pr.ColumnBuffers[pathStr]; !ok {
 		var err error
-		if pr.ColumnBuffers[pathStr], err = NewColumnBuffer(pr.PFile, pr.Footer, pr.SchemaHandler, pathStr); err != nil {
-			return []interface{}{}, []int32{}, []int32{}, errors.Wrap(err, "NewColumnBuffer")
+		if pr.ColumnBuffers[pathStr], err = pr.newColumnBuffer(pathStr); err != nil {
+			return []interface{}{}, []int32{}, []int32{}, errors.Wrap(err, "newColumnBuffer")
 		}
 	}
 
 	if cb, ok := pr.ColumnBuffers[pathStr]; ok {
-		table, _ := cb.ReadRows(int64(num))
+		table, _, err := cb.ReadRowsContext(context.Background(), int64(num))
+		if err != nil {
+			return table.Values, table.RepetitionLevels, table.DefinitionLevels, errors.Wrap(err, "cb.ReadRowsContext")
+		}
 		return table.Values, table.RepetitionLevels, table.DefinitionLevels, nil
 	}
 	return []interface{}{}, []int32{}, []int32{}, errors.Wrap(errPathNotFound, "errPathNotFound")
@@ -98,3 +116,90 @@ func (pr *ParquetReader) ReadColumnByIndex(index int64, num int64) (values []int
 	}
 	return values, rls, dls, nil
 }
+
+//readBatch reads a column of a physical type with ColumnBufferType.ReadBatch
+func (pr *ParquetReader) readBatch(pathStr string, dataType parquet.Type, defLevels []int16, repLevels []int16, maxValues int,
+	copyValues func(values *layout.TypedValues, from int, to int, dst int)) (int, int, error) {
+	errPathNotFound := errors.Errorf("path %v not found", pathStr)
+
+	pathStr, err := pr.SchemaHandler.ConvertToInPathStr(pathStr)
+	if len(pathStr) <= 0 || err != nil {
+		return 0, 0, errors.Wrap(err, "pr.SchemaHandler.ConvertToInPathStr")
+	}
+
+	index, ok := pr.SchemaHandler.MapIndex[pathStr]
+	if !ok {
+		return 0, 0, errors.Wrap(errPathNotFound, "errPathNotFound")
+	}
+	if schema := pr.SchemaHandler.SchemaElements[index]; !schema.IsSetType() || schema.GetType() != dataType {
+		return 0, 0, errors.Errorf("column %v is not %v", pathStr, dataType)
+	}
+	if pr.resolution != nil && pr.resolution.evolutions[pathStr] != nil {
+		return 0, 0, errors.Errorf("column %v differs from the file, it can only be read by rows", pathStr)
+	}
+
+	if _, ok := pr.ColumnBuffers[pathStr]; !ok {
+		if pr.ColumnBuffers[pathStr], err = pr.newColumnBuffer(pathStr); err != nil {
+			return 0, 0, errors.Wrap(err, "newColumnBuffer")
+		}
+	}
+
+	numLevels, numValues, err := pr.ColumnBuffers[pathStr].ReadBatch(defLevels, repLevels, maxValues, copyValues)
+	if err != nil {
+		return numLevels, numValues, errors.Wrap(err, "ReadBatch")
+	}
+	return numLevels, numValues, nil
+}
+
+//ReadBooleans reads the values of a BOOLEAN column into dst and their levels into defLevels and repLevels.
+//It returns the number of levels and of values read, the values of the null levels are not in dst.
+//At most len(defLevels) levels are read, the level slices can be nil if the column has no such levels.
+//The batch reads and the row reads of a column can be mixed at row boundaries. 0 levels are read at the end.
+func (pr *ParquetReader) ReadBooleans(pathStr string, dst []bool, defLevels []int16, repLevels []int16) (numLevels int, numValues int, err error) {
+	return pr.readBatch(pathStr, parquet.Type_BOOLEAN, defLevels, repLevels, len(dst), func(values *layout.TypedValues, from int, to int, i int) {
+		copy(dst[i:], values.Booleans[from:to])
+	})
+}
+
+//ReadInt32s reads the values of an INT32 column like ReadBooleans.
+func (pr *ParquetReader) ReadInt32s(pathStr string, dst []int32, defLevels []int16, repLevels []int16) (numLevels int, numValues int, err error) {
+	return pr.readBatch(pathStr, parquet.Type_INT32, defLevels, repLevels, len(dst), func(values *layout.TypedValues, from int, to int, i int) {
+		copy(dst[i:], values.Int32s[from:to])
+	})
+}
+
+//ReadInt64s reads the values of an INT64 column like ReadBooleans.
+func (pr *ParquetReader) ReadInt64s(pathStr string, dst []int64, defLevels []int16, repLevels []int16) (numLevels int, numValues int, err error) {
+	return pr.readBatch(pathStr, parquet.Type_INT64, defLevels, repLevels, len(dst), func(values *layout.TypedValues, from int, to int, i int) {
+		copy(dst[i:], values.Int64s[from:to])
+	})
+}
+
+//ReadFloat32s reads the values of a FLOAT column like ReadBooleans.
+func (pr *ParquetReader) ReadFloat32s(pathStr string, dst []float32, defLevels []int16, repLevels []int16) (numLevels int, numValues int, err error) {
+	return pr.readBatch(pathStr, parquet.Type_FLOAT, defLevels, repLevels, len(dst), func(values *layout.TypedValues, from int, to int, i int) {
+		copy(dst[i:], values.Float32s[from:to])
+	})
+}
+
+//ReadFloat64s reads the values of a DOUBLE column like ReadBooleans.
+func (pr *ParquetReader) ReadFloat64s(pathStr string, dst []float64, defLevels []int16, repLevels []int16) (numLevels int, numValues int, err error) {
+	return pr.readBatch(pathStr, parquet.Type_DOUBLE, defLevels, repLevels, len(dst), func(values *layout.TypedValues, from int, to int, i int) {
+		copy(dst[i:], values.Float64s[from:to])
+	})
+}
+
+//ReadByteArrays reads the values of a BYTE_ARRAY column like ReadBooleans.
+//The byte arrays share the buffers of the decoded pages, they must not be modified.
+func (pr *ParquetReader) ReadByteArrays(pathStr string, dst [][]byte, defLevels []int16, repLevels []int16) (numLevels int, numValues int, err error) {
+	return pr.readBatch(pathStr, parquet.Type_BYTE_ARRAY, defLevels, repLevels, len(dst), func(values *layout.TypedValues, from int, to int, i int) {
+		copy(dst[i:], values.ByteArrays[from:to])
+	})
+}
+
+//ReadFixedLenByteArrays reads the values of a FIXED_LEN_BYTE_ARRAY column like ReadByteArrays.
+func (pr *ParquetReader) ReadFixedLenByteArrays(pathStr string, dst [][]byte, defLevels []int16, repLevels []int16) (numLevels int, numValues int, err error) {
+	return pr.readBatch(pathStr, parquet.Type_FIXED_LEN_BYTE_ARRAY, defLevels, repLevels, len(dst), func(values *layout.TypedValues, from int, to int, i int) {
+		copy(dst[i:], values.ByteArrays[from:to])
+	})
+}
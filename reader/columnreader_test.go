@@ -0,0 +1,150 @@
+package reader
+
+import (
+	"testing"
+
+	"github.com/sabey/parquet-go-source/buffer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadTypedBatch(t *testing.T) {
+	buf := pageFile.write(t)
+	pr, err := NewParquetColumnReader(buffer.NewBufferFileFromBytes(buf), 1)
+	assert.Nil(t, err)
+
+	//required values in batches which don't match the pages
+	ids := make([]int64, 37)
+	var id int64
+	for {
+		numLevels, numValues, err := pr.ReadInt64s("parquet_go_root\x01id", ids, nil, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, numLevels, numValues)
+		if numLevels == 0 {
+			break
+		}
+		for _, v := range ids[:numValues] {
+			assert.Equal(t, id, v)
+			id++
+		}
+	}
+	assert.Equal(t, int64(1000), id)
+
+	//dictionary encoded values
+	names := make([][]byte, 1000)
+	numLevels, _, err := pr.ReadByteArrays("parquet_go_root\x01name", names, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 1000, numLevels)
+	for i, name := range names {
+		assert.Equal(t, newPageRecord(int64(i)).Name, string(name))
+	}
+
+	//optional values, the definition levels are needed
+	_, _, err = pr.ReadByteArrays("parquet_go_root\x01note", make([][]byte, 10), nil, nil)
+	assert.NotNil(t, err)
+	notes, defLevels := make([][]byte, 100), make([]int16, 100)
+	id = 0
+	for {
+		numLevels, numValues, err := pr.ReadByteArrays("parquet_go_root\x01note", notes, defLevels, nil)
+		assert.Nil(t, err)
+		if numLevels == 0 {
+			break
+		}
+		j := 0
+		for _, dl := range defLevels[:numLevels] {
+			if rec := newPageRecord(id); rec.Note != nil {
+				assert.Equal(t, int16(1), dl)
+				assert.Equal(t, *rec.Note, string(notes[j]))
+				j++
+			} else {
+				assert.Equal(t, int16(0), dl)
+			}
+			id++
+		}
+		assert.Equal(t, numValues, j)
+	}
+	assert.Equal(t, int64(1000), id)
+
+	//repeated values, the rows are split over the batches
+	items := make([]int32, 13)
+	defLevels, repLevels := make([]int16, 20), make([]int16, 20)
+	var rows [][]int32
+	for {
+		numLevels, numValues, err := pr.ReadInt32s("parquet_go_root\x01items", items, defLevels, repLevels)
+		assert.Nil(t, err)
+		if numLevels == 0 {
+			break
+		}
+		j := 0
+		for i := 0; i < numLevels; i++ {
+			if repLevels[i] == 0 {
+				rows = append(rows, nil)
+			}
+			if defLevels[i] == 1 {
+				rows[len(rows)-1] = append(rows[len(rows)-1], items[j])
+				j++
+			}
+		}
+		assert.Equal(t, numValues, j)
+	}
+	assert.Equal(t, 1000, len(rows))
+	for i, row := range rows {
+		assert.Equal(t, newPageRecord(int64(i)).Items, row)
+	}
+
+	_, _, err = pr.ReadInt32s("parquet_go_root\x01id", make([]int32, 10), nil, nil)
+	assert.NotNil(t, err)
+	pr.ReadStop()
+}
+
+func TestReadTypedBatchWithRows(t *testing.T) {
+	buf := pageFile.write(t)
+	pr, err := NewParquetColumnReader(buffer.NewBufferFileFromBytes(buf), 1)
+	assert.Nil(t, err)
+
+	//the typed batches and the row reads continue each other
+	values, _, _, err := pr.ReadColumnByPath("parquet_go_root\x01name", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 10, len(values))
+	names := make([][]byte, 20)
+	numLevels, _, err := pr.ReadByteArrays("parquet_go_root\x01name", names, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 20, numLevels)
+	for i, name := range names {
+		assert.Equal(t, newPageRecord(int64(10+i)).Name, string(name))
+	}
+	assert.Nil(t, pr.SkipRowsByPath("parquet_go_root\x01name", 470))
+	numLevels, _, err = pr.ReadByteArrays("parquet_go_root\x01name", names, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 20, numLevels)
+	for i, name := range names {
+		assert.Equal(t, newPageRecord(int64(500+i)).Name, string(name))
+	}
+	values, _, _, err = pr.ReadColumnByPath("parquet_go_root\x01name", 1000)
+	assert.Nil(t, err)
+	assert.Equal(t, 480, len(values))
+	assert.Equal(t, newPageRecord(520).Name, values[0])
+	assert.Equal(t, newPageRecord(999).Name, values[479])
+	pr.ReadStop()
+
+	//only the rows of the filtered pages are read
+	filter := WithFilter(Or(Lt("parquet_go_root\x01id", 3), Gt("parquet_go_root\x01id", 996)))
+	pr, err = NewParquetColumnReader(buffer.NewBufferFileFromBytes(buf), 1, filter)
+	assert.Nil(t, err)
+	expected, _, _, err := pr.ReadColumnByPath("parquet_go_root\x01id", 1000)
+	assert.Nil(t, err)
+	pr.ReadStop()
+
+	pr, err = NewParquetColumnReader(buffer.NewBufferFileFromBytes(buf), 1, filter)
+	assert.Nil(t, err)
+	ids := make([]int64, 1000)
+	numLevels, _, err = pr.ReadInt64s("parquet_go_root\x01id", ids, nil, nil)
+	assert.Nil(t, err)
+	assert.True(t, numLevels < 1000)
+	assert.Equal(t, len(expected), numLevels)
+	for i, v := range expected {
+		assert.Equal(t, v, ids[i])
+	}
+	assert.Equal(t, []int64{0, 1, 2}, ids[:3])
+	assert.Equal(t, []int64{997, 998, 999}, ids[numLevels-3:numLevels])
+	pr.ReadStop()
+}
@@ -0,0 +1,94 @@
+package reader
+
+import (
+	"github.com/pkg/errors"
+	"github.com/sabey/parquet-go/encryption"
+	"github.com/sabey/parquet-go/parquet"
+)
+
+//verifyFooter verifies the signature of a plaintext footer of an encrypted file. It's skipped
+//without decryption properties, then only the plaintext columns can be read.
+func (pr *ParquetReader) verifyFooter(footerBuf []byte, signature []byte) error {
+	if pr.DecryptionProperties == nil {
+		return nil
+	}
+	var err error
+	if pr.FileDecryptor, err = encryption.NewFileDecryptor(pr.DecryptionProperties, pr.Footer.EncryptionAlgorithm, pr.Footer.FooterSigningKeyMetadata); err != nil {
+		return errors.Wrap(err, "encryption.NewFileDecryptor")
+	}
+	footerCipher, err := pr.FileDecryptor.FooterCipher()
+	if err != nil {
+		return errors.Wrap(err, "pr.FileDecryptor.FooterCipher")
+	}
+	if err = footerCipher.VerifyFooter(footerBuf, signature); err != nil {
+		return errors.Wrap(err, "footerCipher.VerifyFooter")
+	}
+	return nil
+}
+
+//decryptColumnMetaData decrypts the metadata of the encrypted chunks whose keys are available.
+//The chunks without keys keep their plaintext metadata, or get one with only the path, and
+//they can't be read.
+func (pr *ParquetReader) decryptColumnMetaData() error {
+	for i, rowGroup := range pr.Footer.RowGroups {
+		if !rowGroup.IsSetOrdinal() {
+			ordinal := int16(i)
+			rowGroup.Ordinal = &ordinal
+		}
+
+		for j, chunk := range rowGroup.Columns {
+			if chunk.CryptoMetadata == nil || chunk.EncryptedColumnMetadata == nil {
+				continue
+			}
+			chunkCipher, err := newChunkCipher(pr.FileDecryptor, rowGroup, j)
+			if err != nil {
+				if chunk.MetaData == nil {
+					chunk.MetaData = parquet.NewColumnMetaData()
+					chunk.MetaData.PathInSchema = chunk.CryptoMetadata.GetENCRYPTION_WITH_COLUMN_KEY().GetPathInSchema()
+				}
+				continue
+			}
+
+			buf, err := chunkCipher.Decrypt(chunk.EncryptedColumnMetadata, encryption.ColumnMetaData, 0)
+			if err != nil {
+				return errors.Wrap(err, "chunkCipher.Decrypt")
+			}
+			metaData := parquet.NewColumnMetaData()
+			if err = encryption.Deserialize(metaData, buf); err != nil {
+				return errors.Wrap(err, "encryption.Deserialize")
+			}
+			chunk.MetaData = metaData
+		}
+	}
+	return nil
+}
+
+//newChunkCipher returns the cipher of a column chunk in a row group, it's nil if the chunk isn't encrypted
+func newChunkCipher(fileDecryptor *encryption.FileDecryptor, rowGroup *parquet.RowGroup, column int) (*encryption.ChunkCipher, error) {
+	chunk := rowGroup.Columns[column]
+	if chunk.CryptoMetadata == nil {
+		return nil, nil
+	}
+	if fileDecryptor == nil {
+		return nil, errors.Errorf("column %v is encrypted, no decryption properties", chunk.GetMetaData().GetPathInSchema())
+	}
+	cipher, err := fileDecryptor.ColumnCipher(chunk.CryptoMetadata)
+	if err != nil {
+		return nil, errors.Wrap(err, "fileDecryptor.ColumnCipher")
+	}
+	return &encryption.ChunkCipher{
+		Cipher:          cipher,
+		RowGroupOrdinal: rowGroup.GetOrdinal(),
+		ColumnOrdinal:   int16(column),
+	}, nil
+}
+
+//chunkCipher returns the cipher of a chunk in a row group, it's nil if the chunk isn't encrypted
+func (pr *ParquetReader) chunkCipher(rowGroup *parquet.RowGroup, chunk *parquet.ColumnChunk) (*encryption.ChunkCipher, error) {
+	for i, c := range rowGroup.Columns {
+		if c == chunk {
+			return newChunkCipher(pr.FileDecryptor, rowGroup, i)
+		}
+	}
+	return nil, errors.New("column chunk not found")
+}
@@ -0,0 +1,150 @@
+package reader
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sabey/parquet-go-source/buffer"
+	"github.com/sabey/parquet-go/encryption"
+	"github.com/sabey/parquet-go/writer"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	footerKey = []byte("0123456789abcdef")
+	nameKey   = []byte("fedcba9876543210fedcba9876543210")
+	keys      = encryption.KeyMap{"footer": footerKey, "name": nameKey}
+)
+
+func TestEncryption(t *testing.T) {
+	testData := []*encryption.FileEncryptionProperties{
+		{FooterKey: footerKey, FooterKeyMetadata: []byte("footer")},
+		{
+			Algorithm:         encryption.AES_GCM_CTR_V1,
+			FooterKey:         footerKey,
+			FooterKeyMetadata: []byte("footer"),
+			ColumnKeys:        map[string]*encryption.ColumnKey{"parquet_go_root\x01name": {Key: nameKey, KeyMetadata: []byte("name")}},
+			AADPrefix:         []byte("file"),
+		},
+		{
+			FooterKey:         footerKey,
+			FooterKeyMetadata: []byte("footer"),
+			PlaintextFooter:   true,
+			ColumnKeys:        map[string]*encryption.ColumnKey{"parquet_go_root\x01name": {Key: nameKey, KeyMetadata: []byte("name")}},
+		},
+	}
+
+	for i, properties := range testData {
+		buf := pageFile.write(t, writer.WithEncryption(properties))
+		magic := string(buf[len(buf)-4:])
+		if properties.PlaintextFooter {
+			assert.Equal(t, "PAR1", magic)
+		} else {
+			assert.Equal(t, "PARE", magic)
+		}
+
+		decryption := WithDecryption(&encryption.FileDecryptionProperties{KeyRetriever: keys})
+		pr, err := NewParquetReader(buffer.NewBufferFileFromBytes(buf), new(pageRecord), 1, decryption)
+		assert.Nil(t, err, "case %v", i)
+		assert.Nil(t, pr.SkipRows(100))
+		recs := make([]pageRecord, 900)
+		assert.Nil(t, pr.Read(&recs))
+		for j, rec := range recs {
+			assert.Equal(t, newPageRecord(int64(100+j)), rec)
+		}
+		pr.ReadStop()
+
+		pr, err = NewParquetReader(buffer.NewBufferFileFromBytes(buf), new(pageRecord), 1, decryption,
+			WithFilter(And(Gt("parquet_go_root\x01id", 250), Lt("parquet_go_root\x01id", 260), Eq("parquet_go_root\x01name", "z"))))
+		assert.Nil(t, err, "case %v", i)
+		recs = make([]pageRecord, pr.GetNumRows())
+		assert.Nil(t, pr.Read(&recs))
+		found := false
+		for _, rec := range recs {
+			found = found || rec.ID == 259
+		}
+		assert.True(t, found, "case %v", i)
+		pr.ReadStop()
+
+		_, err = NewParquetReader(buffer.NewBufferFileFromBytes(buf), new(pageRecord), 1)
+		assert.NotNil(t, err, "case %v", i)
+		_, err = NewParquetReader(buffer.NewBufferFileFromBytes(buf), new(pageRecord), 1,
+			WithDecryption(&encryption.FileDecryptionProperties{KeyRetriever: encryption.KeyMap{"footer": nameKey, "name": footerKey}}))
+		assert.NotNil(t, err, "case %v", i)
+	}
+}
+
+func TestEncryptionPlaintextFooter(t *testing.T) {
+	buf := pageFile.write(t, writer.WithEncryption(&encryption.FileEncryptionProperties{
+		FooterKey:         footerKey,
+		FooterKeyMetadata: []byte("footer"),
+		PlaintextFooter:   true,
+		ColumnKeys:        map[string]*encryption.ColumnKey{"parquet_go_root\x01name": {Key: nameKey, KeyMetadata: []byte("name")}},
+	}))
+
+	//the plaintext columns are read without keys
+	pr, err := NewParquetColumnReader(buffer.NewBufferFileFromBytes(buf), 1)
+	assert.Nil(t, err)
+	values, _, _, err := pr.ReadColumnByPath("parquet_go_root\x01id", 1000)
+	assert.Nil(t, err)
+	assert.Equal(t, 1000, len(values))
+	assert.Equal(t, int64(999), values[999])
+	_, _, _, err = pr.ReadColumnByPath("parquet_go_root\x01name", 1000)
+	assert.NotNil(t, err)
+	pr.ReadStop()
+
+	//the footer signature is verified with the footer key
+	tampered := append([]byte{}, buf...)
+	tampered[len(tampered)-8-5] ^= 1
+	_, err = NewParquetColumnReader(buffer.NewBufferFileFromBytes(tampered), 1,
+		WithDecryption(&encryption.FileDecryptionProperties{KeyRetriever: keys}))
+	assert.NotNil(t, err)
+}
+
+func TestEncryptionAADPrefix(t *testing.T) {
+	buf := pageFile.write(t, writer.WithEncryption(&encryption.FileEncryptionProperties{
+		FooterKey:       footerKey,
+		AADPrefix:       []byte("file"),
+		SupplyAADPrefix: true,
+	}))
+
+	keyRetriever := encryption.KeyMap{"": footerKey}
+	_, err := NewParquetReader(buffer.NewBufferFileFromBytes(buf), new(pageRecord), 1,
+		WithDecryption(&encryption.FileDecryptionProperties{KeyRetriever: keyRetriever}))
+	assert.NotNil(t, err)
+	_, err = NewParquetReader(buffer.NewBufferFileFromBytes(buf), new(pageRecord), 1,
+		WithDecryption(&encryption.FileDecryptionProperties{KeyRetriever: keyRetriever, AADPrefix: []byte("other")}))
+	assert.NotNil(t, err)
+
+	pr, err := NewParquetReader(buffer.NewBufferFileFromBytes(buf), new(pageRecord), 1,
+		WithDecryption(&encryption.FileDecryptionProperties{KeyRetriever: keyRetriever, AADPrefix: []byte("file")}))
+	assert.Nil(t, err)
+	recs := make([]pageRecord, 1000)
+	assert.Nil(t, pr.Read(&recs))
+	assert.Equal(t, newPageRecord(999), recs[999])
+	pr.ReadStop()
+}
+
+func TestEncryptionBloomFilter(t *testing.T) {
+	buf := testFile{
+		Schema:  new(bloomRecord),
+		Record:  func(i int) interface{} { return bloomRecord{ID: int64(i), Name: "name-" + string(rune('a'+i%26))} },
+		NumRows: 100,
+	}.write(t, writer.WithEncryption(&encryption.FileEncryptionProperties{
+		FooterKey:         footerKey,
+		FooterKeyMetadata: []byte("footer"),
+		ColumnKeys:        map[string]*encryption.ColumnKey{"parquet_go_root\x01name": {Key: nameKey, KeyMetadata: []byte("name")}},
+	}))
+	assert.False(t, bytes.Contains(buf, []byte("name-")))
+
+	pr, err := NewParquetReader(buffer.NewBufferFileFromBytes(buf), new(bloomRecord), 1,
+		WithDecryption(&encryption.FileDecryptionProperties{KeyRetriever: keys}))
+	assert.Nil(t, err)
+	ok, err := pr.MightContain("parquet_go_root\x01name", "name-c")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	ok, err = pr.MightContain("parquet_go_root\x01name", "name-1")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	pr.ReadStop()
+}
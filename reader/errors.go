@@ -0,0 +1,81 @@
+package reader
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+//ColumnError is an error of a column reader. PageOffset is the offset in the file of the page
+//which was read, -1 if it's unknown.
+type ColumnError struct {
+	Path       string
+	RowGroup   int64
+	PageOffset int64
+	Err        error
+}
+
+func (e *ColumnError) Error() string {
+	return fmt.Sprintf("column %v, row group %v, page offset %v: %v", strings.Replace(e.Path, "\x01", ".", -1), e.RowGroup, e.PageOffset, e.Err)
+}
+
+func (e *ColumnError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ColumnError) Cause() error {
+	return e.Err
+}
+
+//ColumnErrors holds the errors of the columns of a read
+type ColumnErrors []*ColumnError
+
+func (e ColumnErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%v column errors: %v", len(e), strings.Join(msgs, "; "))
+}
+
+//Is reports whether one of the column errors matches target
+func (e ColumnErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+//As finds the first column error which matches target
+func (e ColumnErrors) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+//SchemaError is a column of the schema of a reader which can't be read from the file
+type SchemaError struct {
+	Path   string
+	Reason string
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("column %v: %v", strings.Replace(e.Path, "\x01", ".", -1), e.Reason)
+}
+
+//SchemaErrors holds the columns of the schema of a reader which can't be read from the file
+type SchemaErrors []*SchemaError
+
+func (e SchemaErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%v incompatible columns: %v", len(e), strings.Join(msgs, "; "))
+}
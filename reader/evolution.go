@@ -0,0 +1,331 @@
+package reader
+
+import (
+	"fmt"
+
+	"github.com/sabey/parquet-go/common"
+	"github.com/sabey/parquet-go/layout"
+	"github.com/sabey/parquet-go/parquet"
+	"github.com/sabey/parquet-go/schema"
+)
+
+//schemaResolution matches the schema of a reader with the schema of the file
+type schemaResolution struct {
+	//Index of the element of the file by index of the element of the reader, -1 if it isn't in the file
+	fileIndexes []int32
+	//Internal path in the reader by path in the file of the columns of the file which are read
+	inPaths map[string]string
+	//Schema handler of the reader with the types and the repetition types of the file, the pages are decoded with it
+	readSchemaHandler *schema.SchemaHandler
+	//Conversions of the columns which differ from the file
+	evolutions map[string]*columnEvolution
+}
+
+//columnEvolution converts the rows of a column read from the file to the schema of the reader
+type columnEvolution struct {
+	//Schema element of the column in the reader
+	schema *parquet.SchemaElement
+	//Definition levels of the reader by definition level of the file, nil if they are the same
+	definitionLevels []int32
+	//Converts a value of the file to the type of the reader, nil if they have the same type
+	promote func(interface{}) interface{}
+	//The column isn't in the file, its values are null
+	missing bool
+}
+
+//isMissing reports whether the column isn't in the file
+func (ce *columnEvolution) isMissing() bool {
+	return ce != nil && ce.missing
+}
+
+//convert converts a table read from the file to the schema of the reader
+func (ce *columnEvolution) convert(table *layout.Table) {
+	if ce.definitionLevels != nil {
+		for i, dl := range table.DefinitionLevels {
+			table.DefinitionLevels[i] = ce.definitionLevels[dl]
+		}
+		if int(table.MaxDefinitionLevel) < len(ce.definitionLevels) {
+			table.MaxDefinitionLevel = ce.definitionLevels[table.MaxDefinitionLevel]
+		}
+	}
+	if ce.promote != nil {
+		for i, value := range table.Values {
+			if value != nil {
+				table.Values[i] = ce.promote(value)
+			}
+		}
+	}
+	table.Schema = ce.schema
+	table.RepetitionType = ce.schema.GetRepetitionType()
+}
+
+//promotions are the physical types of the file which can be read as a wider type. They return the conversion of
+//the values with the logical types of the file and of the reader, nil if they aren't compatible.
+var promotions = map[[2]parquet.Type]func(fileType, readType *parquet.LogicalType) func(interface{}) interface{}{
+	{parquet.Type_INT32, parquet.Type_INT64}: func(fileType, readType *parquet.LogicalType) func(interface{}) interface{} {
+		if isDecimal(fileType) || isDecimal(readType) {
+			if !isDecimal(fileType) || !isDecimal(readType) || !compatibleLogicalTypes(fileType, readType) {
+				return nil
+			}
+			return func(v interface{}) interface{} {
+				return int64(v.(int32))
+			}
+		}
+		fileInt, readInt := intType(fileType, 32), intType(readType, 64)
+		if fileInt == nil || readInt == nil || !compatibleIntTypes(fileInt, readInt) {
+			return nil
+		}
+		if !fileInt.IsSigned {
+			return func(v interface{}) interface{} {
+				return int64(uint32(v.(int32)))
+			}
+		}
+		return func(v interface{}) interface{} {
+			return int64(v.(int32))
+		}
+	},
+	{parquet.Type_FLOAT, parquet.Type_DOUBLE}: func(fileType, readType *parquet.LogicalType) func(interface{}) interface{} {
+		if fileType != nil || readType != nil {
+			return nil
+		}
+		return func(v interface{}) interface{} {
+			return float64(v.(float32))
+		}
+	},
+}
+
+//logicalType returns the logical type of a column, from its converted type if it has none, nil without any.
+//The adjustment to UTC of the times is cleared, it doesn't change the values.
+func logicalType(se *parquet.SchemaElement) *parquet.LogicalType {
+	lt := se.GetLogicalType()
+	if lt == nil {
+		lt = common.NewLogicalTypeFromConvertedType(se, &common.Tag{Precision: se.GetPrecision(), Scale: se.GetScale()})
+	}
+	if lt == nil || lt.CountSetFieldsLogicalType() == 0 {
+		return nil
+	}
+	res := *lt
+	if lt.TIME != nil {
+		timeType := *lt.TIME
+		timeType.IsAdjustedToUTC = false
+		res.TIME = &timeType
+	}
+	if lt.TIMESTAMP != nil {
+		timestampType := *lt.TIMESTAMP
+		timestampType.IsAdjustedToUTC = false
+		res.TIMESTAMP = &timestampType
+	}
+	return &res
+}
+
+//isDecimal reports whether a logical type is a decimal
+func isDecimal(lt *parquet.LogicalType) bool {
+	return lt != nil && lt.DECIMAL != nil
+}
+
+//intType returns the integer type of a logical type, a signed integer of bitWidth without logical type and nil
+//for the other logical types
+func intType(lt *parquet.LogicalType, bitWidth int8) *parquet.IntType {
+	if lt == nil {
+		return &parquet.IntType{BitWidth: bitWidth, IsSigned: true}
+	}
+	return lt.INTEGER
+}
+
+//compatibleIntTypes reports whether the integers of fileType can be read as readType
+func compatibleIntTypes(fileType, readType *parquet.IntType) bool {
+	if fileType.IsSigned == readType.IsSigned {
+		return fileType.BitWidth <= readType.BitWidth
+	}
+	//the unsigned integers fit in larger signed integers
+	return !fileType.IsSigned && fileType.BitWidth < readType.BitWidth
+}
+
+//compatibleLogicalTypes reports whether the values of a column of the file with the logical type fileType can be
+//read with readType. The values of the columns without logical type are read as they are.
+func compatibleLogicalTypes(fileType, readType *parquet.LogicalType) bool {
+	switch {
+	case fileType == nil || readType == nil:
+		return true
+	case fileType.INTEGER != nil && readType.INTEGER != nil:
+		return compatibleIntTypes(fileType.INTEGER, readType.INTEGER)
+	case fileType.DECIMAL != nil && readType.DECIMAL != nil:
+		return fileType.DECIMAL.Scale == readType.DECIMAL.Scale && fileType.DECIMAL.Precision <= readType.DECIMAL.Precision
+	}
+	return fileType.Equals(readType)
+}
+
+//typeName returns the physical type of a column with its converted type
+func typeName(se *parquet.SchemaElement) string {
+	if se.IsSetConvertedType() {
+		return fmt.Sprintf("%v %v", se.GetType(), se.GetConvertedType())
+	}
+	return se.GetType().String()
+}
+
+//resolveSchema resolves the schema of the reader against the schema of the file. The elements are matched by their
+//names in their parents, or by their field_id with ResolveByFieldID.
+func (pr *ParquetReader) resolveSchema() error {
+	sh := pr.SchemaHandler
+	fileSchemaHandler := schema.NewSchemaHandlerFromSchemaList(pr.Footer.Schema)
+
+	//the parents are before their children
+	fileIndexes := make([]int32, len(sh.SchemaElements))
+	for i := 1; i < len(fileIndexes); i++ {
+		fileIndexes[i] = -1
+		if fieldID := sh.SchemaElements[i].GetFieldID(); pr.ResolveByFieldID && fieldID != 0 {
+			if inPathStr, ok := fileSchemaHandler.FieldIDToPath[fieldID]; ok {
+				fileIndexes[i] = fileSchemaHandler.MapIndex[inPathStr]
+			}
+			continue
+		}
+
+		path := common.StrToPath(sh.IndexMap[int32(i)])
+		parent := fileIndexes[sh.MapIndex[common.PathToStr(path[:len(path)-1])]]
+		if parent < 0 {
+			continue
+		}
+		exPathStr := fileSchemaHandler.InPathToExPath[fileSchemaHandler.IndexMap[parent]] + common.PAR_GO_PATH_DELIMITER + sh.Infos[i].ExName
+		if inPathStr, ok := fileSchemaHandler.ExPathToInPath[exPathStr]; ok {
+			fileIndexes[i] = fileSchemaHandler.MapIndex[inPathStr]
+		}
+	}
+	return pr.resolveSchemaIndexes(fileSchemaHandler, fileIndexes)
+}
+
+//resolveSchemaIndexes checks that the columns of the reader can be read from the elements of the file at fileIndexes.
+//The columns missing in the file are read as nulls and the columns of the file which aren't in the reader are ignored.
+//INT32 is read as INT64, FLOAT as DOUBLE and REQUIRED as OPTIONAL, the integers as wider integers and the decimals
+//with a higher precision. The other differences, like a DATE read as a TIMESTAMP_MILLIS, are SchemaErrors.
+func (pr *ParquetReader) resolveSchemaIndexes(fileSchemaHandler *schema.SchemaHandler, fileIndexes []int32) error {
+	sh := pr.SchemaHandler
+	res := &schemaResolution{
+		fileIndexes:       fileIndexes,
+		inPaths:           make(map[string]string),
+		readSchemaHandler: sh,
+		evolutions:        make(map[string]*columnEvolution),
+	}
+
+	var errs SchemaErrors
+	for _, pathStr := range sh.ValueColumns {
+		evolution, err := resolveColumn(sh, fileSchemaHandler, fileIndexes, pathStr)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if evolution != nil {
+			res.evolutions[pathStr] = evolution
+		}
+		if j := fileIndexes[sh.MapIndex[pathStr]]; j >= 0 {
+			filePath := common.StrToPath(fileSchemaHandler.InPathToExPath[fileSchemaHandler.IndexMap[j]])[1:]
+			res.inPaths[common.PathToStr(filePath)] = pathStr
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	//the pages are decoded with the levels and the types of the file
+	if len(res.evolutions) > 0 {
+		read := *sh
+		read.SchemaElements = make([]*parquet.SchemaElement, len(sh.SchemaElements))
+		for i, se := range sh.SchemaElements {
+			read.SchemaElements[i] = se
+			if j := fileIndexes[i]; i > 0 && j >= 0 {
+				fileSchema := fileSchemaHandler.SchemaElements[j]
+				readSchema := *se
+				readSchema.Type = fileSchema.Type
+				readSchema.TypeLength = fileSchema.TypeLength
+				readSchema.RepetitionType = fileSchema.RepetitionType
+				readSchema.ConvertedType = fileSchema.ConvertedType
+				readSchema.LogicalType = fileSchema.LogicalType
+				read.SchemaElements[i] = &readSchema
+			}
+		}
+		res.readSchemaHandler = &read
+	}
+	pr.resolution = res
+	return nil
+}
+
+//resolveColumn returns the conversion of a column of the reader from the file, nil if it's read as it is
+func resolveColumn(sh *schema.SchemaHandler, fileSchemaHandler *schema.SchemaHandler, fileIndexes []int32, pathStr string) (*columnEvolution, *SchemaError) {
+	path := common.StrToPath(pathStr)
+	se := sh.SchemaElements[sh.MapIndex[pathStr]]
+	schemaError := func(format string, args ...interface{}) *SchemaError {
+		return &SchemaError{Path: pathStr, Reason: fmt.Sprintf(format, args...)}
+	}
+
+	if fileIndexes[sh.MapIndex[pathStr]] < 0 {
+		if maxDefinitionLevel, _ := sh.MaxDefinitionLevel(path); maxDefinitionLevel == 0 {
+			return nil, schemaError("required column is not in the file")
+		}
+		return &columnEvolution{schema: se, missing: true}, nil
+	}
+
+	//definition levels of the nodes of the path in the file and in the reader
+	fileLevels, levels := make([]int32, len(path)), make([]int32, len(path))
+	parent := int32(0)
+	for k := 1; k < len(path); k++ {
+		index := sh.MapIndex[common.PathToStr(path[:k+1])]
+		if fileIndexes[index] < 0 {
+			return nil, schemaError("%v is not in the file", sh.Infos[index].ExName)
+		}
+		//the elements matched by field_id must keep their parents
+		filePath := common.StrToPath(fileSchemaHandler.IndexMap[fileIndexes[index]])
+		if common.PathToStr(filePath[:len(filePath)-1]) != fileSchemaHandler.IndexMap[fileIndexes[parent]] {
+			return nil, schemaError("%v is in another group in the file", sh.Infos[index].ExName)
+		}
+		parent = index
+
+		fileSchema := fileSchemaHandler.SchemaElements[fileIndexes[index]]
+		fileRepetitionType, repetitionType := fileSchema.GetRepetitionType(), sh.SchemaElements[index].GetRepetitionType()
+		if fileRepetitionType != repetitionType &&
+			(fileRepetitionType != parquet.FieldRepetitionType_REQUIRED || repetitionType != parquet.FieldRepetitionType_OPTIONAL) {
+			return nil, schemaError("%v %v in the file can't be read as %v", sh.Infos[index].ExName, fileRepetitionType, repetitionType)
+		}
+		if (fileSchema.GetNumChildren() > 0) != (sh.SchemaElements[index].GetNumChildren() > 0) {
+			return nil, schemaError("%v is a group in only one of the file and the reader", sh.Infos[index].ExName)
+		}
+
+		fileLevels[k], levels[k] = fileLevels[k-1], levels[k-1]
+		if fileRepetitionType != parquet.FieldRepetitionType_REQUIRED {
+			fileLevels[k]++
+		}
+		if repetitionType != parquet.FieldRepetitionType_REQUIRED {
+			levels[k]++
+		}
+	}
+
+	res := &columnEvolution{schema: se}
+	fileSchema := fileSchemaHandler.SchemaElements[fileIndexes[sh.MapIndex[pathStr]]]
+	fileLogicalType, readLogicalType := logicalType(fileSchema), logicalType(se)
+	if fileSchema.GetType() != se.GetType() {
+		if promotion := promotions[[2]parquet.Type{fileSchema.GetType(), se.GetType()}]; promotion != nil {
+			res.promote = promotion(fileLogicalType, readLogicalType)
+		}
+		if res.promote == nil {
+			return nil, schemaError("type %v in the file can't be read as %v", typeName(fileSchema), typeName(se))
+		}
+	} else if se.GetType() == parquet.Type_FIXED_LEN_BYTE_ARRAY && fileSchema.GetTypeLength() != se.GetTypeLength() {
+		return nil, schemaError("length %v in the file can't be read as %v", fileSchema.GetTypeLength(), se.GetTypeLength())
+	} else if !compatibleLogicalTypes(fileLogicalType, readLogicalType) {
+		return nil, schemaError("type %v in the file can't be read as %v", typeName(fileSchema), typeName(se))
+	}
+	if fileSchema.IsSetScale() && se.IsSetScale() && fileSchema.GetScale() != se.GetScale() {
+		return nil, schemaError("scale %v in the file can't be read as %v", fileSchema.GetScale(), se.GetScale())
+	}
+
+	//a definition level of the file defines the nodes up to the last one with this level
+	if fileLevels[len(path)-1] != levels[len(path)-1] {
+		res.definitionLevels = make([]int32, fileLevels[len(path)-1]+1)
+		for k := range fileLevels {
+			res.definitionLevels[fileLevels[k]] = levels[k]
+		}
+	}
+
+	if res.promote == nil && res.definitionLevels == nil {
+		return nil, nil
+	}
+	return res, nil
+}
@@ -0,0 +1,254 @@
+package reader
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/sabey/parquet-go-source/buffer"
+	"github.com/stretchr/testify/assert"
+)
+
+type evolutionRecordV1 struct {
+	ID      int32   `parquet:"name=id, type=INT32, bloomfilter=true"`
+	Score   float32 `parquet:"name=score, type=FLOAT"`
+	Name    string  `parquet:"name=name, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Note    *string `parquet:"name=note, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Dropped string  `parquet:"name=dropped, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Items   []int32 `parquet:"name=items, type=INT32, repetitiontype=REPEATED"`
+}
+
+//evolutionRecordV2 reorders the columns, promotes id, score and items, makes name optional,
+//drops dropped and adds email and age
+type evolutionRecordV2 struct {
+	Age   int32   `parquet:"name=age, type=INT32, repetitiontype=OPTIONAL"`
+	Name  *string `parquet:"name=name, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Items []int64 `parquet:"name=items, type=INT64, repetitiontype=REPEATED"`
+	ID    int64   `parquet:"name=id, type=INT64"`
+	Email *string `parquet:"name=email, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Note  *string `parquet:"name=note, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Score float64 `parquet:"name=score, type=DOUBLE"`
+}
+
+func newEvolutionRecordV1(id int32) evolutionRecordV1 {
+	rec := evolutionRecordV1{ID: id, Score: float32(id) / 2, Name: string(rune('a' + id%26)), Dropped: "dropped"}
+	if id%3 == 0 {
+		note := "note"
+		rec.Note = &note
+	}
+	for i := int32(0); i < id%4; i++ {
+		rec.Items = append(rec.Items, id)
+	}
+	return rec
+}
+
+func newEvolutionRecordV2(id int32) evolutionRecordV2 {
+	v1 := newEvolutionRecordV1(id)
+	rec := evolutionRecordV2{ID: int64(id), Score: float64(v1.Score), Name: &v1.Name, Note: v1.Note}
+	for _, item := range v1.Items {
+		rec.Items = append(rec.Items, int64(item))
+	}
+	return rec
+}
+
+//evolutionFile has 2 row groups of 50 rows of evolutionRecordV1 in small pages, ids 0-99
+var evolutionFile = testFile{
+	Schema:       new(evolutionRecordV1),
+	Record:       func(i int) interface{} { return newEvolutionRecordV1(int32(i)) },
+	NumRows:      100,
+	RowGroupRows: 50,
+	PageSize:     64,
+}
+
+func TestSchemaEvolution(t *testing.T) {
+	buf := evolutionFile.write(t)
+
+	for _, skip := range []int64{0, 7, 50, 73} {
+		pr, err := NewParquetReader(buffer.NewBufferFileFromBytes(buf), new(evolutionRecordV2), 2)
+		assert.Nil(t, err)
+		assert.Nil(t, pr.SkipRows(skip))
+		recs := make([]evolutionRecordV2, 100-skip)
+		assert.Nil(t, pr.Read(&recs))
+		assert.Equal(t, int(100-skip), len(recs))
+		for i, rec := range recs {
+			assert.Equal(t, newEvolutionRecordV2(int32(skip)+int32(i)), rec)
+		}
+		pr.ReadStop()
+	}
+
+	//the values read by column are converted too, the typed batch reads need the types of the file
+	pr, err := NewParquetReader(buffer.NewBufferFileFromBytes(buf), new(evolutionRecordV2), 1)
+	assert.Nil(t, err)
+	values, _, dls, err := pr.ReadColumnByPath("parquet_go_root\x01id", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{int64(0), int64(1)}, values)
+	assert.Equal(t, []int32{0, 0}, dls)
+	values, _, dls, err = pr.ReadColumnByPath("parquet_go_root\x01name", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{"a", "b"}, values)
+	assert.Equal(t, []int32{1, 1}, dls)
+	values, _, _, err = pr.ReadColumnByPath("parquet_go_root\x01email", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{nil, nil}, values)
+	_, _, err = pr.ReadInt64s("parquet_go_root\x01id", make([]int64, 1), nil, nil)
+	assert.NotNil(t, err)
+	pr.ReadStop()
+
+	//the statistics and the Bloom filters of the promoted columns are ignored
+	pr, err = NewParquetReader(buffer.NewBufferFileFromBytes(buf), new(evolutionRecordV2), 1,
+		WithFilter(Eq("parquet_go_root\x01id", 60)))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(100), pr.GetNumRows())
+	ok, err := pr.MightContain("parquet_go_root\x01id", 60)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	pr.ReadStop()
+}
+
+func TestSchemaEvolutionErrors(t *testing.T) {
+	buf := evolutionFile.write(t)
+
+	type record struct {
+		ID      string  `parquet:"name=id, type=BYTE_ARRAY, convertedtype=UTF8"`
+		Score   float32 `parquet:"name=score, type=FLOAT"`
+		Note    string  `parquet:"name=note, type=BYTE_ARRAY, convertedtype=UTF8"`
+		Email   string  `parquet:"name=email, type=BYTE_ARRAY, convertedtype=UTF8"`
+		Dropped int32   `parquet:"name=dropped, type=INT32, repetitiontype=OPTIONAL"`
+	}
+	_, err := NewParquetReader(buffer.NewBufferFileFromBytes(buf), new(record), 1)
+	var schemaErrs SchemaErrors
+	assert.True(t, errors.As(err, &schemaErrs))
+	paths := make([]string, len(schemaErrs))
+	for i, schemaErr := range schemaErrs {
+		paths[i] = schemaErr.Path
+	}
+	assert.Equal(t, []string{"Parquet_go_root\x01ID", "Parquet_go_root\x01Note", "Parquet_go_root\x01Email", "Parquet_go_root\x01Dropped"}, paths)
+}
+
+type fieldIDAddressV1 struct {
+	City string `parquet:"name=city, type=BYTE_ARRAY, convertedtype=UTF8, fieldid=5"`
+}
+
+type fieldIDRecordV1 struct {
+	ID      int32            `parquet:"name=id, type=INT32, fieldid=1"`
+	Name    string           `parquet:"name=name, type=BYTE_ARRAY, convertedtype=UTF8, fieldid=2"`
+	Score   float32          `parquet:"name=score, type=FLOAT, fieldid=3"`
+	Address fieldIDAddressV1 `parquet:"name=address, fieldid=4"`
+	Tags    []string         `parquet:"name=tags, type=LIST, valuetype=BYTE_ARRAY, valueconvertedtype=UTF8, fieldid=6, valuefieldid=7"`
+}
+
+type fieldIDAddressV2 struct {
+	Town string `parquet:"name=town, type=BYTE_ARRAY, convertedtype=UTF8, fieldid=5"`
+}
+
+//fieldIDRecordV2 renames the columns of fieldIDRecordV1, and score is a new column with the name of the dropped one
+type fieldIDRecordV2 struct {
+	Key      int64            `parquet:"name=key, type=INT64, fieldid=1"`
+	FullName *string          `parquet:"name=full_name, type=BYTE_ARRAY, convertedtype=UTF8, fieldid=2"`
+	Score    *float64         `parquet:"name=score, type=DOUBLE, fieldid=8"`
+	Location fieldIDAddressV2 `parquet:"name=location, fieldid=4"`
+	Labels   []string         `parquet:"name=labels, type=LIST, valuetype=BYTE_ARRAY, valueconvertedtype=UTF8, fieldid=6, valuefieldid=7"`
+}
+
+func TestFieldIDResolution(t *testing.T) {
+	buf := testFile{
+		Schema: new(fieldIDRecordV1),
+		Record: func(i int) interface{} {
+			name := string(rune('a' + i))
+			return fieldIDRecordV1{ID: int32(i), Name: name, Score: float32(i), Address: fieldIDAddressV1{City: "city-" + name}, Tags: []string{name, name}}
+		},
+		NumRows: 10,
+	}.write(t)
+
+	pr, err := NewParquetReader(buffer.NewBufferFileFromBytes(buf), new(fieldIDRecordV2), 1, WithFieldIDResolution())
+	assert.Nil(t, err)
+	recs := make([]fieldIDRecordV2, 10)
+	assert.Nil(t, pr.Read(&recs))
+	for i, rec := range recs {
+		name := string(rune('a' + i))
+		assert.Equal(t, fieldIDRecordV2{Key: int64(i), FullName: &name, Location: fieldIDAddressV2{Town: "city-" + name}, Labels: []string{name, name}}, rec)
+	}
+	pr.ReadStop()
+
+	//by name, key is a required column which isn't in the file
+	_, err = NewParquetReader(buffer.NewBufferFileFromBytes(buf), new(fieldIDRecordV2), 1)
+	var schemaErrs SchemaErrors
+	assert.True(t, errors.As(err, &schemaErrs))
+	assert.Equal(t, "Parquet_go_root\x01Key", schemaErrs[0].Path)
+
+	//the columns can't move to another group
+	type record struct {
+		City string `parquet:"name=city, type=BYTE_ARRAY, convertedtype=UTF8, fieldid=5"`
+	}
+	_, err = NewParquetReader(buffer.NewBufferFileFromBytes(buf), new(record), 1, WithFieldIDResolution())
+	assert.True(t, errors.As(err, &schemaErrs))
+	assert.Equal(t, 1, len(schemaErrs))
+}
+
+type logicalTypeRecordV1 struct {
+	Unsigned uint32 `parquet:"name=unsigned, type=INT32, convertedtype=UINT_32"`
+	Small    int32  `parquet:"name=small, type=INT32, convertedtype=INT_8"`
+	Price    int32  `parquet:"name=price, type=INT32, convertedtype=DECIMAL, scale=2, precision=9"`
+	Day      int32  `parquet:"name=day, type=INT32, convertedtype=DATE"`
+	Name     string `parquet:"name=name, type=BYTE_ARRAY, convertedtype=UTF8"`
+}
+
+//logicalTypeRecordV2 promotes the integers and the decimal of logicalTypeRecordV1
+type logicalTypeRecordV2 struct {
+	Unsigned uint64 `parquet:"name=unsigned, type=INT64, convertedtype=UINT_64"`
+	Small    int64  `parquet:"name=small, type=INT64"`
+	Price    int64  `parquet:"name=price, type=INT64, convertedtype=DECIMAL, scale=2, precision=18"`
+}
+
+func TestSchemaEvolutionLogicalTypes(t *testing.T) {
+	buf := testFile{
+		Schema: new(logicalTypeRecordV1),
+		Record: func(i int) interface{} {
+			return logicalTypeRecordV1{Unsigned: 4000000000, Small: -100, Price: -12345, Day: 18500, Name: "a"}
+		},
+		NumRows: 1,
+	}.write(t)
+
+	pr, err := NewParquetReader(buffer.NewBufferFileFromBytes(buf), new(logicalTypeRecordV2), 1)
+	assert.Nil(t, err)
+	recs := make([]logicalTypeRecordV2, 1)
+	assert.Nil(t, pr.Read(&recs))
+	assert.Equal(t, []logicalTypeRecordV2{{Unsigned: 4000000000, Small: -100, Price: -12345}}, recs)
+	pr.ReadStop()
+
+	testData := []struct {
+		Name   string
+		Record interface{}
+	}{
+		{"date as timestamp", new(struct {
+			Day int64 `parquet:"name=day, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+		})},
+		{"date as int64", new(struct {
+			Day int64 `parquet:"name=day, type=INT64"`
+		})},
+		{"date as time", new(struct {
+			Day int32 `parquet:"name=day, type=INT32, convertedtype=TIME_MILLIS"`
+		})},
+		{"unsigned as signed", new(struct {
+			Unsigned int32 `parquet:"name=unsigned, type=INT32, convertedtype=INT_32"`
+		})},
+		{"signed as unsigned", new(struct {
+			Small uint64 `parquet:"name=small, type=INT64, convertedtype=UINT_64"`
+		})},
+		{"decimal as integer", new(struct {
+			Price int64 `parquet:"name=price, type=INT64, convertedtype=INT_64"`
+		})},
+		{"decimal with a lower precision", new(struct {
+			Price int32 `parquet:"name=price, type=INT32, convertedtype=DECIMAL, scale=2, precision=4"`
+		})},
+		{"string as enum", new(struct {
+			Name string `parquet:"name=name, type=BYTE_ARRAY, convertedtype=ENUM"`
+		})},
+	}
+	for _, data := range testData {
+		_, err := NewParquetReader(buffer.NewBufferFileFromBytes(buf), data.Record, 1)
+		var schemaErrs SchemaErrors
+		if !errors.As(err, &schemaErrs) || len(schemaErrs) != 1 {
+			t.Errorf("%s: expected a schema error, got %v", data.Name, err)
+		}
+	}
+}
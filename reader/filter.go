@@ -0,0 +1,719 @@
+package reader
+
+import (
+	"encoding/binary"
+	"math"
+	"reflect"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/sabey/parquet-go/bloomfilter"
+	"github.com/sabey/parquet-go/common"
+	"github.com/sabey/parquet-go/layout"
+	"github.com/sabey/parquet-go/parquet"
+	"github.com/sabey/parquet-go/schema"
+)
+
+//Filter is a predicate over column values. It is evaluated against the
+//statistics stored in the file, so a reader can skip data that can't match.
+//Rows in the data that is read are not filtered.
+//Filters are built with Eq, Lt, Gt, In, IsNull, And, Or and Not.
+type Filter interface {
+	//bind resolves the column paths and converts the literal values to the parquet type of the column
+	bind(sh *schema.SchemaHandler) error
+	//eval reports whether any value may match and whether all values must match
+	eval(stats statsFunc) (mayMatch bool, allMatch bool)
+	//columnPaths appends the internal paths of the bound columns to res
+	columnPaths(res []string) []string
+}
+
+//columnStats is the statistics of a column in a row group or a page
+type columnStats struct {
+	Min, Max     interface{}
+	NullCount    int64
+	NumValues    int64
+	HasMinMax    bool
+	HasNullCount bool
+	//all the values are null, it's known for pages without a null count
+	AllNull bool
+	//values of a repeated column don't map one-to-one to rows
+	Repeated bool
+	//BloomFilter loads the Bloom filter of the column chunk, it's nil for pages
+	BloomFilter func() *bloomfilter.Filter
+}
+
+//mightContain reports whether the value may be in the column, using the Bloom filter
+func (st *columnStats) mightContain(value interface{}) bool {
+	if st.BloomFilter == nil {
+		return true
+	}
+	bloomFilter := st.BloomFilter()
+	if bloomFilter == nil {
+		return true
+	}
+	hash, err := bloomfilter.Hash(value)
+	return err != nil || bloomFilter.Check(hash)
+}
+
+//allNull reports whether the column has only null values
+func (st *columnStats) allNull() bool {
+	return st.AllNull || (st.HasNullCount && st.NumValues > 0 && st.NullCount >= st.NumValues)
+}
+
+//statsFunc returns the statistics of a column by its internal path, or nil if unknown
+type statsFunc func(pathStr string) *columnStats
+
+//column is the bound column a predicate refers to
+type column struct {
+	Path      string
+	PathStr   string
+	Type      parquet.Type
+	Unsigned  bool
+	FuncTable common.FuncTable
+}
+
+func (c *column) bind(sh *schema.SchemaHandler) error {
+	pathStr, err := sh.ConvertToInPathStr(c.Path)
+	if err != nil {
+		return errors.Wrap(err, "sh.ConvertToInPathStr")
+	}
+	idx, ok := sh.MapIndex[pathStr]
+	if !ok {
+		return errors.Errorf("path %v not found", c.Path)
+	}
+	se := sh.SchemaElements[idx]
+	if se.GetNumChildren() > 0 || se.Type == nil {
+		return errors.Errorf("path %v is not a leaf column", c.Path)
+	}
+	c.PathStr = pathStr
+	c.Type = se.GetType()
+	c.Unsigned = isUnsigned(se)
+	c.FuncTable = common.FindFuncTable(se.Type, se.ConvertedType, se.LogicalType)
+	return nil
+}
+
+func (c *column) columnPaths(res []string) []string {
+	return append(res, c.PathStr)
+}
+
+func (c *column) less(a, b interface{}) bool {
+	return c.FuncTable.LessThan(a, b)
+}
+
+func (c *column) equal(a, b interface{}) bool {
+	return !c.less(a, b) && !c.less(b, a)
+}
+
+type cmpOp int
+
+const (
+	opEq cmpOp = iota
+	opLt
+	opGt
+)
+
+type cmpFilter struct {
+	column
+	Op    cmpOp
+	Value interface{}
+	value interface{}
+}
+
+//Eq matches rows where the column at path equals value
+func Eq(path string, value interface{}) Filter {
+	return &cmpFilter{column: column{Path: path}, Op: opEq, Value: value}
+}
+
+//Lt matches rows where the column at path is less than value
+func Lt(path string, value interface{}) Filter {
+	return &cmpFilter{column: column{Path: path}, Op: opLt, Value: value}
+}
+
+//Gt matches rows where the column at path is greater than value
+func Gt(path string, value interface{}) Filter {
+	return &cmpFilter{column: column{Path: path}, Op: opGt, Value: value}
+}
+
+func (f *cmpFilter) bind(sh *schema.SchemaHandler) error {
+	var err error
+	if err = f.column.bind(sh); err != nil {
+		return errors.Wrap(err, "f.column.bind")
+	}
+	if f.value, err = toParquetValue(f.Value, f.Type, f.Unsigned); err != nil {
+		return errors.Wrap(err, "toParquetValue")
+	}
+	return nil
+}
+
+func (f *cmpFilter) eval(stats statsFunc) (bool, bool) {
+	st := stats(f.PathStr)
+	if st == nil {
+		return true, false
+	}
+	if st.allNull() {
+		return false, false
+	}
+	if f.Op == opEq && !st.mightContain(f.value) {
+		return false, false
+	}
+	if !st.HasMinMax {
+		return true, false
+	}
+	noNulls := st.HasNullCount && st.NullCount == 0 && !st.Repeated
+	switch f.Op {
+	case opEq:
+		may := !f.less(f.value, st.Min) && !f.less(st.Max, f.value)
+		return may, noNulls && f.equal(st.Min, f.value) && f.equal(st.Max, f.value)
+	case opLt:
+		return f.less(st.Min, f.value), noNulls && f.less(st.Max, f.value)
+	case opGt:
+		return f.less(f.value, st.Max), noNulls && f.less(f.value, st.Min)
+	}
+	return true, false
+}
+
+type inFilter struct {
+	column
+	Values []interface{}
+	values []interface{}
+}
+
+//In matches rows where the column at path equals one of values
+func In(path string, values ...interface{}) Filter {
+	return &inFilter{column: column{Path: path}, Values: values}
+}
+
+func (f *inFilter) bind(sh *schema.SchemaHandler) error {
+	if err := f.column.bind(sh); err != nil {
+		return errors.Wrap(err, "f.column.bind")
+	}
+	f.values = make([]interface{}, len(f.Values))
+	for i, v := range f.Values {
+		var err error
+		if f.values[i], err = toParquetValue(v, f.Type, f.Unsigned); err != nil {
+			return errors.Wrap(err, "toParquetValue")
+		}
+	}
+	return nil
+}
+
+func (f *inFilter) eval(stats statsFunc) (bool, bool) {
+	st := stats(f.PathStr)
+	if st == nil {
+		return true, false
+	}
+	if st.allNull() {
+		return false, false
+	}
+	noNulls := st.HasNullCount && st.NullCount == 0 && !st.Repeated
+	may, all := false, false
+	for _, v := range f.values {
+		inRange := !st.HasMinMax || (!f.less(v, st.Min) && !f.less(st.Max, v))
+		if !may && inRange && st.mightContain(v) {
+			may = true
+		}
+		if st.HasMinMax && noNulls && f.equal(st.Min, v) && f.equal(st.Max, v) {
+			all = true
+		}
+	}
+	return may, all
+}
+
+type isNullFilter struct {
+	column
+}
+
+//IsNull matches rows where the column at path is null
+func IsNull(path string) Filter {
+	return &isNullFilter{column: column{Path: path}}
+}
+
+func (f *isNullFilter) eval(stats statsFunc) (bool, bool) {
+	st := stats(f.PathStr)
+	if st == nil || st.Repeated {
+		return true, false
+	}
+	if st.allNull() {
+		return true, true
+	}
+	if !st.HasNullCount {
+		return true, false
+	}
+	return st.NullCount > 0, false
+}
+
+type andFilter struct {
+	Filters []Filter
+}
+
+//And matches rows matched by all of filters
+func And(filters ...Filter) Filter {
+	return &andFilter{Filters: filters}
+}
+
+func (f *andFilter) bind(sh *schema.SchemaHandler) error {
+	for _, c := range f.Filters {
+		if err := c.bind(sh); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *andFilter) columnPaths(res []string) []string {
+	for _, c := range f.Filters {
+		res = c.columnPaths(res)
+	}
+	return res
+}
+
+func (f *andFilter) eval(stats statsFunc) (bool, bool) {
+	may, all := true, true
+	for _, c := range f.Filters {
+		m, a := c.eval(stats)
+		may, all = may && m, all && a
+	}
+	return may, all
+}
+
+type orFilter struct {
+	Filters []Filter
+}
+
+//Or matches rows matched by any of filters
+func Or(filters ...Filter) Filter {
+	return &orFilter{Filters: filters}
+}
+
+func (f *orFilter) bind(sh *schema.SchemaHandler) error {
+	for _, c := range f.Filters {
+		if err := c.bind(sh); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *orFilter) columnPaths(res []string) []string {
+	for _, c := range f.Filters {
+		res = c.columnPaths(res)
+	}
+	return res
+}
+
+func (f *orFilter) eval(stats statsFunc) (bool, bool) {
+	may, all := false, false
+	for _, c := range f.Filters {
+		m, a := c.eval(stats)
+		may, all = may || m, all || a
+	}
+	return may, all
+}
+
+type notFilter struct {
+	Filter Filter
+}
+
+//Not matches rows not matched by filter
+func Not(filter Filter) Filter {
+	return &notFilter{Filter: filter}
+}
+
+func (f *notFilter) bind(sh *schema.SchemaHandler) error {
+	return f.Filter.bind(sh)
+}
+
+func (f *notFilter) columnPaths(res []string) []string {
+	return f.Filter.columnPaths(res)
+}
+
+func (f *notFilter) eval(stats statsFunc) (bool, bool) {
+	may, all := f.Filter.eval(stats)
+	return !all, !may
+}
+
+//toParquetValue converts a filter literal to the go type used for values of the parquet type. The integers out of
+//the range of the column are errors, the unsigned ones are stored with the bits of the unsigned values.
+func toParquetValue(v interface{}, pT parquet.Type, unsigned bool) (interface{}, error) {
+	if v == nil {
+		return nil, errors.New("nil is not a valid filter value, use IsNull")
+	}
+	rv := reflect.ValueOf(v)
+	kind := rv.Kind()
+	isInt := kind >= reflect.Int && kind <= reflect.Int64
+	isUint := kind >= reflect.Uint && kind <= reflect.Uint64
+	isFloat := kind == reflect.Float32 || kind == reflect.Float64
+
+	switch pT {
+	case parquet.Type_BOOLEAN:
+		if kind == reflect.Bool {
+			return rv.Bool(), nil
+		}
+	case parquet.Type_INT32:
+		switch {
+		case isInt && unsigned && rv.Int() >= 0 && rv.Int() <= math.MaxUint32:
+			return int32(uint32(rv.Int())), nil
+		case isInt && !unsigned && rv.Int() >= math.MinInt32 && rv.Int() <= math.MaxInt32:
+			return int32(rv.Int()), nil
+		case isUint && unsigned && rv.Uint() <= math.MaxUint32:
+			return int32(uint32(rv.Uint())), nil
+		case isUint && !unsigned && rv.Uint() <= math.MaxInt32:
+			return int32(rv.Uint()), nil
+		case isInt || isUint:
+			return nil, errors.Errorf("filter value %v is out of the range of the parquet type %v", v, pT)
+		}
+	case parquet.Type_INT64:
+		switch {
+		case isInt && (!unsigned || rv.Int() >= 0):
+			return rv.Int(), nil
+		case isUint && (unsigned || rv.Uint() <= math.MaxInt64):
+			return int64(rv.Uint()), nil
+		case isInt || isUint:
+			return nil, errors.Errorf("filter value %v is out of the range of the parquet type %v", v, pT)
+		}
+	case parquet.Type_FLOAT:
+		if isFloat {
+			return float32(rv.Float()), nil
+		}
+	case parquet.Type_DOUBLE:
+		if isFloat {
+			return rv.Float(), nil
+		}
+	case parquet.Type_INT96, parquet.Type_BYTE_ARRAY, parquet.Type_FIXED_LEN_BYTE_ARRAY:
+		if kind == reflect.String {
+			return rv.String(), nil
+		} else if b, ok := v.([]byte); ok {
+			return string(b), nil
+		}
+	}
+	return nil, errors.Errorf("filter value %v (%T) doesn't match parquet type %v", v, v, pT)
+}
+
+//decodeStatValue decodes a plain encoded min/max value of the statistics
+func decodeStatValue(buf []byte, pT parquet.Type) (interface{}, bool) {
+	switch pT {
+	case parquet.Type_BOOLEAN:
+		if len(buf) < 1 {
+			return nil, false
+		}
+		return buf[0]&1 == 1, true
+	case parquet.Type_INT32:
+		if len(buf) < 4 {
+			return nil, false
+		}
+		return int32(binary.LittleEndian.Uint32(buf)), true
+	case parquet.Type_INT64:
+		if len(buf) < 8 {
+			return nil, false
+		}
+		return int64(binary.LittleEndian.Uint64(buf)), true
+	case parquet.Type_FLOAT:
+		if len(buf) < 4 {
+			return nil, false
+		}
+		return math.Float32frombits(binary.LittleEndian.Uint32(buf)), true
+	case parquet.Type_DOUBLE:
+		if len(buf) < 8 {
+			return nil, false
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(buf)), true
+	case parquet.Type_INT96, parquet.Type_BYTE_ARRAY, parquet.Type_FIXED_LEN_BYTE_ARRAY:
+		return string(buf), true
+	}
+	return nil, false
+}
+
+//signedSortOrder reports whether the deprecated min/max statistics can be trusted for the column
+func signedSortOrder(se *parquet.SchemaElement) bool {
+	switch se.GetType() {
+	case parquet.Type_BOOLEAN, parquet.Type_INT32, parquet.Type_INT64, parquet.Type_FLOAT, parquet.Type_DOUBLE:
+	default:
+		return false
+	}
+	return !isUnsigned(se)
+}
+
+//isUnsigned reports whether the column holds unsigned integers
+func isUnsigned(se *parquet.SchemaElement) bool {
+	if se.IsSetConvertedType() {
+		switch se.GetConvertedType() {
+		case parquet.ConvertedType_UINT_8, parquet.ConvertedType_UINT_16,
+			parquet.ConvertedType_UINT_32, parquet.ConvertedType_UINT_64:
+			return true
+		}
+	}
+	lt := se.GetLogicalType()
+	return lt != nil && lt.INTEGER != nil && !lt.INTEGER.IsSigned
+}
+
+//newColumnStats converts the statistics of a column chunk
+func newColumnStats(se *parquet.SchemaElement, statistics *parquet.Statistics, numValues int64, repeated bool) *columnStats {
+	st := &columnStats{NumValues: numValues, Repeated: repeated}
+	if statistics == nil {
+		return st
+	}
+	if statistics.IsSetNullCount() {
+		st.NullCount, st.HasNullCount = statistics.GetNullCount(), true
+	}
+
+	var minBuf, maxBuf []byte
+	if statistics.IsSetMinValue() && statistics.IsSetMaxValue() {
+		minBuf, maxBuf = statistics.MinValue, statistics.MaxValue
+	} else if statistics.IsSetMin() && statistics.IsSetMax() && signedSortOrder(se) {
+		minBuf, maxBuf = statistics.Min, statistics.Max
+	} else {
+		return st
+	}
+
+	var okMin, okMax bool
+	st.Min, okMin = decodeStatValue(minBuf, se.GetType())
+	st.Max, okMax = decodeStatValue(maxBuf, se.GetType())
+	st.HasMinMax = okMin && okMax
+	return st
+}
+
+//rowGroupStats returns the statistics of the column chunks in a row group
+func rowGroupStats(sh *schema.SchemaHandler, rowGroup *parquet.RowGroup) statsFunc {
+	chunks := make(map[string]*parquet.ColumnChunk)
+	for _, chunk := range rowGroup.GetColumns() {
+		if chunk.MetaData == nil {
+			continue
+		}
+		path := append([]string{sh.GetRootInName()}, chunk.MetaData.GetPathInSchema()...)
+		chunks[common.PathToStr(path)] = chunk
+	}
+
+	return func(pathStr string) *columnStats {
+		chunk, ok := chunks[pathStr]
+		se := sh.SchemaElements[sh.MapIndex[pathStr]]
+		//the statistics of the columns promoted to another type are ignored
+		if !ok || chunk.MetaData.GetType() != se.GetType() {
+			return nil
+		}
+		maxRL, _ := sh.MaxRepetitionLevel(common.StrToPath(pathStr))
+		return newColumnStats(se, chunk.MetaData.Statistics, chunk.MetaData.GetNumValues(), maxRL > 0)
+	}
+}
+
+//FilterRowGroups drops the row groups in the footer whose statistics or Bloom filters show that no row can match the filter
+func (pr *ParquetReader) FilterRowGroups() error {
+	if pr.Filter == nil {
+		return nil
+	}
+	if err := pr.Filter.bind(pr.SchemaHandler); err != nil {
+		return errors.Wrap(err, "pr.Filter.bind")
+	}
+
+	rowGroups := make([]*parquet.RowGroup, 0, len(pr.Footer.RowGroups))
+	numRows := int64(0)
+	for _, rowGroup := range pr.Footer.RowGroups {
+		var bloomFilterErr error
+		chunkStats := rowGroupStats(pr.SchemaHandler, rowGroup)
+		stats := func(pathStr string) *columnStats {
+			st := chunkStats(pathStr)
+			if st == nil {
+				return nil
+			}
+			st.BloomFilter = func() *bloomfilter.Filter {
+				bloomFilter, err := pr.readBloomFilter(rowGroup, pathStr)
+				if err != nil && bloomFilterErr == nil {
+					bloomFilterErr = err
+				}
+				return bloomFilter
+			}
+			return st
+		}
+
+		may, _ := pr.Filter.eval(stats)
+		if bloomFilterErr != nil {
+			return errors.Wrap(bloomFilterErr, "pr.readBloomFilter")
+		}
+		if !may {
+			continue
+		}
+		rowGroups = append(rowGroups, rowGroup)
+		numRows += rowGroup.GetNumRows()
+	}
+	pr.Footer.RowGroups = rowGroups
+	pr.Footer.NumRows = numRows
+	return nil
+}
+
+//RowRange is the rows [Start, End), counted over all the row groups of a reader
+type RowRange struct {
+	Start, End int64
+}
+
+//pageStats is the statistics of the pages of a column chunk
+type pageStats struct {
+	FirstRowIndexes []int64
+	Stats           []*columnStats
+}
+
+//at returns the statistics of the page holding the row
+func (ps *pageStats) at(row int64) *columnStats {
+	i := sort.Search(len(ps.FirstRowIndexes), func(i int) bool {
+		return ps.FirstRowIndexes[i] > row
+	}) - 1
+	if i < 0 {
+		return nil
+	}
+	return ps.Stats[i]
+}
+
+//newPageStats converts the page index of a column chunk
+func newPageStats(se *parquet.SchemaElement, columnIndex *parquet.ColumnIndex, offsetIndex *parquet.OffsetIndex, repeated bool) *pageStats {
+	locations := offsetIndex.GetPageLocations()
+	ln := len(locations)
+	if len(columnIndex.NullPages) != ln || len(columnIndex.MinValues) != ln || len(columnIndex.MaxValues) != ln {
+		return nil
+	}
+	hasNullCounts := len(columnIndex.NullCounts) == ln
+
+	ps := &pageStats{
+		FirstRowIndexes: make([]int64, ln),
+		Stats:           make([]*columnStats, ln),
+	}
+	for i, location := range locations {
+		st := &columnStats{Repeated: repeated}
+		if hasNullCounts {
+			st.NullCount, st.HasNullCount = columnIndex.NullCounts[i], true
+		}
+		if columnIndex.NullPages[i] {
+			st.AllNull = true
+		} else {
+			var okMin, okMax bool
+			st.Min, okMin = decodeStatValue(columnIndex.MinValues[i], se.GetType())
+			st.Max, okMax = decodeStatValue(columnIndex.MaxValues[i], se.GetType())
+			st.HasMinMax = okMin && okMax
+		}
+		ps.FirstRowIndexes[i] = location.FirstRowIndex
+		ps.Stats[i] = st
+	}
+	return ps
+}
+
+//readPageStats reads the page index of the chunks of the columns in a row group.
+//Chunks without a page index are left out.
+func (pr *ParquetReader) readPageStats(rowGroup *parquet.RowGroup, pathStrs []string) (map[string]*pageStats, error) {
+	sh := pr.SchemaHandler
+	wanted := make(map[string]bool)
+	for _, pathStr := range pathStrs {
+		wanted[pathStr] = true
+	}
+
+	res := make(map[string]*pageStats)
+	for _, chunk := range rowGroup.GetColumns() {
+		if chunk.MetaData == nil {
+			continue
+		}
+		path := append([]string{sh.GetRootInName()}, chunk.MetaData.GetPathInSchema()...)
+		pathStr := common.PathToStr(path)
+		if !wanted[pathStr] || !chunk.IsSetColumnIndexOffset() || !chunk.IsSetOffsetIndexOffset() {
+			continue
+		}
+		se := sh.SchemaElements[sh.MapIndex[pathStr]]
+		if chunk.MetaData.GetType() != se.GetType() {
+			continue
+		}
+
+		//the indexes of the encrypted columns without keys are ignored
+		chunkCipher, err := pr.chunkCipher(rowGroup, chunk)
+		if err != nil {
+			continue
+		}
+
+		pFile := pr.PFile
+		if chunk.FilePath != nil {
+			if pFile, err = pr.PFile.Open(*chunk.FilePath); err != nil {
+				return nil, errors.Wrap(err, "pr.PFile.Open")
+			}
+		}
+		columnIndex, err := layout.ReadColumnIndex(pFile, chunk, chunkCipher)
+		if err != nil {
+			return nil, errors.Wrap(err, "layout.ReadColumnIndex")
+		}
+		offsetIndex, err := layout.ReadOffsetIndex(pFile, chunk, chunkCipher)
+		if err != nil {
+			return nil, errors.Wrap(err, "layout.ReadOffsetIndex")
+		}
+		if chunk.FilePath != nil {
+			pFile.Close()
+		}
+
+		maxRL, _ := sh.MaxRepetitionLevel(path)
+		if !offsetIndexUsable(pr.Footer, offsetIndex, rowGroup.GetNumRows(), maxRL > 0) {
+			continue
+		}
+		if ps := newPageStats(se, columnIndex, offsetIndex, maxRL > 0); ps != nil {
+			res[pathStr] = ps
+		}
+	}
+	return res, nil
+}
+
+//FilterPages selects the rows of the pages whose statistics show that a row
+//may match the filter, using the ColumnIndex and OffsetIndex of the chunks.
+//Only the selected rows are read and the row groups without one are dropped.
+func (pr *ParquetReader) FilterPages() error {
+	if pr.Filter == nil {
+		return nil
+	}
+	pathStrs := pr.Filter.columnPaths(nil)
+
+	rowGroups := make([]*parquet.RowGroup, 0, len(pr.Footer.RowGroups))
+	rowRanges := make([]RowRange, 0)
+	var rowIndex, numRows int64
+	for _, rowGroup := range pr.Footer.RowGroups {
+		pages, err := pr.readPageStats(rowGroup, pathStrs)
+		if err != nil {
+			return errors.Wrap(err, "pr.readPageStats")
+		}
+
+		//the rows between two page boundaries of any column share the statistics
+		boundaries := []int64{0, rowGroup.GetNumRows()}
+		for _, ps := range pages {
+			boundaries = append(boundaries, ps.FirstRowIndexes...)
+		}
+		sort.Slice(boundaries, func(i, j int) bool { return boundaries[i] < boundaries[j] })
+
+		chunkStats := rowGroupStats(pr.SchemaHandler, rowGroup)
+		selected := int64(0)
+		for i := 0; i+1 < len(boundaries); i++ {
+			bgn, end := boundaries[i], boundaries[i+1]
+			if bgn >= end || end > rowGroup.GetNumRows() {
+				continue
+			}
+			stats := func(pathStr string) *columnStats {
+				if ps, ok := pages[pathStr]; ok {
+					return ps.at(bgn)
+				}
+				return chunkStats(pathStr)
+			}
+			if may, _ := pr.Filter.eval(stats); !may {
+				continue
+			}
+
+			if n := len(rowRanges); n > 0 && rowRanges[n-1].End == rowIndex+bgn {
+				rowRanges[n-1].End = rowIndex + end
+			} else {
+				rowRanges = append(rowRanges, RowRange{Start: rowIndex + bgn, End: rowIndex + end})
+			}
+			selected += end - bgn
+		}
+
+		if selected == 0 {
+			continue
+		}
+		rowGroups = append(rowGroups, rowGroup)
+		rowIndex += rowGroup.GetNumRows()
+		numRows += selected
+	}
+
+	pr.Footer.RowGroups = rowGroups
+	pr.Footer.NumRows = numRows
+	if numRows < rowIndex {
+		pr.RowRanges = rowRanges
+	}
+	return nil
+}
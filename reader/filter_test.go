@@ -0,0 +1,299 @@
+package reader
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/sabey/parquet-go-source/buffer"
+	"github.com/sabey/parquet-go/common"
+	"github.com/sabey/parquet-go/layout"
+	"github.com/sabey/parquet-go/parquet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterRowGroups(t *testing.T) {
+	buf := filterFile.write(t)
+
+	testData := []struct {
+		Filter       Filter
+		ExpectedRows int64
+	}{
+		{Eq("parquet_go_root\x01id", 15), 10},
+		{Eq("Parquet_go_root\x01ID", int32(15)), 10},
+		{Lt("parquet_go_root\x01id", 10), 10},
+		{Gt("parquet_go_root\x01id", 9), 30},
+		{Gt("parquet_go_root\x01id", 39), 0},
+		{In("parquet_go_root\x01name", "a", "d"), 20},
+		{IsNull("parquet_go_root\x01note"), 30},
+		{Not(IsNull("parquet_go_root\x01note")), 10},
+		{Not(Lt("parquet_go_root\x01id", 20)), 20},
+		{And(Gt("parquet_go_root\x01id", 5), Lt("parquet_go_root\x01id", 25)), 30},
+		{Or(Eq("parquet_go_root\x01id", 1), Eq("parquet_go_root\x01name", "d")), 20},
+	}
+
+	for _, data := range testData {
+		pr, err := NewParquetReader(buffer.NewBufferFileFromBytes(buf), new(pageRecord), 1, WithFilter(data.Filter))
+		assert.Nil(t, err)
+		assert.Equal(t, data.ExpectedRows, pr.GetNumRows())
+
+		recs := make([]pageRecord, pr.GetNumRows())
+		assert.Nil(t, pr.Read(&recs))
+		assert.Equal(t, int(data.ExpectedRows), len(recs))
+		pr.ReadStop()
+	}
+}
+
+func TestFilterBindError(t *testing.T) {
+	buf := filterFile.write(t)
+
+	_, err := NewParquetReader(buffer.NewBufferFileFromBytes(buf), new(pageRecord), 1, WithFilter(Eq("parquet_go_root\x01missing", 1)))
+	assert.NotNil(t, err)
+
+	_, err = NewParquetReader(buffer.NewBufferFileFromBytes(buf), new(pageRecord), 1, WithFilter(Eq("parquet_go_root\x01id", "x")))
+	assert.NotNil(t, err)
+}
+
+func TestToParquetValue(t *testing.T) {
+	testData := []struct {
+		Value    interface{}
+		Type     parquet.Type
+		Unsigned bool
+		Expected interface{}
+	}{
+		{int64(-5), parquet.Type_INT32, false, int32(-5)},
+		{uint8(5), parquet.Type_INT32, false, int32(5)},
+		{int64(1<<32 + 5), parquet.Type_INT32, false, nil},
+		{int64(math.MinInt32 - 1), parquet.Type_INT32, false, nil},
+		{uint32(math.MaxUint32), parquet.Type_INT32, false, nil},
+		{uint32(math.MaxUint32), parquet.Type_INT32, true, int32(-1)},
+		{int64(math.MaxUint32 + 1), parquet.Type_INT32, true, nil},
+		{-1, parquet.Type_INT32, true, nil},
+		{uint64(math.MaxInt64), parquet.Type_INT64, false, int64(math.MaxInt64)},
+		{uint64(math.MaxUint64), parquet.Type_INT64, false, nil},
+		{uint64(math.MaxUint64), parquet.Type_INT64, true, int64(-1)},
+		{-1, parquet.Type_INT64, true, nil},
+	}
+
+	for _, data := range testData {
+		v, err := toParquetValue(data.Value, data.Type, data.Unsigned)
+		if data.Expected == nil {
+			assert.NotNil(t, err, "%v %v", data.Value, data.Type)
+		} else {
+			assert.Nil(t, err)
+			assert.Equal(t, data.Expected, v)
+		}
+	}
+
+	//the filters don't wrap the values out of range
+	buf := filterFile.write(t)
+	_, err := NewParquetReader(buffer.NewBufferFileFromBytes(buf), new(pageRecord), 1, WithFilter(Lt("parquet_go_root\x01id", uint64(math.MaxUint64))))
+	assert.NotNil(t, err)
+}
+
+func TestSkipRowsWithPageIndex(t *testing.T) {
+	buf := pageFile.write(t)
+
+	for _, skip := range []int64{0, 1, 7, 100, 499, 500, 501, 733, 999} {
+		pr, err := NewParquetReader(buffer.NewBufferFileFromBytes(buf), new(pageRecord), 1)
+		assert.Nil(t, err)
+		assert.Nil(t, pr.SkipRows(skip))
+
+		recs := make([]pageRecord, 1000-skip)
+		assert.Nil(t, pr.Read(&recs))
+		for i, rec := range recs {
+			assert.Equal(t, newPageRecord(skip+int64(i)), rec)
+		}
+		pr.ReadStop()
+	}
+}
+
+func TestFilterPages(t *testing.T) {
+	buf := pageFile.write(t)
+
+	testData := []struct {
+		Filter Filter
+		IDs    []int64
+	}{
+		{Eq("parquet_go_root\x01id", 500), []int64{500}},
+		{Or(Lt("parquet_go_root\x01id", 3), Gt("parquet_go_root\x01id", 996)), []int64{0, 1, 2, 997, 998, 999}},
+		{And(Gt("parquet_go_root\x01id", 250), Lt("parquet_go_root\x01id", 260), Eq("parquet_go_root\x01name", "z")), []int64{259}},
+	}
+
+	for _, data := range testData {
+		pr, err := NewParquetReader(buffer.NewBufferFileFromBytes(buf), new(pageRecord), 1, WithFilter(data.Filter))
+		assert.Nil(t, err)
+		num := pr.GetNumRows()
+		assert.True(t, num < 1000)
+
+		recs := make([]pageRecord, num)
+		assert.Nil(t, pr.Read(&recs))
+		found := make(map[int64]bool)
+		for _, rec := range recs {
+			assert.Equal(t, newPageRecord(rec.ID), rec)
+			found[rec.ID] = true
+		}
+		for _, id := range data.IDs {
+			assert.True(t, found[id], "id %v", id)
+		}
+		pr.ReadStop()
+	}
+}
+
+//toLegacyFile rewrites the OffsetIndexes and the created_by of the footer of a file like the legacy writer:
+//the FirstRowIndex is the number of values before the page
+func toLegacyFile(t *testing.T, data []byte) []byte {
+
+	footerSize := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	footer := parquet.NewFileMetaData()
+	td := thrift.NewTDeserializer()
+	td.Protocol = thrift.NewTCompactProtocolFactory().GetProtocol(td.Transport)
+	assert.Nil(t, td.Read(context.TODO(), footer, data[len(data)-8-footerSize:len(data)-8]))
+
+	res := append([]byte{}, data[:len(data)-8-footerSize]...)
+	ts := thrift.NewTSerializer()
+	ts.Protocol = thrift.NewTCompactProtocolFactory().GetProtocol(ts.Transport)
+	for _, rowGroup := range footer.RowGroups {
+		for _, chunk := range rowGroup.Columns {
+			offsetIndex, err := layout.ReadOffsetIndex(buffer.NewBufferFileFromBytes(data), chunk, nil)
+			assert.Nil(t, err)
+			firstRowIndex := int64(0)
+			for _, location := range offsetIndex.PageLocations {
+				header := parquet.NewPageHeader()
+				assert.Nil(t, td.Read(context.TODO(), header, data[location.Offset:]))
+				location.FirstRowIndex = firstRowIndex
+				firstRowIndex += int64(header.DataPageHeader.NumValues)
+			}
+
+			offsetIndexBuf, err := ts.Write(context.TODO(), offsetIndex)
+			assert.Nil(t, err)
+			offset, size := int64(len(res)), int32(len(offsetIndexBuf))
+			chunk.OffsetIndexOffset, chunk.OffsetIndexLength = &offset, &size
+			res = append(res, offsetIndexBuf...)
+		}
+	}
+
+	createdBy := common.LegacyCreatedBy
+	footer.CreatedBy = &createdBy
+	footerBuf, err := ts.Write(context.TODO(), footer)
+	assert.Nil(t, err)
+	res = append(res, footerBuf...)
+	footerSizeBuf := make([]byte, 4)
+	binary.LittleEndian.PutUint32(footerSizeBuf, uint32(len(footerBuf)))
+	res = append(res, footerSizeBuf...)
+	return append(res, "PAR1"...)
+}
+
+func TestSkipRowsWithLegacyOffsetIndex(t *testing.T) {
+	buf := toLegacyFile(t, pageFile.write(t))
+
+	for _, skip := range []int64{0, 1, 13, 30, 50, 95, 99, 500, 733} {
+		pr, err := NewParquetReader(buffer.NewBufferFileFromBytes(buf), new(pageRecord), 1)
+		assert.Nil(t, err)
+		assert.Nil(t, pr.SkipRows(skip))
+
+		recs := make([]pageRecord, 1000-skip)
+		assert.Nil(t, pr.Read(&recs))
+		for i, rec := range recs {
+			assert.Equal(t, newPageRecord(skip+int64(i)), rec)
+		}
+		pr.ReadStop()
+	}
+
+	pr, err := NewParquetReader(buffer.NewBufferFileFromBytes(buf), new(pageRecord), 1,
+		WithFilter(Eq("parquet_go_root\x01items", 50)))
+	assert.Nil(t, err)
+	recs := make([]pageRecord, pr.GetNumRows())
+	assert.Nil(t, pr.Read(&recs))
+	found := false
+	for _, rec := range recs {
+		assert.Equal(t, newPageRecord(rec.ID), rec)
+		found = found || rec.ID == 50
+	}
+	assert.True(t, found)
+	pr.ReadStop()
+}
+
+func TestOffsetIndexUsable(t *testing.T) {
+	newOffsetIndex := func(firstRowIndexes ...int64) *parquet.OffsetIndex {
+		offsetIndex := parquet.NewOffsetIndex()
+		for _, firstRowIndex := range firstRowIndexes {
+			offsetIndex.PageLocations = append(offsetIndex.PageLocations, &parquet.PageLocation{FirstRowIndex: firstRowIndex})
+		}
+		return offsetIndex
+	}
+	createdBy, legacyCreatedBy := common.CreatedBy, common.LegacyCreatedBy
+	footer, legacyFooter := &parquet.FileMetaData{CreatedBy: &createdBy}, &parquet.FileMetaData{CreatedBy: &legacyCreatedBy}
+
+	testData := []struct {
+		Footer      *parquet.FileMetaData
+		OffsetIndex *parquet.OffsetIndex
+		Repeated    bool
+		Usable      bool
+	}{
+		{footer, newOffsetIndex(0, 16, 32), true, true},
+		{legacyFooter, newOffsetIndex(0, 16, 32), false, true},
+		{legacyFooter, newOffsetIndex(0, 16, 32), true, false},
+		{footer, newOffsetIndex(), false, false},
+		{footer, newOffsetIndex(1, 16, 32), false, false},
+		{footer, newOffsetIndex(0, 16, 16), false, false},
+		{footer, newOffsetIndex(0, 32, 16), false, false},
+		{footer, newOffsetIndex(0, 16, 40), false, false},
+	}
+
+	for i, data := range testData {
+		assert.Equal(t, data.Usable, offsetIndexUsable(data.Footer, data.OffsetIndex, 40, data.Repeated), "case %v", i)
+	}
+}
+
+type bloomRecord struct {
+	ID   int64  `parquet:"name=id, type=INT64, bloomfilter=true"`
+	Name string `parquet:"name=name, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY, bloomfilter=true"`
+}
+
+func TestBloomFilter(t *testing.T) {
+	//the ids of the row groups are interleaved, so the min/max statistics can't skip any
+	buf := testFile{
+		Schema: new(bloomRecord),
+		Record: func(i int) interface{} {
+			id := int64(i%100*4 + i/100)
+			return bloomRecord{ID: id, Name: fmt.Sprintf("name-%d", id)}
+		},
+		NumRows:      400,
+		RowGroupRows: 100,
+	}.write(t)
+
+	pr, err := NewParquetReader(buffer.NewBufferFileFromBytes(buf), new(bloomRecord), 1)
+	assert.Nil(t, err)
+	assert.True(t, pr.Footer.RowGroups[0].Columns[0].MetaData.IsSetBloomFilterLength())
+	ok, err := pr.MightContain("parquet_go_root\x01id", 17)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	ok, err = pr.MightContain("parquet_go_root\x01name", "name-1000")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	_, err = pr.MightContain("parquet_go_root\x01id", "x")
+	assert.NotNil(t, err)
+	pr.ReadStop()
+
+	testData := []struct {
+		Filter            Filter
+		ExpectedRowGroups int
+	}{
+		{Eq("parquet_go_root\x01id", 17), 1},
+		{Eq("parquet_go_root\x01id", 1000), 0},
+		{Eq("parquet_go_root\x01name", "name-18"), 1},
+		{In("parquet_go_root\x01id", 17, 18), 2},
+		{Not(Eq("parquet_go_root\x01id", 17)), 4},
+	}
+
+	for _, data := range testData {
+		pr, err := NewParquetReader(buffer.NewBufferFileFromBytes(buf), new(bloomRecord), 1, WithFilter(data.Filter))
+		assert.Nil(t, err)
+		assert.Equal(t, data.ExpectedRowGroups, len(pr.Footer.RowGroups))
+		pr.ReadStop()
+	}
+}
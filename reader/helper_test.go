@@ -0,0 +1,87 @@
+package reader
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sabey/parquet-go-source/writerfile"
+	"github.com/sabey/parquet-go/writer"
+	"github.com/stretchr/testify/assert"
+)
+
+//testFile is a file written by the tests
+type testFile struct {
+	//Schema of the writer, a pointer to a record
+	Schema interface{}
+	//Record returns the row i
+	Record  func(i int) interface{}
+	NumRows int
+	//Rows of the row groups, 0 for a single row group
+	RowGroupRows int
+	//Page size of the writer, 0 for the default
+	PageSize int64
+}
+
+//write writes the rows of the file with the options of the writer
+func (f testFile) write(t *testing.T, opts ...writer.WriterOption) []byte {
+	buf := new(bytes.Buffer)
+	pw, err := writer.NewParquetWriter(writerfile.NewWriterFile(buf), f.Schema, 1, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.PageSize > 0 {
+		pw.PageSize = f.PageSize
+	}
+	for i := 0; i < f.NumRows; i++ {
+		assert.Nil(t, pw.Write(f.Record(i)))
+		if f.RowGroupRows > 0 && (i+1)%f.RowGroupRows == 0 {
+			assert.Nil(t, pw.Flush(true))
+		}
+	}
+	assert.Nil(t, pw.WriteStop())
+	return buf.Bytes()
+}
+
+type pageRecord struct {
+	ID    int64   `parquet:"name=id, type=INT64"`
+	Name  string  `parquet:"name=name, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Note  *string `parquet:"name=note, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Items []int32 `parquet:"name=items, type=INT32, repetitiontype=REPEATED"`
+}
+
+func newPageRecord(id int64) pageRecord {
+	rec := pageRecord{ID: id, Name: string(rune('a' + id%26))}
+	if id%3 == 0 {
+		note := "note"
+		rec.Note = &note
+	}
+	for i := int64(0); i < id%4; i++ {
+		rec.Items = append(rec.Items, int32(id))
+	}
+	return rec
+}
+
+//pageFile has 2 row groups of 500 rows in small pages, ids 0-999
+var pageFile = testFile{
+	Schema:       new(pageRecord),
+	Record:       func(i int) interface{} { return newPageRecord(int64(i)) },
+	NumRows:      1000,
+	RowGroupRows: 500,
+	PageSize:     64,
+}
+
+//filterFile has 4 row groups of 10 rows, ids 0-39; the names are the letters of the row groups and the notes
+//are only set in the last row group
+var filterFile = testFile{
+	Schema: new(pageRecord),
+	Record: func(i int) interface{} {
+		rec := pageRecord{ID: int64(i), Name: string(rune('a' + i/10))}
+		if i >= 30 {
+			note := "note"
+			rec.Note = &note
+		}
+		return rec
+	},
+	NumRows:      40,
+	RowGroupRows: 10,
+}
@@ -0,0 +1,93 @@
+package reader
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+//DefaultIteratorBatchSize is the number of rows read at a time by a RowIterator with a batch size of 0
+const DefaultIteratorBatchSize = 128
+
+//RowIterator reads the rows of a file one by one. The rows are read in batches of BatchSize rows,
+//the column buffers only decode the pages holding them, so the memory doesn't grow with the number of rows.
+type RowIterator struct {
+	pr        *ParquetReader
+	ctx       context.Context
+	batchSize int
+
+	batch reflect.Value
+	index int
+	err   error
+}
+
+//RowIterator returns an iterator over the rows which are not read yet
+func (pr *ParquetReader) RowIterator(batchSize int) *RowIterator {
+	return pr.RowIteratorContext(context.Background(), batchSize)
+}
+
+//RowIteratorContext returns an iterator over the rows which are not read yet, the reads are
+//cancelled when ctx is done
+func (pr *ParquetReader) RowIteratorContext(ctx context.Context, batchSize int) *RowIterator {
+	if batchSize <= 0 {
+		batchSize = DefaultIteratorBatchSize
+	}
+	return &RowIterator{pr: pr, ctx: ctx, batchSize: batchSize}
+}
+
+//Next moves to the next row, it returns false at the end of the file or at the first error
+func (it *RowIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if it.batch.IsValid() && it.index+1 < it.batch.Len() {
+		it.index++
+		return true
+	}
+
+	pr := it.pr
+	if pr.ObjType == nil {
+		if pr.ObjType, it.err = pr.SchemaHandler.GetType(pr.SchemaHandler.GetRootInName()); it.err != nil {
+			it.err = errors.Wrap(it.err, "pr.SchemaHandler.GetType")
+			return false
+		}
+	}
+
+	//a new slice is read for each batch, the rows of the previous batch may still be referenced
+	batch := reflect.New(reflect.SliceOf(pr.ObjType))
+	batch.Elem().Set(reflect.MakeSlice(reflect.SliceOf(pr.ObjType), it.batchSize, it.batchSize))
+	if err := pr.ReadContext(it.ctx, batch.Interface()); err != nil {
+		it.err = errors.Wrap(err, "pr.ReadContext")
+		return false
+	}
+	it.batch, it.index = batch.Elem(), 0
+	return it.batch.Len() > 0
+}
+
+//Scan stores the current row in dst, which must be a pointer to the type of the rows
+func (it *RowIterator) Scan(dst interface{}) error {
+	if !it.batch.IsValid() || it.index >= it.batch.Len() {
+		return errors.New("no current row")
+	}
+	dstValue := reflect.ValueOf(dst)
+	row := it.batch.Index(it.index)
+	if dstValue.Kind() != reflect.Ptr || dstValue.IsNil() || dstValue.Elem().Type() != row.Type() {
+		return errors.Errorf("dst must be a pointer to %v", row.Type())
+	}
+	dstValue.Elem().Set(row)
+	return nil
+}
+
+//Row returns the current row
+func (it *RowIterator) Row() interface{} {
+	if !it.batch.IsValid() || it.index >= it.batch.Len() {
+		return nil
+	}
+	return it.batch.Index(it.index).Interface()
+}
+
+//Err returns the error which stopped the iteration, nil at the end of the file
+func (it *RowIterator) Err() error {
+	return it.err
+}
@@ -0,0 +1,52 @@
+package reader
+
+import (
+	"testing"
+
+	"github.com/sabey/parquet-go-source/buffer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRowIterator(t *testing.T) {
+	buf := pageFile.write(t)
+
+	for _, batchSize := range []int{0, 1, 7, 500, 2000} {
+		pr, err := NewParquetReader(buffer.NewBufferFileFromBytes(buf), new(pageRecord), 2)
+		assert.Nil(t, err)
+		assert.Nil(t, pr.SkipRows(10))
+
+		it := pr.RowIterator(batchSize)
+		id := int64(10)
+		for it.Next() {
+			var rec pageRecord
+			assert.Nil(t, it.Scan(&rec))
+			assert.Equal(t, newPageRecord(id), rec)
+			assert.Equal(t, rec, it.Row())
+			id++
+		}
+		assert.Nil(t, it.Err())
+		assert.Equal(t, int64(1000), id)
+		assert.False(t, it.Next())
+		pr.ReadStop()
+	}
+}
+
+func TestRowIteratorFilter(t *testing.T) {
+	buf := pageFile.write(t)
+
+	pr, err := NewParquetReader(buffer.NewBufferFileFromBytes(buf), new(pageRecord), 1, WithFilter(Gt("parquet_go_root\x01id", 900)))
+	assert.Nil(t, err)
+	it := pr.RowIterator(16)
+	var num int64
+	for it.Next() {
+		var rec pageRecord
+		var wrong bloomRecord
+		assert.Nil(t, it.Scan(&rec))
+		assert.NotNil(t, it.Scan(&wrong))
+		assert.NotNil(t, it.Scan(rec))
+		num++
+	}
+	assert.Nil(t, it.Err())
+	assert.Equal(t, pr.GetNumRows(), num)
+	pr.ReadStop()
+}
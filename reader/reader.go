@@ -11,6 +11,7 @@ import (
 	"github.com/apache/thrift/lib/go/thrift"
 	"github.com/pkg/errors"
 	"github.com/sabey/parquet-go/common"
+	"github.com/sabey/parquet-go/encryption"
 	"github.com/sabey/parquet-go/layout"
 	"github.com/sabey/parquet-go/marshal"
 	"github.com/sabey/parquet-go/parquet"
@@ -29,14 +30,69 @@ type ParquetReader struct {
 	//One reader can only read one type objects
 	ObjType        reflect.Type
 	ObjPartialType reflect.Type
+
+	//Infer the schema of the struct object from its Go types, see schema.NewSchemaHandlerFromStructInferred
+	InferSchema bool
+	//Match the columns of the schema with the columns of the file by their field_id instead of their names
+	ResolveByFieldID bool
+
+	//Row groups and pages which can't match the filter are skipped
+	Filter Filter
+	//Rows selected by the filter, nil means all rows
+	RowRanges []RowRange
+
+	//Properties to read encrypted files, the FileDecryptor is set if the file is encrypted
+	DecryptionProperties *encryption.FileDecryptionProperties
+	FileDecryptor        *encryption.FileDecryptor
+
+	//Columns of the file matched with the schema, see resolveSchema
+	resolution *schemaResolution
+
+	//Workers of the reads, a cancelled read may return before they stop
+	workers sync.WaitGroup
+}
+
+//ReaderOption configures a parquet reader when it is created
+type ReaderOption func(*ParquetReader)
+
+//WithFilter skips the row groups and pages whose statistics show that no row can match filter
+func WithFilter(filter Filter) ReaderOption {
+	return func(pr *ParquetReader) {
+		pr.Filter = filter
+	}
+}
+
+//WithDecryption reads encrypted files with the properties
+func WithDecryption(properties *encryption.FileDecryptionProperties) ReaderOption {
+	return func(pr *ParquetReader) {
+		pr.DecryptionProperties = properties
+	}
+}
+
+//WithSchemaInference infers the schema of the struct object from its Go types, the parquet tags only override it
+func WithSchemaInference() ReaderOption {
+	return func(pr *ParquetReader) {
+		pr.InferSchema = true
+	}
+}
+
+//WithFieldIDResolution matches the columns of the schema with the columns of the file by their field_id,
+//so the renamed columns are still read. The elements without a field_id are matched by their names.
+func WithFieldIDResolution() ReaderOption {
+	return func(pr *ParquetReader) {
+		pr.ResolveByFieldID = true
+	}
 }
 
 //Create a parquet reader: obj is a object with schema tags or a JSON schema string
-func NewParquetReader(pFile source.ParquetFile, obj interface{}, np int64) (*ParquetReader, error) {
+func NewParquetReader(pFile source.ParquetFile, obj interface{}, np int64, opts ...ReaderOption) (*ParquetReader, error) {
 	var err error
 	res := new(ParquetReader)
 	res.NP = np
 	res.PFile = pFile
+	for _, opt := range opts {
+		opt(res)
+	}
 	if err = res.ReadFooter(); err != nil {
 		return nil, errors.Wrap(err, "res.ReadFooter")
 	}
@@ -51,7 +107,12 @@ func NewParquetReader(pFile source.ParquetFile, obj interface{}, np int64) (*Par
 			res.SchemaHandler = schema.NewSchemaHandlerFromSchemaList(sa)
 
 		} else {
-			if res.SchemaHandler, err = schema.NewSchemaHandlerFromStruct(obj); err != nil {
+			if res.InferSchema {
+				res.SchemaHandler, err = schema.NewSchemaHandlerFromStructInferred(obj)
+			} else {
+				res.SchemaHandler, err = schema.NewSchemaHandlerFromStruct(obj)
+			}
+			if err != nil {
 				return res, errors.Wrap(err, "schema.NewSchemaHandlerFromStruct")
 			}
 
@@ -62,13 +123,22 @@ func NewParquetReader(pFile source.ParquetFile, obj interface{}, np int64) (*Par
 		res.SchemaHandler = schema.NewSchemaHandlerFromSchemaList(res.Footer.Schema)
 	}
 
+	if err = res.resolveSchema(); err != nil {
+		return res, errors.Wrap(err, "res.resolveSchema")
+	}
 	res.RenameSchema()
+	if err = res.FilterRowGroups(); err != nil {
+		return res, errors.Wrap(err, "res.FilterRowGroups")
+	}
+	if err = res.FilterPages(); err != nil {
+		return res, errors.Wrap(err, "res.FilterPages")
+	}
 	for i := 0; i < len(res.SchemaHandler.SchemaElements); i++ {
 		schema := res.SchemaHandler.SchemaElements[i]
 		if schema.GetNumChildren() == 0 {
 			pathStr := res.SchemaHandler.IndexMap[int32(i)]
-			if res.ColumnBuffers[pathStr], err = NewColumnBuffer(pFile, res.Footer, res.SchemaHandler, pathStr); err != nil {
-				return res, errors.Wrap(err, "NewColumnBuffer")
+			if res.ColumnBuffers[pathStr], err = res.newColumnBuffer(pathStr); err != nil {
+				return res, errors.Wrap(err, "newColumnBuffer")
 			}
 		}
 	}
@@ -76,6 +146,15 @@ func NewParquetReader(pFile source.ParquetFile, obj interface{}, np int64) (*Par
 	return res, nil
 }
 
+//newColumnBuffer creates the buffer of a column, which reads the rows selected by the filter
+func (pr *ParquetReader) newColumnBuffer(pathStr string) (*ColumnBufferType, error) {
+	if pr.resolution == nil {
+		return openColumnBuffer(pr.PFile, pr.Footer, pr.SchemaHandler, pathStr, pr.FileDecryptor, pr.RowRanges, nil)
+	}
+	return openColumnBuffer(pr.PFile, pr.Footer, pr.resolution.readSchemaHandler, pathStr, pr.FileDecryptor, pr.RowRanges,
+		pr.resolution.evolutions[pathStr])
+}
+
 func (pr *ParquetReader) SetSchemaHandlerFromJSON(jsonSchema string) error {
 	var err error
 
@@ -83,32 +162,51 @@ func (pr *ParquetReader) SetSchemaHandlerFromJSON(jsonSchema string) error {
 		return errors.Wrap(err, "schema.NewSchemaHandlerFromJSON")
 	}
 
+	if err = pr.resolveSchema(); err != nil {
+		return errors.Wrap(err, "pr.resolveSchema")
+	}
 	pr.RenameSchema()
+	if err = pr.FilterRowGroups(); err != nil {
+		return errors.Wrap(err, "pr.FilterRowGroups")
+	}
+	if err = pr.FilterPages(); err != nil {
+		return errors.Wrap(err, "pr.FilterPages")
+	}
 	for i := 0; i < len(pr.SchemaHandler.SchemaElements); i++ {
 		schemaElement := pr.SchemaHandler.SchemaElements[i]
 		if schemaElement.GetNumChildren() == 0 {
 			pathStr := pr.SchemaHandler.IndexMap[int32(i)]
-			if pr.ColumnBuffers[pathStr], err = NewColumnBuffer(pr.PFile, pr.Footer, pr.SchemaHandler, pathStr); err != nil {
-				return errors.Wrap(err, "NewColumnBuffer")
+			if pr.ColumnBuffers[pathStr], err = pr.newColumnBuffer(pathStr); err != nil {
+				return errors.Wrap(err, "newColumnBuffer")
 			}
 		}
 	}
 	return nil
 }
 
-//Rename schema name to inname
+//Rename schema name to inname, the elements and the columns of the file are matched by resolveSchema.
+//The columns of the file which aren't in the schema get an empty path.
 func (pr *ParquetReader) RenameSchema() {
 	for i := 0; i < len(pr.SchemaHandler.Infos); i++ {
-		pr.Footer.Schema[i].Name = pr.SchemaHandler.Infos[i].InName
+		j := int32(i)
+		if pr.resolution != nil {
+			j = pr.resolution.fileIndexes[i]
+		}
+		if j >= 0 && int(j) < len(pr.Footer.Schema) {
+			pr.Footer.Schema[j].Name = pr.SchemaHandler.Infos[i].InName
+		}
 	}
 	for _, rowGroup := range pr.Footer.RowGroups {
 		for _, chunk := range rowGroup.Columns {
-			exPath := make([]string, 0)
-			exPath = append(exPath, pr.SchemaHandler.GetRootExName())
-			exPath = append(exPath, chunk.MetaData.GetPathInSchema()...)
-			exPathStr := common.PathToStr(exPath)
-
-			inPathStr := pr.SchemaHandler.ExPathToInPath[exPathStr]
+			var inPathStr string
+			if pr.resolution != nil {
+				inPathStr = pr.resolution.inPaths[common.PathToStr(chunk.MetaData.GetPathInSchema())]
+			} else {
+				exPath := make([]string, 0)
+				exPath = append(exPath, pr.SchemaHandler.GetRootExName())
+				exPath = append(exPath, chunk.MetaData.GetPathInSchema()...)
+				inPathStr = pr.SchemaHandler.ExPathToInPath[common.PathToStr(exPath)]
+			}
 			inPath := common.StrToPath(inPathStr)[1:]
 			chunk.MetaData.PathInSchema = inPath
 		}
@@ -133,52 +231,96 @@ func (pr *ParquetReader) GetFooterSize() (uint32, error) {
 	return size, nil
 }
 
-//Read footer from parquet file
+//Read footer from parquet file, it's decrypted if the file is encrypted
 func (pr *ParquetReader) ReadFooter() error {
 	size, err := pr.GetFooterSize()
 	if err != nil {
 		return errors.Wrap(err, "pr.GetFooterSize")
 	}
+	magic := make([]byte, 4)
+	if _, err = pr.PFile.Seek(-4, io.SeekEnd); err != nil {
+		return errors.Wrap(err, "pr.PFile.Seek")
+	}
+	if _, err = io.ReadFull(pr.PFile, magic); err != nil {
+		return errors.Wrap(err, "io.ReadFull")
+	}
 	if _, err = pr.PFile.Seek(-(int64)(8+size), io.SeekEnd); err != nil {
 		return errors.Wrap(err, "pr.PFile.Seek")
 	}
-	pr.Footer = parquet.NewFileMetaData()
-	pf := thrift.NewTCompactProtocolFactory()
-	protocol := pf.GetProtocol(thrift.NewStreamTransportR(pr.PFile))
-	if err := pr.Footer.Read(context.TODO(), protocol); err != nil {
-		return errors.Wrap(err, "pr.Footer.Read")
+	buf := make([]byte, size)
+	if _, err = io.ReadFull(pr.PFile, buf); err != nil {
+		return errors.Wrap(err, "io.ReadFull")
+	}
+
+	if string(magic) == "PARE" {
+		if pr.FileDecryptor, pr.Footer, err = encryption.DecryptFooter(pr.DecryptionProperties, buf); err != nil {
+			return errors.Wrap(err, "encryption.DecryptFooter")
+		}
+
+	} else {
+		transport := thrift.NewTMemoryBufferLen(len(buf))
+		transport.Write(buf)
+		pr.Footer = parquet.NewFileMetaData()
+		if err = pr.Footer.Read(context.TODO(), thrift.NewTCompactProtocol(transport)); err != nil {
+			return errors.Wrap(err, "pr.Footer.Read")
+		}
+		if pr.Footer.IsSetEncryptionAlgorithm() {
+			if err = pr.verifyFooter(buf[:len(buf)-transport.Len()], transport.Bytes()); err != nil {
+				return errors.Wrap(err, "pr.verifyFooter")
+			}
+		}
+	}
+
+	if pr.FileDecryptor != nil {
+		if err = pr.decryptColumnMetaData(); err != nil {
+			return errors.Wrap(err, "pr.decryptColumnMetaData")
+		}
 	}
 	return nil
 }
 
 //Skip rows of parquet file
 func (pr *ParquetReader) SkipRows(num int64) error {
+	return pr.SkipRowsContext(context.Background(), num)
+}
+
+//Skip rows of parquet file, it returns ctx.Err() when ctx is done before the rows are skipped.
+//The reader can't be used after the skip is cancelled.
+func (pr *ParquetReader) SkipRowsContext(ctx context.Context, num int64) error {
 	var err error
 	if num <= 0 {
 		return nil
 	}
-	doneChan := make(chan int, pr.NP)
+	if err = ctx.Err(); err != nil {
+		return errors.Wrap(err, "ctx.Err")
+	}
+	doneChan := make(chan error, len(pr.SchemaHandler.ValueColumns))
 	taskChan := make(chan string, len(pr.SchemaHandler.ValueColumns))
-	stopChan := make(chan int)
+	stopChan := make(chan struct{})
+	defer close(stopChan)
+	workerCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
 	for _, pathStr := range pr.SchemaHandler.ValueColumns {
 		if _, ok := pr.ColumnBuffers[pathStr]; !ok {
-			if pr.ColumnBuffers[pathStr], err = NewColumnBuffer(pr.PFile, pr.Footer, pr.SchemaHandler, pathStr); err != nil {
-				return errors.Wrap(err, "NewColumnBuffer")
+			if pr.ColumnBuffers[pathStr], err = pr.newColumnBuffer(pathStr); err != nil {
+				return errors.Wrap(err, "newColumnBuffer")
 			}
 		}
 	}
 
 	for i := int64(0); i < pr.NP; i++ {
+		pr.workers.Add(1)
 		go func() {
+			defer pr.workers.Done()
 			for {
 				select {
 				case <-stopChan:
 					return
 				case pathStr := <-taskChan:
 					cb := pr.ColumnBuffers[pathStr]
-					cb.SkipRows(int64(num))
-					doneChan <- 0
+					_, err := cb.SkipRowsContext(workerCtx, int64(num))
+					doneChan <- err
 				}
 			}
 		}()
@@ -188,18 +330,48 @@ func (pr *ParquetReader) SkipRows(num int64) error {
 		taskChan <- key
 	}
 
-	for i := 0; i < len(pr.ColumnBuffers); i++ {
-		<-doneChan
+	if err = waitColumns(ctx, cancel, doneChan, len(pr.ColumnBuffers)); err != nil {
+		return errors.Wrap(err, "waitColumns")
 	}
-	for i := int64(0); i < pr.NP; i++ {
-		stopChan <- 0
+	return nil
+}
+
+//waitColumns waits for num columns to be done by the workers. At the first error of a column
+//the other columns are cancelled, and the errors of all the columns are returned as ColumnErrors.
+//It returns ctx.Err() as soon as ctx is done.
+func waitColumns(ctx context.Context, cancel context.CancelFunc, doneChan <-chan error, num int) error {
+	var errs ColumnErrors
+	for i := 0; i < num; i++ {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-doneChan:
+			if err == nil || (len(errs) > 0 && errors.Is(err, context.Canceled)) {
+				continue
+			}
+			columnErr, ok := err.(*ColumnError)
+			if !ok {
+				columnErr = &ColumnError{PageOffset: -1, Err: err}
+			}
+			errs = append(errs, columnErr)
+			cancel()
+		}
+	}
+	if len(errs) > 0 {
+		return errs
 	}
 	return nil
 }
 
 //Read rows of parquet file and unmarshal all to dst
 func (pr *ParquetReader) Read(dstInterface interface{}) error {
-	if err := pr.read(dstInterface, ""); err != nil {
+	return pr.ReadContext(context.Background(), dstInterface)
+}
+
+//Read rows of parquet file and unmarshal all to dst, it returns ctx.Err() when ctx is done
+//before the rows are read. The reader can't be used after the read is cancelled.
+func (pr *ParquetReader) ReadContext(ctx context.Context, dstInterface interface{}) error {
+	if err := pr.read(ctx, dstInterface, ""); err != nil {
 		return errors.Wrap(err, "pr.read")
 	}
 	return nil
@@ -207,6 +379,11 @@ func (pr *ParquetReader) Read(dstInterface interface{}) error {
 
 // Read maxReadNumber objects
 func (pr *ParquetReader) ReadByNumber(maxReadNumber int) ([]interface{}, error) {
+	return pr.ReadByNumberContext(context.Background(), maxReadNumber)
+}
+
+// Read maxReadNumber objects like ReadContext
+func (pr *ParquetReader) ReadByNumberContext(ctx context.Context, maxReadNumber int) ([]interface{}, error) {
 	var err error
 	if pr.ObjType == nil {
 		if pr.ObjType, err = pr.SchemaHandler.GetType(pr.SchemaHandler.GetRootInName()); err != nil {
@@ -218,8 +395,8 @@ func (pr *ParquetReader) ReadByNumber(maxReadNumber int) ([]interface{}, error)
 	res := reflect.New(vs.Type())
 	res.Elem().Set(vs)
 
-	if err = pr.Read(res.Interface()); err != nil {
-		return nil, errors.Wrap(err, "pr.Read")
+	if err = pr.ReadContext(ctx, res.Interface()); err != nil {
+		return nil, errors.Wrap(err, "pr.ReadContext")
 	}
 
 	ln := res.Elem().Len()
@@ -233,12 +410,17 @@ func (pr *ParquetReader) ReadByNumber(maxReadNumber int) ([]interface{}, error)
 
 //Read rows of parquet file and unmarshal all to dst
 func (pr *ParquetReader) ReadPartial(dstInterface interface{}, prefixPath string) error {
+	return pr.ReadPartialContext(context.Background(), dstInterface, prefixPath)
+}
+
+//Read rows of parquet file and unmarshal all to dst like ReadContext
+func (pr *ParquetReader) ReadPartialContext(ctx context.Context, dstInterface interface{}, prefixPath string) error {
 	prefixPath, err := pr.SchemaHandler.ConvertToInPathStr(prefixPath)
 	if err != nil {
 		return errors.Wrap(err, "pr.SchemaHandler.ConvertToInPathStr")
 	}
 
-	if err := pr.read(dstInterface, prefixPath); err != nil {
+	if err := pr.read(ctx, dstInterface, prefixPath); err != nil {
 		return errors.Wrap(err, "pr.read")
 	}
 	return nil
@@ -246,6 +428,11 @@ func (pr *ParquetReader) ReadPartial(dstInterface interface{}, prefixPath string
 
 // Read maxReadNumber partial objects
 func (pr *ParquetReader) ReadPartialByNumber(maxReadNumber int, prefixPath string) ([]interface{}, error) {
+	return pr.ReadPartialByNumberContext(context.Background(), maxReadNumber, prefixPath)
+}
+
+// Read maxReadNumber partial objects like ReadContext
+func (pr *ParquetReader) ReadPartialByNumberContext(ctx context.Context, maxReadNumber int, prefixPath string) ([]interface{}, error) {
 	var err error
 	if pr.ObjPartialType == nil {
 		if pr.ObjPartialType, err = pr.SchemaHandler.GetType(prefixPath); err != nil {
@@ -257,8 +444,8 @@ func (pr *ParquetReader) ReadPartialByNumber(maxReadNumber int, prefixPath strin
 	res := reflect.New(vs.Type())
 	res.Elem().Set(vs)
 
-	if err = pr.ReadPartial(res.Interface(), prefixPath); err != nil {
-		return nil, errors.Wrap(err, "pr.ReadPartial")
+	if err = pr.ReadPartialContext(ctx, res.Interface(), prefixPath); err != nil {
+		return nil, errors.Wrap(err, "pr.ReadPartialContext")
 	}
 
 	ln := res.Elem().Len()
@@ -271,7 +458,7 @@ func (pr *ParquetReader) ReadPartialByNumber(maxReadNumber int, prefixPath strin
 }
 
 //Read rows of parquet file with a prefixPath
-func (pr *ParquetReader) read(dstInterface interface{}, prefixPath string) error {
+func (pr *ParquetReader) read(ctx context.Context, dstInterface interface{}, prefixPath string) error {
 	var err error
 	tmap := make(map[string]*layout.Table)
 	locker := new(sync.Mutex)
@@ -280,20 +467,32 @@ func (pr *ParquetReader) read(dstInterface interface{}, prefixPath string) error
 	if num <= 0 {
 		return nil
 	}
+	if err = ctx.Err(); err != nil {
+		return errors.Wrap(err, "ctx.Err")
+	}
 
-	doneChan := make(chan int, pr.NP)
+	doneChan := make(chan error, len(pr.ColumnBuffers))
 	taskChan := make(chan string, len(pr.ColumnBuffers))
-	stopChan := make(chan int)
+	stopChan := make(chan struct{})
+	defer close(stopChan)
+	workerCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
 	for i := int64(0); i < pr.NP; i++ {
+		pr.workers.Add(1)
 		go func() {
+			defer pr.workers.Done()
 			for {
 				select {
 				case <-stopChan:
 					return
 				case pathStr := <-taskChan:
 					cb := pr.ColumnBuffers[pathStr]
-					table, _ := cb.ReadRows(int64(num))
+					table, _, err := cb.ReadRowsContext(workerCtx, int64(num))
+					if err != nil {
+						doneChan <- err
+						continue
+					}
 					locker.Lock()
 					if _, ok := tmap[pathStr]; ok {
 						tmap[pathStr].Merge(table)
@@ -302,7 +501,7 @@ func (pr *ParquetReader) read(dstInterface interface{}, prefixPath string) error
 						tmap[pathStr].Merge(table)
 					}
 					locker.Unlock()
-					doneChan <- 0
+					doneChan <- nil
 				}
 			}
 		}()
@@ -315,18 +514,15 @@ func (pr *ParquetReader) read(dstInterface interface{}, prefixPath string) error
 			readNum++
 		}
 	}
-	for i := 0; i < readNum; i++ {
-		<-doneChan
-	}
-
-	for i := int64(0); i < pr.NP; i++ {
-		stopChan <- 0
+	if err = waitColumns(ctx, cancel, doneChan, readNum); err != nil {
+		return errors.Wrap(err, "waitColumns")
 	}
 
 	dstList := make([]interface{}, pr.NP)
 	delta := (int64(num) + pr.NP - 1) / pr.NP
 
 	var wg sync.WaitGroup
+	errs := make([]error, pr.NP)
 	for c := int64(0); c < pr.NP; c++ {
 		bgn := c * delta
 		end := bgn + delta
@@ -344,27 +540,35 @@ func (pr *ParquetReader) read(dstInterface interface{}, prefixPath string) error
 
 			dstList[index] = reflect.New(reflect.SliceOf(ot)).Interface()
 			if err2 := marshal.Unmarshal(&tmap, b, e, dstList[index], pr.SchemaHandler, prefixPath); err2 != nil {
-				err = errors.Wrap(err2, "marshal.Unmarshal")
+				errs[index] = errors.Wrap(err2, "marshal.Unmarshal")
 			}
 		}(int(bgn), int(end), int(c))
 	}
 
 	wg.Wait()
 
+	for _, err2 := range errs {
+		if err2 != nil {
+			return err2
+		}
+	}
+
 	dstValue := reflect.ValueOf(dstInterface).Elem()
 	dstValue.SetLen(0)
 	for _, dst := range dstList {
 		dstValue.Set(reflect.AppendSlice(dstValue, reflect.ValueOf(dst).Elem()))
 	}
 
-	return err
+	return nil
 }
 
-//Stop Read
+//Stop Read. The files of the columns are closed before waiting for the workers of a cancelled read,
+//which unblocks their pending reads.
 func (pr *ParquetReader) ReadStop() {
 	for _, cb := range pr.ColumnBuffers {
 		if cb != nil {
 			cb.PFile.Close()
 		}
 	}
+	pr.workers.Wait()
 }
@@ -0,0 +1,212 @@
+package reader
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sabey/parquet-go-source/buffer"
+	"github.com/sabey/parquet-go/layout"
+	"github.com/sabey/parquet-go/source"
+	"github.com/stretchr/testify/assert"
+)
+
+//cancelFile cancels the context of a read after a number of reads of the file and its copies
+type cancelFile struct {
+	source.ParquetFile
+	state *cancelState
+}
+
+type cancelState struct {
+	sync.Mutex
+	reads  int
+	cancel context.CancelFunc
+}
+
+func (f *cancelFile) Read(b []byte) (int, error) {
+	f.state.Lock()
+	if f.state.cancel != nil {
+		if f.state.reads--; f.state.reads < 0 {
+			f.state.cancel()
+		}
+	}
+	f.state.Unlock()
+	return f.ParquetFile.Read(b)
+}
+
+func (f *cancelFile) Open(name string) (source.ParquetFile, error) {
+	file, err := f.ParquetFile.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &cancelFile{ParquetFile: file, state: f.state}, nil
+}
+
+func TestReadContext(t *testing.T) {
+	buf := pageFile.write(t)
+
+	pr, err := NewParquetReader(buffer.NewBufferFileFromBytes(buf), new(pageRecord), 2)
+	assert.Nil(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	assert.Nil(t, pr.SkipRowsContext(ctx, 10))
+	recs, err := pr.ReadByNumberContext(ctx, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 10, len(recs))
+	assert.Equal(t, newPageRecord(10), recs[0])
+
+	cancel()
+	assert.True(t, errors.Is(pr.SkipRowsContext(ctx, 10), context.Canceled))
+	_, err = pr.ReadByNumberContext(ctx, 10)
+	assert.True(t, errors.Is(err, context.Canceled))
+	pr.ReadStop()
+}
+
+func TestReadContextCancelDuringRead(t *testing.T) {
+	buf := pageFile.write(t)
+
+	for _, reads := range []int{0, 1, 3} {
+		state := &cancelState{}
+		pr, err := NewParquetReader(&cancelFile{ParquetFile: buffer.NewBufferFileFromBytes(buf), state: state}, new(pageRecord), 2)
+		assert.Nil(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		state.Lock()
+		state.reads, state.cancel = reads, cancel
+		state.Unlock()
+
+		recs := make([]pageRecord, 1000)
+		err = pr.ReadContext(ctx, &recs)
+		assert.True(t, errors.Is(err, context.Canceled), "reads %v: %v", reads, err)
+		pr.ReadStop()
+	}
+}
+
+//blockFile blocks the reads of the file and its copies once blocking is set, until one of them is closed
+type blockFile struct {
+	source.ParquetFile
+	state *blockState
+}
+
+type blockState struct {
+	sync.Mutex
+	blocking bool
+	closed   chan struct{}
+	once     sync.Once
+}
+
+func (f *blockFile) Read(b []byte) (int, error) {
+	f.state.Lock()
+	blocking := f.state.blocking
+	f.state.Unlock()
+	if blocking {
+		<-f.state.closed
+		return 0, errors.New("file closed")
+	}
+	return f.ParquetFile.Read(b)
+}
+
+func (f *blockFile) Close() error {
+	f.state.once.Do(func() { close(f.state.closed) })
+	return f.ParquetFile.Close()
+}
+
+func (f *blockFile) Open(name string) (source.ParquetFile, error) {
+	file, err := f.ParquetFile.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &blockFile{ParquetFile: file, state: f.state}, nil
+}
+
+func TestReadStopWithBlockedRead(t *testing.T) {
+	buf := pageFile.write(t)
+
+	state := &blockState{closed: make(chan struct{})}
+	pr, err := NewParquetReader(&blockFile{ParquetFile: buffer.NewBufferFileFromBytes(buf), state: state}, new(pageRecord), 2)
+	assert.Nil(t, err)
+	state.Lock()
+	state.blocking = true
+	state.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	recs := make([]pageRecord, 1000)
+	err = pr.ReadContext(ctx, &recs)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), err)
+
+	stopped := make(chan struct{})
+	go func() {
+		pr.ReadStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("ReadStop is blocked by the pending reads")
+	}
+}
+
+func TestReadColumnError(t *testing.T) {
+	buf := pageFile.write(t)
+
+	//corrupt the compressed data of the third page of the ids in the second row group
+	pr, err := NewParquetReader(buffer.NewBufferFileFromBytes(buf), new(pageRecord), 1)
+	assert.Nil(t, err)
+	chunk := pr.Footer.RowGroups[1].Columns[0]
+	offsetIndex, err := layout.ReadOffsetIndex(pr.PFile, chunk, nil)
+	assert.Nil(t, err)
+	location := offsetIndex.PageLocations[2]
+	header, err := layout.ReadPageHeader(source.ConvertToThriftReader(pr.PFile, location.Offset, int64(location.CompressedPageSize)))
+	assert.Nil(t, err)
+	pr.ReadStop()
+	end := location.Offset + int64(location.CompressedPageSize)
+	for i := end - int64(header.CompressedPageSize); i < end; i++ {
+		buf[i] = 0xff
+	}
+
+	checkError := func(pr *ParquetReader, err error) {
+		pathStr, _ := pr.SchemaHandler.ConvertToInPathStr("parquet_go_root\x01id")
+		var columnErr *ColumnError
+		assert.True(t, errors.As(err, &columnErr))
+		assert.Equal(t, pathStr, columnErr.Path)
+		assert.Equal(t, int64(1), columnErr.RowGroup)
+		assert.Equal(t, location.Offset, columnErr.PageOffset)
+	}
+
+	pr, err = NewParquetReader(buffer.NewBufferFileFromBytes(buf), new(pageRecord), 2)
+	assert.Nil(t, err)
+	recs := make([]pageRecord, 1000)
+	err = pr.Read(&recs)
+	checkError(pr, err)
+	var columnErrs ColumnErrors
+	assert.True(t, errors.As(err, &columnErrs))
+	assert.Equal(t, 1, len(columnErrs))
+	pr.ReadStop()
+
+	pr, err = NewParquetReader(buffer.NewBufferFileFromBytes(buf), new(pageRecord), 2)
+	assert.Nil(t, err)
+	checkError(pr, pr.SkipRows(500+location.FirstRowIndex))
+	pr.ReadStop()
+
+	pr, err = NewParquetColumnReader(buffer.NewBufferFileFromBytes(buf), 1)
+	assert.Nil(t, err)
+	_, _, _, err = pr.ReadColumnByPath("parquet_go_root\x01id", 1000)
+	checkError(pr, err)
+	pr.ReadStop()
+
+	//the column buffers keep the errors of the reads without error
+	pr, err = NewParquetReader(buffer.NewBufferFileFromBytes(buf), new(pageRecord), 1)
+	assert.Nil(t, err)
+	pathStr, _ := pr.SchemaHandler.ConvertToInPathStr("parquet_go_root\x01id")
+	cb := pr.ColumnBuffers[pathStr]
+	_, read := cb.ReadRows(1000)
+	assert.True(t, read < 1000)
+	checkError(pr, cb.Err())
+	_, _, err = cb.ReadRowsContext(context.Background(), 1)
+	checkError(pr, err)
+	_, err = cb.SkipRowsContext(context.Background(), 1)
+	checkError(pr, err)
+	pr.ReadStop()
+}
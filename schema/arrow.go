@@ -1,9 +1,12 @@
 package schema
 
 import (
+	"bytes"
+	"encoding/base64"
 	"fmt"
 
 	"github.com/apache/arrow/go/arrow"
+	"github.com/apache/arrow/go/arrow/ipc"
 	"github.com/pkg/errors"
 	"github.com/sabey/parquet-go/common"
 	"github.com/sabey/parquet-go/parquet"
@@ -14,125 +17,453 @@ import (
 const (
 	convertedMetaDataTemplate = "name=%s, type=%s, convertedtype=%s"
 	primitiveMetaDataTemplate = "name=%s, type=%s"
+	groupMetaDataTemplate     = "name=%s"
+	listMetaDataTemplate      = "name=%s, type=LIST"
+	optionalMetaData          = ", repetitiontype=OPTIONAL"
+	listElementName           = "element"
+	logicalMetaDataTemplate   = "name=%s, type=%s, logicaltype=%s, logicaltype.isadjustedtoutc=%t, logicaltype.unit=%s"
+	decimalMetaDataTemplate   = "name=%s, type=%s, convertedtype=DECIMAL, precision=%d, scale=%d"
 	rootNodeName              = "Parquet45go45root"
 )
 
+// Metadata of the arrow extension types written as parquet logical types
+const (
+	ExtensionNameKey  = "ARROW:extension:name"
+	UUIDExtensionName = "arrow.uuid"
+)
+
+// ArrowSchemaKey is the key of the file metadata holding the serialized
+// arrow schema of the file
+const ArrowSchemaKey = "ARROW:schema"
+
 // ConvertArrowToParquetSchema converts arrow schema to representation
 // understandable by parquet-go library.
 // We need this coversion and can't directly use arrow format because the
 // go parquet type contains metadata which the base writer is using to
 // determine the size of the objects.
+// The metadata of a nested field is the metadata of its group, the fields
+// of the group are only in the schema handler of NewSchemaHandlerFromArrow.
 func ConvertArrowToParquetSchema(schema *arrow.Schema) ([]string, error) {
 	metaData := make([]string, len(schema.Fields()))
 	for k, v := range schema.Fields() {
-		switch fieldType := v.Type; fieldType.Name() {
-		case arrow.PrimitiveTypes.Int8.Name():
-			metaData[k] = fmt.Sprintf(convertedMetaDataTemplate,
-				v.Name, parquet.Type_INT32, parquet.ConvertedType_INT_8)
-		case arrow.PrimitiveTypes.Int16.Name():
-			metaData[k] = fmt.Sprintf(convertedMetaDataTemplate,
-				v.Name, parquet.Type_INT32, parquet.ConvertedType_INT_16)
-		case arrow.PrimitiveTypes.Int32.Name():
-			metaData[k] = fmt.Sprintf(convertedMetaDataTemplate,
-				v.Name, parquet.Type_INT32, parquet.ConvertedType_INT_32)
-		case arrow.PrimitiveTypes.Int64.Name():
-			metaData[k] = fmt.Sprintf(convertedMetaDataTemplate,
-				v.Name, parquet.Type_INT64, parquet.ConvertedType_INT_64)
-		case arrow.PrimitiveTypes.Uint8.Name():
-			metaData[k] = fmt.Sprintf(convertedMetaDataTemplate,
-				v.Name, parquet.Type_INT32, parquet.ConvertedType_UINT_8)
-		case arrow.PrimitiveTypes.Uint16.Name():
-			metaData[k] = fmt.Sprintf(convertedMetaDataTemplate,
-				v.Name, parquet.Type_INT32, parquet.ConvertedType_UINT_16)
-		case arrow.PrimitiveTypes.Uint32.Name():
-			metaData[k] = fmt.Sprintf(convertedMetaDataTemplate,
-				v.Name, parquet.Type_INT32, parquet.ConvertedType_UINT_32)
-		case arrow.PrimitiveTypes.Uint64.Name():
-			metaData[k] = fmt.Sprintf(convertedMetaDataTemplate,
-				v.Name, parquet.Type_INT64, parquet.ConvertedType_UINT_64)
-		case arrow.PrimitiveTypes.Float32.Name():
-			metaData[k] = fmt.Sprintf(primitiveMetaDataTemplate, v.Name,
-				parquet.Type_FLOAT)
-		case arrow.PrimitiveTypes.Float64.Name():
-			metaData[k] = fmt.Sprintf(primitiveMetaDataTemplate, v.Name,
-				parquet.Type_DOUBLE)
-		case arrow.PrimitiveTypes.Date32.Name(),
-			arrow.PrimitiveTypes.Date64.Name():
-			metaData[k] = fmt.Sprintf(convertedMetaDataTemplate, v.Name,
-				parquet.Type_INT32, parquet.ConvertedType_DATE)
-		case arrow.FixedWidthTypes.Date32.Name(), arrow.FixedWidthTypes.Date64.Name():
-			metaData[k] = fmt.Sprintf(convertedMetaDataTemplate, v.Name,
-				parquet.Type_INT32, parquet.ConvertedType_DATE)
-		case arrow.BinaryTypes.Binary.Name():
-			metaData[k] = fmt.Sprintf(primitiveMetaDataTemplate, v.Name,
-				parquet.Type_BYTE_ARRAY)
-		case arrow.BinaryTypes.String.Name():
-			metaData[k] = fmt.Sprintf(convertedMetaDataTemplate, v.Name,
-				parquet.Type_BYTE_ARRAY, parquet.ConvertedType_UTF8)
-		case arrow.FixedWidthTypes.Boolean.Name():
-			metaData[k] = fmt.Sprintf(primitiveMetaDataTemplate, v.Name,
-				parquet.Type_BOOLEAN)
-		case arrow.FixedWidthTypes.Time32ms.Name():
-			metaData[k] = fmt.Sprintf(convertedMetaDataTemplate, v.Name,
-				parquet.Type_INT32, parquet.ConvertedType_TIME_MILLIS)
-		case arrow.FixedWidthTypes.Timestamp_ms.Name():
-			tsType := fieldType.(*arrow.TimestampType)
-			if tsType.Unit != arrow.Millisecond {
-				return nil, errors.Errorf("Unsupported arrow format: %s", tsType.String())
-			}
-			metaData[k] = fmt.Sprintf(convertedMetaDataTemplate, v.Name,
-				parquet.Type_INT64, parquet.ConvertedType_TIMESTAMP_MILLIS)
-		default:
-			return nil,
-				errors.Errorf("Unsupported arrow format: %s", fieldType.Name())
+		item, err := arrowFieldToJSONItem(v)
+		if err != nil {
+			return nil, err
 		}
+		metaData[k] = item.Tag
 	}
 	return metaData, nil
 }
 
+// arrowFieldToJSONItem converts an arrow field to an item of a json schema.
+// Lists are converted to the three-level LIST structure, with a nullable
+// element since arrow list values can be null, and structs to groups.
+// Nullable fields are optional.
+func arrowFieldToJSONItem(field arrow.Field) (*JSONSchemaItemType, error) {
+	repetitionType := ""
+	if field.Nullable {
+		repetitionType = optionalMetaData
+	}
+
+	var elemType arrow.DataType
+	switch fieldType := field.Type.(type) {
+	case *arrow.ListType:
+		elemType = fieldType.Elem()
+	case *arrow.FixedSizeListType:
+		elemType = fieldType.Elem()
+	case *arrow.StructType:
+		item := &JSONSchemaItemType{
+			Tag:    fmt.Sprintf(groupMetaDataTemplate, field.Name) + repetitionType,
+			Fields: make([]*JSONSchemaItemType, len(fieldType.Fields())),
+		}
+		for i, child := range fieldType.Fields() {
+			childItem, err := arrowFieldToJSONItem(child)
+			if err != nil {
+				return nil, err
+			}
+			item.Fields[i] = childItem
+		}
+		return item, nil
+	default:
+		tag, err := arrowPrimitiveToTag(field)
+		if err != nil {
+			return nil, err
+		}
+		return &JSONSchemaItemType{Tag: tag + repetitionType}, nil
+	}
+
+	elemItem, err := arrowFieldToJSONItem(arrow.Field{Name: listElementName, Type: elemType, Nullable: true})
+	if err != nil {
+		return nil, err
+	}
+	return &JSONSchemaItemType{
+		Tag:    fmt.Sprintf(listMetaDataTemplate, field.Name) + repetitionType,
+		Fields: []*JSONSchemaItemType{elemItem},
+	}, nil
+}
+
+// arrowPrimitiveToTag returns the metadata of a primitive arrow field
+func arrowPrimitiveToTag(field arrow.Field) (string, error) {
+	switch fieldType := field.Type; fieldType.Name() {
+	case arrow.PrimitiveTypes.Int8.Name():
+		return fmt.Sprintf(convertedMetaDataTemplate,
+			field.Name, parquet.Type_INT32, parquet.ConvertedType_INT_8), nil
+	case arrow.PrimitiveTypes.Int16.Name():
+		return fmt.Sprintf(convertedMetaDataTemplate,
+			field.Name, parquet.Type_INT32, parquet.ConvertedType_INT_16), nil
+	case arrow.PrimitiveTypes.Int32.Name():
+		return fmt.Sprintf(convertedMetaDataTemplate,
+			field.Name, parquet.Type_INT32, parquet.ConvertedType_INT_32), nil
+	case arrow.PrimitiveTypes.Int64.Name():
+		return fmt.Sprintf(convertedMetaDataTemplate,
+			field.Name, parquet.Type_INT64, parquet.ConvertedType_INT_64), nil
+	case arrow.PrimitiveTypes.Uint8.Name():
+		return fmt.Sprintf(convertedMetaDataTemplate,
+			field.Name, parquet.Type_INT32, parquet.ConvertedType_UINT_8), nil
+	case arrow.PrimitiveTypes.Uint16.Name():
+		return fmt.Sprintf(convertedMetaDataTemplate,
+			field.Name, parquet.Type_INT32, parquet.ConvertedType_UINT_16), nil
+	case arrow.PrimitiveTypes.Uint32.Name():
+		return fmt.Sprintf(convertedMetaDataTemplate,
+			field.Name, parquet.Type_INT32, parquet.ConvertedType_UINT_32), nil
+	case arrow.PrimitiveTypes.Uint64.Name():
+		return fmt.Sprintf(convertedMetaDataTemplate,
+			field.Name, parquet.Type_INT64, parquet.ConvertedType_UINT_64), nil
+	case arrow.PrimitiveTypes.Float32.Name():
+		return fmt.Sprintf(primitiveMetaDataTemplate, field.Name,
+			parquet.Type_FLOAT), nil
+	case arrow.PrimitiveTypes.Float64.Name():
+		return fmt.Sprintf(primitiveMetaDataTemplate, field.Name,
+			parquet.Type_DOUBLE), nil
+	case arrow.PrimitiveTypes.Date32.Name(),
+		arrow.PrimitiveTypes.Date64.Name():
+		return fmt.Sprintf(convertedMetaDataTemplate, field.Name,
+			parquet.Type_INT32, parquet.ConvertedType_DATE), nil
+	case arrow.FixedWidthTypes.Date32.Name(), arrow.FixedWidthTypes.Date64.Name():
+		return fmt.Sprintf(convertedMetaDataTemplate, field.Name,
+			parquet.Type_INT32, parquet.ConvertedType_DATE), nil
+	case arrow.BinaryTypes.Binary.Name():
+		return fmt.Sprintf(primitiveMetaDataTemplate, field.Name,
+			parquet.Type_BYTE_ARRAY), nil
+	case arrow.BinaryTypes.String.Name():
+		return fmt.Sprintf(convertedMetaDataTemplate, field.Name,
+			parquet.Type_BYTE_ARRAY, parquet.ConvertedType_UTF8), nil
+	case arrow.FixedWidthTypes.Boolean.Name():
+		return fmt.Sprintf(primitiveMetaDataTemplate, field.Name,
+			parquet.Type_BOOLEAN), nil
+	case arrow.FixedWidthTypes.Time32ms.Name():
+		// seconds are converted to milliseconds
+		return fmt.Sprintf(convertedMetaDataTemplate, field.Name,
+			parquet.Type_INT32, parquet.ConvertedType_TIME_MILLIS), nil
+	case arrow.FixedWidthTypes.Time64us.Name():
+		if fieldType.(*arrow.Time64Type).Unit == arrow.Nanosecond {
+			return fmt.Sprintf(logicalMetaDataTemplate, field.Name,
+				parquet.Type_INT64, "TIME", false, "NANOS"), nil
+		}
+		return fmt.Sprintf(convertedMetaDataTemplate, field.Name,
+			parquet.Type_INT64, parquet.ConvertedType_TIME_MICROS), nil
+	case arrow.FixedWidthTypes.Timestamp_ms.Name():
+		// seconds are converted to milliseconds, and the timestamps with
+		// a time zone are adjusted to UTC
+		tsType := fieldType.(*arrow.TimestampType)
+		isAdjustedToUTC := tsType.TimeZone != ""
+		switch {
+		case isAdjustedToUTC && tsType.Unit != arrow.Nanosecond:
+			convertedType := parquet.ConvertedType_TIMESTAMP_MILLIS
+			if tsType.Unit == arrow.Microsecond {
+				convertedType = parquet.ConvertedType_TIMESTAMP_MICROS
+			}
+			return fmt.Sprintf(convertedMetaDataTemplate+", isadjustedtoutc=true",
+				field.Name, parquet.Type_INT64, convertedType), nil
+		case tsType.Unit == arrow.Nanosecond:
+			return fmt.Sprintf(logicalMetaDataTemplate, field.Name,
+				parquet.Type_INT64, "TIMESTAMP", isAdjustedToUTC, "NANOS"), nil
+		case tsType.Unit == arrow.Microsecond:
+			return fmt.Sprintf(logicalMetaDataTemplate, field.Name,
+				parquet.Type_INT64, "TIMESTAMP", false, "MICROS"), nil
+		}
+		return fmt.Sprintf(logicalMetaDataTemplate, field.Name,
+			parquet.Type_INT64, "TIMESTAMP", false, "MILLIS"), nil
+	case arrow.FixedWidthTypes.Duration_ms.Name():
+		return fmt.Sprintf(primitiveMetaDataTemplate, field.Name,
+			parquet.Type_INT64), nil
+	case arrow.FixedWidthTypes.Float16.Name():
+		// half floats are converted to floats
+		return fmt.Sprintf(primitiveMetaDataTemplate, field.Name,
+			parquet.Type_FLOAT), nil
+	case (&arrow.Decimal128Type{}).Name():
+		decimalType := fieldType.(*arrow.Decimal128Type)
+		switch {
+		case decimalType.Precision <= 9:
+			return fmt.Sprintf(decimalMetaDataTemplate, field.Name,
+				parquet.Type_INT32, decimalType.Precision, decimalType.Scale), nil
+		case decimalType.Precision <= 18:
+			return fmt.Sprintf(decimalMetaDataTemplate, field.Name,
+				parquet.Type_INT64, decimalType.Precision, decimalType.Scale), nil
+		}
+		return fmt.Sprintf(decimalMetaDataTemplate+", length=%d", field.Name,
+			parquet.Type_FIXED_LEN_BYTE_ARRAY, decimalType.Precision, decimalType.Scale,
+			common.DecimalLength(decimalType.Precision)), nil
+	case (&arrow.FixedSizeBinaryType{}).Name():
+		byteWidth := fieldType.(*arrow.FixedSizeBinaryType).ByteWidth
+		tag := fmt.Sprintf(primitiveMetaDataTemplate+", length=%d", field.Name,
+			parquet.Type_FIXED_LEN_BYTE_ARRAY, byteWidth)
+		if i := field.Metadata.FindKey(ExtensionNameKey); byteWidth == 16 && i >= 0 &&
+			field.Metadata.Values()[i] == UUIDExtensionName {
+			tag += ", logicaltype=UUID"
+		}
+		return tag, nil
+	default:
+		return "",
+			errors.Errorf("Unsupported arrow format: %s", fieldType.Name())
+	}
+}
+
 // NewSchemaHandlerFromArrow creates a schema handler from arrow format.
 // This handler is needed since the base ParquetWriter does not understand
 // arrow schema and we need to translate it to the native format which the
 // parquet-go library understands.
 func NewSchemaHandlerFromArrow(arrowSchema *arrow.Schema) (
 	*SchemaHandler, error) {
-	schemaList := make([]*parquet.SchemaElement, 0)
-	infos := make([]*common.Tag, 0)
+	root := &JSONSchemaItemType{
+		Tag:    fmt.Sprintf(groupMetaDataTemplate, rootNodeName),
+		Fields: make([]*JSONSchemaItemType, len(arrowSchema.Fields())),
+	}
+	for i, field := range arrowSchema.Fields() {
+		item, err := arrowFieldToJSONItem(field)
+		if err != nil {
+			return nil, errors.Wrap(err, "arrowFieldToJSONItem")
+		}
+		root.Fields[i] = item
+	}
 
-	fields, err := ConvertArrowToParquetSchema(arrowSchema)
+	res, err := newSchemaHandlerFromJSONItem(root)
 	if err != nil {
-		return nil, errors.Wrap(err, "ConvertArrowToParquetSchema")
+		return nil, errors.Wrap(err, "newSchemaHandlerFromJSONItem")
 	}
+	return res, nil
+}
 
-	rootSchema := parquet.NewSchemaElement()
-	rootSchema.Name = rootNodeName
-	rootNumChildren := int32(len(fields))
-	rootSchema.NumChildren = &rootNumChildren
-	rt := parquet.FieldRepetitionType_REQUIRED
-	rootSchema.RepetitionType = &rt
-	schemaList = append(schemaList, rootSchema)
+// ConvertParquetToArrowSchema converts the schema of a parquet file to an
+// arrow schema. The fields are the children of the root. LIST groups and
+// repeated fields are lists, MAP groups are lists of structs of their key
+// and value, and the other groups are structs.
+func ConvertParquetToArrowSchema(schemaHandler *SchemaHandler) (*arrow.Schema, error) {
+	elements := schemaHandler.SchemaElements
+	if len(elements) == 0 {
+		return nil, errors.New("empty schema")
+	}
+	fields := make([]arrow.Field, 0, elements[0].GetNumChildren())
+	for i := 1; i < len(elements); {
+		field, next, err := arrowField(schemaHandler, i)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+		i = next
+	}
+	return arrow.NewSchema(fields, nil), nil
+}
 
-	rootInfo := common.NewTag()
-	rootInfo.InName = rootNodeName
-	rootInfo.ExName = rootNodeName
-	rootInfo.RepetitionType = parquet.FieldRepetitionType_REQUIRED
-	infos = append(infos, rootInfo)
+// ArrowListElement returns the index of the repeated group of a LIST or MAP
+// group and the index of the element holding the values of the list, and
+// whether the element i is such a group. The element is the repeated group
+// itself for the MAP groups and for the lists of two levels.
+func ArrowListElement(schemaHandler *SchemaHandler, i int) (int, int, bool) {
+	elements := schemaHandler.SchemaElements
+	element := elements[i]
+	logicalType := element.GetLogicalType()
+	isList := element.GetConvertedType() == parquet.ConvertedType_LIST ||
+		(logicalType != nil && logicalType.IsSetLIST())
+	isMap := element.GetConvertedType() == parquet.ConvertedType_MAP ||
+		element.GetConvertedType() == parquet.ConvertedType_MAP_KEY_VALUE ||
+		(logicalType != nil && logicalType.IsSetMAP())
+	if !isList && !isMap || element.GetNumChildren() != 1 || i+1 >= len(elements) ||
+		elements[i+1].GetRepetitionType() != parquet.FieldRepetitionType_REPEATED {
+		return 0, 0, false
+	}
+	if isMap || elements[i+1].GetNumChildren() != 1 {
+		return i + 1, i + 1, true
+	}
+	return i + 1, i + 2, true
+}
+
+// arrowField returns the arrow field of the element i of the schema, and
+// the index of the element after its descendants. A repeated element is a
+// list of its values.
+func arrowField(schemaHandler *SchemaHandler, i int) (arrow.Field, int, error) {
+	element := schemaHandler.SchemaElements[i]
+	field := arrow.Field{
+		Name:     schemaHandler.Infos[i].ExName,
+		Nullable: element.GetRepetitionType() == parquet.FieldRepetitionType_OPTIONAL,
+	}
+	if element.GetNumChildren() == 0 && element.IsSetLogicalType() && element.LogicalType.IsSetUUID() {
+		field.Metadata = arrow.NewMetadata([]string{ExtensionNameKey}, []string{UUIDExtensionName})
+	}
+	dataType, next, err := arrowValueType(schemaHandler, i)
+	if err != nil {
+		return field, next, err
+	}
+	if element.GetRepetitionType() == parquet.FieldRepetitionType_REPEATED {
+		dataType = arrow.ListOf(dataType)
+	}
+	field.Type = dataType
+	return field, next, nil
+}
 
-	for _, field := range fields {
-		info, err := common.StringToTag(field)
+// arrowValueType returns the arrow type of the values of the element i of
+// the schema, whatever its repetition, and the index of the element after
+// its descendants
+func arrowValueType(schemaHandler *SchemaHandler, i int) (arrow.DataType, int, error) {
+	element := schemaHandler.SchemaElements[i]
+	if element.GetNumChildren() == 0 {
+		dataType, err := arrowType(element)
 		if err != nil {
-			return nil, errors.Wrap(err, "common.StringToTag")
+			return nil, i + 1, errors.Wrap(err, "arrowType")
+		}
+		return dataType, i + 1, nil
+	}
+
+	if repeated, elem, ok := ArrowListElement(schemaHandler, i); ok {
+		var dataType arrow.DataType
+		var next int
+		var err error
+		if elem == repeated {
+			dataType, next, err = arrowValueType(schemaHandler, elem)
+		} else {
+			var field arrow.Field
+			field, next, err = arrowField(schemaHandler, elem)
+			dataType = field.Type
 		}
-		infos = append(infos, info)
-		schema, err := common.NewSchemaElementFromTagMap(info)
 		if err != nil {
-			return nil, errors.Wrap(err, "common.NewSchemaElementFromTagMap")
+			return nil, next, err
 		}
-		schemaList = append(schemaList, schema)
+		return arrow.ListOf(dataType), next, nil
 	}
-	res := NewSchemaHandlerFromSchemaList(schemaList)
-	res.Infos = infos
-	res.CreateInExMap()
 
-	return res, nil
+	fields := make([]arrow.Field, element.GetNumChildren())
+	next := i + 1
+	for k := range fields {
+		var err error
+		if fields[k], next, err = arrowField(schemaHandler, next); err != nil {
+			return nil, next, err
+		}
+	}
+	return arrow.StructOf(fields...), next, nil
+}
+
+// arrowType returns the arrow type of the values of a primitive field
+func arrowType(element *parquet.SchemaElement) (arrow.DataType, error) {
+	convertedType := element.GetConvertedType()
+	if !element.IsSetConvertedType() {
+		convertedType = -1
+	}
+	logicalType := element.GetLogicalType()
+	if logicalType == nil {
+		logicalType = parquet.NewLogicalType()
+	}
+	if convertedType == parquet.ConvertedType_DECIMAL || logicalType.IsSetDECIMAL() {
+		return &arrow.Decimal128Type{
+			Precision: element.GetPrecision(),
+			Scale:     element.GetScale(),
+		}, nil
+	}
+	switch element.GetType() {
+	case parquet.Type_BOOLEAN:
+		return arrow.FixedWidthTypes.Boolean, nil
+	case parquet.Type_INT32:
+		if logicalType.IsSetTIME() {
+			return arrow.FixedWidthTypes.Time32ms, nil
+		}
+		switch convertedType {
+		case parquet.ConvertedType_INT_8:
+			return arrow.PrimitiveTypes.Int8, nil
+		case parquet.ConvertedType_INT_16:
+			return arrow.PrimitiveTypes.Int16, nil
+		case parquet.ConvertedType_UINT_8:
+			return arrow.PrimitiveTypes.Uint8, nil
+		case parquet.ConvertedType_UINT_16:
+			return arrow.PrimitiveTypes.Uint16, nil
+		case parquet.ConvertedType_UINT_32:
+			return arrow.PrimitiveTypes.Uint32, nil
+		case parquet.ConvertedType_DATE:
+			return arrow.FixedWidthTypes.Date32, nil
+		case parquet.ConvertedType_TIME_MILLIS:
+			return arrow.FixedWidthTypes.Time32ms, nil
+		}
+		return arrow.PrimitiveTypes.Int32, nil
+	case parquet.Type_INT64:
+		if logicalType.IsSetTIMESTAMP() {
+			timestampType := &arrow.TimestampType{Unit: arrowTimeUnit(logicalType.TIMESTAMP.Unit)}
+			if logicalType.TIMESTAMP.IsAdjustedToUTC {
+				timestampType.TimeZone = "UTC"
+			}
+			return timestampType, nil
+		}
+		if logicalType.IsSetTIME() {
+			return &arrow.Time64Type{Unit: arrowTimeUnit(logicalType.TIME.Unit)}, nil
+		}
+		switch convertedType {
+		case parquet.ConvertedType_UINT_64:
+			return arrow.PrimitiveTypes.Uint64, nil
+		case parquet.ConvertedType_TIME_MICROS:
+			return arrow.FixedWidthTypes.Time64us, nil
+		case parquet.ConvertedType_TIMESTAMP_MILLIS:
+			return arrow.FixedWidthTypes.Timestamp_ms, nil
+		case parquet.ConvertedType_TIMESTAMP_MICROS:
+			return arrow.FixedWidthTypes.Timestamp_us, nil
+		}
+		return arrow.PrimitiveTypes.Int64, nil
+	case parquet.Type_FLOAT:
+		return arrow.PrimitiveTypes.Float32, nil
+	case parquet.Type_DOUBLE:
+		return arrow.PrimitiveTypes.Float64, nil
+	case parquet.Type_BYTE_ARRAY:
+		if convertedType == parquet.ConvertedType_UTF8 {
+			return arrow.BinaryTypes.String, nil
+		}
+		return arrow.BinaryTypes.Binary, nil
+	case parquet.Type_FIXED_LEN_BYTE_ARRAY:
+		return &arrow.FixedSizeBinaryType{ByteWidth: int(element.GetTypeLength())}, nil
+	case parquet.Type_INT96:
+		return &arrow.FixedSizeBinaryType{ByteWidth: 12}, nil
+	}
+	return nil, errors.Errorf("Unsupported parquet type: %v", element.GetType())
+}
+
+// arrowTimeUnit returns the arrow unit of a parquet time unit
+func arrowTimeUnit(unit *parquet.TimeUnit) arrow.TimeUnit {
+	switch {
+	case unit.IsSetNANOS():
+		return arrow.Nanosecond
+	case unit.IsSetMICROS():
+		return arrow.Microsecond
+	}
+	return arrow.Millisecond
+}
+
+// SerializeArrowSchema returns the base64 encoding of the IPC stream of an
+// arrow schema, the value of the ArrowSchemaKey metadata
+func SerializeArrowSchema(arrowSchema *arrow.Schema) (string, error) {
+	buf := new(bytes.Buffer)
+	w := ipc.NewWriter(buf, ipc.WithSchema(arrowSchema))
+	if err := w.Close(); err != nil {
+		return "", errors.Wrap(err, "w.Close")
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// DeserializeArrowSchema returns the arrow schema of the value of the
+// ArrowSchemaKey metadata
+func DeserializeArrowSchema(str string) (*arrow.Schema, error) {
+	data, err := base64.StdEncoding.DecodeString(str)
+	if err != nil {
+		return nil, errors.Wrap(err, "base64.StdEncoding.DecodeString")
+	}
+	r, err := ipc.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Wrap(err, "ipc.NewReader")
+	}
+	defer r.Release()
+	return r.Schema(), nil
 }
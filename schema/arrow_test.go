@@ -72,18 +72,79 @@ func TestTypeConversion(t *testing.T) {
 				"name=f1-d32, type=INT32, convertedtype=DATE",
 				"name=f1-d64, type=INT32, convertedtype=DATE",
 				"name=f1-t32ms, type=INT32, convertedtype=TIME_MILLIS",
-				"name=f1-tsms, type=INT64, convertedtype=TIMESTAMP_MILLIS",
+				"name=f1-tsms, type=INT64, convertedtype=TIMESTAMP_MILLIS, isadjustedtoutc=true",
+			},
+			expectedErr: false,
+		},
+		{
+			title: "test nested and nullable type conversion",
+			testSchema: arrow.NewSchema([]arrow.Field{
+				{Name: "f1-i32", Type: arrow.PrimitiveTypes.Int32, Nullable: true},
+				{Name: "f1-list", Type: arrow.ListOf(arrow.PrimitiveTypes.Int32)},
+				{Name: "f1-fixed", Type: arrow.FixedSizeListOf(2, arrow.PrimitiveTypes.Int32), Nullable: true},
+				{Name: "f1-struct", Type: arrow.StructOf(
+					arrow.Field{Name: "f2-str", Type: arrow.BinaryTypes.String},
+				), Nullable: true},
+			}, nil),
+			expectedParquetMetaData: []string{
+				"name=f1-i32, type=INT32, convertedtype=INT_32, repetitiontype=OPTIONAL",
+				"name=f1-list, type=LIST",
+				"name=f1-fixed, type=LIST, repetitiontype=OPTIONAL",
+				"name=f1-struct, repetitiontype=OPTIONAL",
+			},
+			expectedErr: false,
+		},
+		{
+			title: "test non supported nested types",
+			testSchema: arrow.NewSchema([]arrow.Field{
+				{Name: "f1-list", Type: arrow.ListOf(arrow.FixedWidthTypes.MonthInterval)},
+			}, nil),
+			expectedParquetMetaData: []string{},
+			expectedErr:             true,
+		},
+		{
+			title: "test logical type conversion",
+			testSchema: arrow.NewSchema([]arrow.Field{
+				{Name: "f1-t32s", Type: arrow.FixedWidthTypes.Time32s},
+				{Name: "f1-t64us", Type: arrow.FixedWidthTypes.Time64us},
+				{Name: "f1-t64ns", Type: arrow.FixedWidthTypes.Time64ns},
+				{Name: "f1-tss", Type: arrow.FixedWidthTypes.Timestamp_s},
+				{Name: "f1-tsus", Type: arrow.FixedWidthTypes.Timestamp_us},
+				{Name: "f1-tsns", Type: arrow.FixedWidthTypes.Timestamp_ns},
+				{Name: "f1-tsus-local", Type: &arrow.TimestampType{Unit: arrow.Microsecond}},
+				{Name: "f1-durms", Type: arrow.FixedWidthTypes.Duration_ms},
+				{Name: "f1-f16", Type: arrow.FixedWidthTypes.Float16},
+				{Name: "f1-dec9", Type: &arrow.Decimal128Type{Precision: 9, Scale: 2}},
+				{Name: "f1-dec18", Type: &arrow.Decimal128Type{Precision: 18, Scale: 3}},
+				{Name: "f1-dec38", Type: &arrow.Decimal128Type{Precision: 38, Scale: 4}},
+				{Name: "f1-fsb", Type: &arrow.FixedSizeBinaryType{ByteWidth: 4}},
+				{Name: "f1-uuid", Type: &arrow.FixedSizeBinaryType{ByteWidth: 16},
+					Metadata: arrow.NewMetadata([]string{ExtensionNameKey}, []string{UUIDExtensionName})},
+			}, nil),
+			expectedParquetMetaData: []string{
+				"name=f1-t32s, type=INT32, convertedtype=TIME_MILLIS",
+				"name=f1-t64us, type=INT64, convertedtype=TIME_MICROS",
+				"name=f1-t64ns, type=INT64, logicaltype=TIME, logicaltype.isadjustedtoutc=false, logicaltype.unit=NANOS",
+				"name=f1-tss, type=INT64, convertedtype=TIMESTAMP_MILLIS, isadjustedtoutc=true",
+				"name=f1-tsus, type=INT64, convertedtype=TIMESTAMP_MICROS, isadjustedtoutc=true",
+				"name=f1-tsns, type=INT64, logicaltype=TIMESTAMP, logicaltype.isadjustedtoutc=true, logicaltype.unit=NANOS",
+				"name=f1-tsus-local, type=INT64, logicaltype=TIMESTAMP, logicaltype.isadjustedtoutc=false, logicaltype.unit=MICROS",
+				"name=f1-durms, type=INT64",
+				"name=f1-f16, type=FLOAT",
+				"name=f1-dec9, type=INT32, convertedtype=DECIMAL, precision=9, scale=2",
+				"name=f1-dec18, type=INT64, convertedtype=DECIMAL, precision=18, scale=3",
+				"name=f1-dec38, type=FIXED_LEN_BYTE_ARRAY, convertedtype=DECIMAL, precision=38, scale=4, length=16",
+				"name=f1-fsb, type=FIXED_LEN_BYTE_ARRAY, length=4",
+				"name=f1-uuid, type=FIXED_LEN_BYTE_ARRAY, length=16, logicaltype=UUID",
 			},
 			expectedErr: false,
 		},
 		{
 			title: "test non supported types",
 			testSchema: arrow.NewSchema([]arrow.Field{
-				{Name: "f1-bool", Type: arrow.FixedWidthTypes.Time64us},
-				{Name: "f1-d64", Type: arrow.FixedWidthTypes.Time64us},
-				{Name: "f1-d64", Type: arrow.FixedWidthTypes.Time32s},
-				{Name: "f1-d32", Type: arrow.FixedWidthTypes.Timestamp_ns},
-				{Name: "f1-d64", Type: arrow.FixedWidthTypes.Timestamp_s},
+				{Name: "f1-null", Type: arrow.Null},
+				{Name: "f1-month", Type: arrow.FixedWidthTypes.MonthInterval},
+				{Name: "f1-daytime", Type: arrow.FixedWidthTypes.DayTimeInterval},
 			}, nil),
 			expectedParquetMetaData: []string{},
 			expectedErr:             true,
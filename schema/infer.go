@@ -0,0 +1,107 @@
+package schema
+
+import (
+	"math/big"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sabey/parquet-go/common"
+	"github.com/sabey/parquet-go/parquet"
+)
+
+//NewSchemaHandlerFromStructInferred creates a schema handler from a struct whose schema is inferred from the Go types.
+//All the exported fields are columns, named by their json tags or their field names; a parquet tag "-" skips a field.
+//The pointers are OPTIONAL, the slices LIST but the byte slices, which are BYTE_ARRAY, and the maps MAP. The parquet tags only override the inferred values.
+func NewSchemaHandlerFromStructInferred(obj interface{}) (*SchemaHandler, error) {
+	return newSchemaHandlerFromStruct(obj, true)
+}
+
+//inferFieldTag returns the tag of a field of a struct with an inferred schema, nil if the field is skipped
+func inferFieldTag(f reflect.StructField) (*common.Tag, error) {
+	tagStr := f.Tag.Get("parquet")
+	if f.PkgPath != "" || tagStr == "-" {
+		return nil, nil
+	}
+
+	info := common.NewTag()
+	if len(tagStr) > 0 {
+		var err error
+		if info, err = common.StringToTag(tagStr); err != nil {
+			return nil, errors.Wrap(err, "common.StringToTag")
+		}
+	}
+	if info.ExName == "" {
+		info.ExName = f.Name
+		if name := strings.Split(f.Tag.Get("json"), ",")[0]; name == "-" {
+			return nil, nil
+		} else if name != "" {
+			info.ExName = name
+		}
+	}
+	return info, nil
+}
+
+//inferTag sets the type of a leaf without a type in its tag from its Go type
+func inferTag(info *common.Tag, t reflect.Type) error {
+	if info.Type != "" {
+		return nil
+	}
+
+	pT, cT := parquet.Type_BYTE_ARRAY, parquet.ConvertedType(-1)
+	switch t {
+	case reflect.TypeOf(time.Time{}):
+		pT, cT = parquet.Type_INT64, parquet.ConvertedType_TIMESTAMP_MICROS
+		info.IsAdjustedToUTC = true
+	case reflect.TypeOf(big.Int{}), reflect.TypeOf(big.Rat{}):
+		return errors.Errorf("the tag of a %v field must set the decimal type", t)
+	default:
+		switch t.Kind() {
+		case reflect.Bool:
+			pT = parquet.Type_BOOLEAN
+		case reflect.Int8:
+			pT, cT = parquet.Type_INT32, parquet.ConvertedType_INT_8
+		case reflect.Int16:
+			pT, cT = parquet.Type_INT32, parquet.ConvertedType_INT_16
+		case reflect.Int32:
+			pT = parquet.Type_INT32
+		case reflect.Int, reflect.Int64:
+			pT = parquet.Type_INT64
+		case reflect.Uint8:
+			pT, cT = parquet.Type_INT32, parquet.ConvertedType_UINT_8
+		case reflect.Uint16:
+			pT, cT = parquet.Type_INT32, parquet.ConvertedType_UINT_16
+		case reflect.Uint32:
+			pT, cT = parquet.Type_INT32, parquet.ConvertedType_UINT_32
+		case reflect.Uint, reflect.Uint64:
+			pT, cT = parquet.Type_INT64, parquet.ConvertedType_UINT_64
+		case reflect.Float32:
+			pT = parquet.Type_FLOAT
+		case reflect.Float64:
+			pT = parquet.Type_DOUBLE
+		case reflect.String:
+			cT = parquet.ConvertedType_UTF8
+		case reflect.Slice:
+			if t.Elem().Kind() != reflect.Uint8 {
+				return errors.Errorf("can't infer the parquet type of %v", t)
+			}
+		case reflect.Array:
+			if t.Elem().Kind() != reflect.Uint8 {
+				return errors.Errorf("can't infer the parquet type of %v", t)
+			}
+			pT = parquet.Type_FIXED_LEN_BYTE_ARRAY
+			if info.Length == 0 {
+				info.Length = int32(t.Len())
+			}
+		default:
+			return errors.Errorf("can't infer the parquet type of %v", t)
+		}
+	}
+
+	info.Type = pT.String()
+	if info.ConvertedType == "" && len(info.LogicalTypeFields) == 0 && cT >= 0 {
+		info.ConvertedType = cT.String()
+	}
+	return nil
+}
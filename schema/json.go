@@ -17,7 +17,16 @@ func NewJSONSchemaItem() *JSONSchemaItemType {
 	return new(JSONSchemaItemType)
 }
 
-func NewSchemaHandlerFromJSON(str string) (sh *SchemaHandler, err error) {
+func NewSchemaHandlerFromJSON(str string) (*SchemaHandler, error) {
+	schema := NewJSONSchemaItem()
+	if err := json.Unmarshal([]byte(str), schema); err != nil {
+		return nil, errors.Wrap(err, "json.Unmarshal")
+	}
+	return newSchemaHandlerFromJSONItem(schema)
+}
+
+// newSchemaHandlerFromJSONItem creates a schema handler from the root item of a json schema
+func newSchemaHandlerFromJSONItem(schema *JSONSchemaItemType) (sh *SchemaHandler, err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			switch x := r.(type) {
@@ -31,11 +40,6 @@ func NewSchemaHandlerFromJSON(str string) (sh *SchemaHandler, err error) {
 		}
 	}()
 
-	schema := NewJSONSchemaItem()
-	if err := json.Unmarshal([]byte(str), schema); err != nil {
-		return nil, errors.Wrap(err, "json.Unmarshal")
-	}
-
 	stack := make([]*JSONSchemaItemType, 0)
 	stack = append(stack, schema)
 	schemaElements := make([]*parquet.SchemaElement, 0)
@@ -53,6 +57,7 @@ func NewSchemaHandlerFromJSON(str string) (sh *SchemaHandler, err error) {
 		if info.Type == "" { //struct
 			schema := parquet.NewSchemaElement()
 			schema.Name = info.InName
+			setFieldID(schema, info.FieldID)
 			rt := info.RepetitionType
 			schema.RepetitionType = &rt
 			numField := int32(len(item.Fields))
@@ -71,6 +76,7 @@ func NewSchemaHandlerFromJSON(str string) (sh *SchemaHandler, err error) {
 		} else if info.Type == "LIST" { //list
 			schema := parquet.NewSchemaElement()
 			schema.Name = info.InName
+			setFieldID(schema, info.FieldID)
 			rt1 := info.RepetitionType
 			schema.RepetitionType = &rt1
 			var numField1 int32 = 1
@@ -104,6 +110,7 @@ func NewSchemaHandlerFromJSON(str string) (sh *SchemaHandler, err error) {
 		} else if info.Type == "MAP" { //map
 			schema := parquet.NewSchemaElement()
 			schema.Name = info.InName
+			setFieldID(schema, info.FieldID)
 			rt1 := info.RepetitionType
 			schema.RepetitionType = &rt1
 			var numField1 int32 = 1
@@ -6,6 +6,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/sabey/parquet-go/common"
 	"github.com/sabey/parquet-go/parquet"
+	"github.com/sabey/parquet-go/types"
 )
 
 /*
@@ -54,6 +55,8 @@ type SchemaHandler struct {
 
 	InPathToExPath map[string]string
 	ExPathToInPath map[string]string
+	//Internal paths by field_id, the elements without a field_id or with 0 are left out
+	FieldIDToPath map[int32]string
 
 	ValueColumns []string
 }
@@ -70,6 +73,18 @@ func (sh *SchemaHandler) setValueColumns() {
 	}
 }
 
+// setFieldIDToPath collects the paths of the elements with a field_id in SchemaHandler.FieldIDToPath,
+// the first element of a field_id is kept
+func (sh *SchemaHandler) setFieldIDToPath() {
+	sh.FieldIDToPath = make(map[int32]string)
+	for i := 0; i < len(sh.SchemaElements); i++ {
+		fieldID := sh.SchemaElements[i].GetFieldID()
+		if _, ok := sh.FieldIDToPath[fieldID]; fieldID != 0 && !ok {
+			sh.FieldIDToPath[fieldID] = sh.IndexMap[int32(i)]
+		}
+	}
+}
+
 func (sh *SchemaHandler) GetColumnNum() int64 {
 	return int64(len(sh.ValueColumns))
 }
@@ -214,6 +229,13 @@ func (sh *SchemaHandler) GetRootExName() string {
 	return sh.Infos[0].ExName
 }
 
+//setFieldID sets the field_id of a group element, 0 is no field_id
+func setFieldID(schema *parquet.SchemaElement, fieldID int32) {
+	if fieldID != 0 {
+		schema.FieldID = &fieldID
+	}
+}
+
 type Item struct {
 	GoType reflect.Type
 	Info   *common.Tag
@@ -227,6 +249,11 @@ func NewItem() *Item {
 
 //Create schema handler from a object
 func NewSchemaHandlerFromStruct(obj interface{}) (sh *SchemaHandler, err error) {
+	return newSchemaHandlerFromStruct(obj, false)
+}
+
+//newSchemaHandlerFromStruct creates a schema handler from the parquet tags of a struct, or its inferred schema
+func newSchemaHandlerFromStruct(obj interface{}, infer bool) (sh *SchemaHandler, err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			switch x := r.(type) {
@@ -258,9 +285,13 @@ func NewSchemaHandlerFromStruct(obj interface{}) (sh *SchemaHandler, err error)
 		stack = stack[:ln-1]
 		var newInfo *common.Tag
 
-		if item.GoType.Kind() == reflect.Struct {
+		//the native types, like time.Time, the byte slices and the marshaler types are leaves
+		leaf := types.IsNativeType(item.GoType) || types.IsByteSliceType(item.GoType, item.Info.Type) ||
+			types.IsMarshalerType(item.GoType, item.Info.Type)
+		if item.GoType.Kind() == reflect.Struct && !leaf {
 			schema := parquet.NewSchemaElement()
 			schema.Name = item.Info.InName
+			setFieldID(schema, item.Info.FieldID)
 			schema.RepetitionType = &item.Info.RepetitionType
 			numField := int32(item.GoType.NumField())
 			schema.NumChildren = &numField
@@ -274,17 +305,25 @@ func NewSchemaHandlerFromStruct(obj interface{}) (sh *SchemaHandler, err error)
 				f := item.GoType.Field(i)
 				tagStr := f.Tag.Get("parquet")
 
+				var info *common.Tag
+				if infer {
+					if info, err = inferFieldTag(f); err != nil {
+						return nil, errors.Wrap(err, "inferFieldTag")
+					}
+				} else if len(tagStr) > 0 {
+					if info, err = common.StringToTag(tagStr); err != nil {
+						return nil, errors.Wrap(err, "common.StringToTag")
+					}
+				}
+
 				//ignore item without parquet tag
-				if len(tagStr) <= 0 {
+				if info == nil {
 					numField--
 					continue
 				}
 
 				newItem := NewItem()
-				newItem.Info, err = common.StringToTag(tagStr)
-				if err != nil {
-					return nil, errors.Wrap(err, "common.StringToTag")
-				}
+				newItem.Info = info
 				newItem.Info.InName = f.Name
 				newItem.GoType = f.Type
 				if f.Type.Kind() == reflect.Ptr {
@@ -293,10 +332,11 @@ func NewSchemaHandlerFromStruct(obj interface{}) (sh *SchemaHandler, err error)
 				}
 				stack = append(stack, newItem)
 			}
-		} else if item.GoType.Kind() == reflect.Slice &&
+		} else if item.GoType.Kind() == reflect.Slice && !leaf &&
 			item.Info.RepetitionType != parquet.FieldRepetitionType_REPEATED {
 			schema := parquet.NewSchemaElement()
 			schema.Name = item.Info.InName
+			setFieldID(schema, item.Info.FieldID)
 			rt1 := item.Info.RepetitionType
 			schema.RepetitionType = &rt1
 			var numField int32 = 1
@@ -332,16 +372,17 @@ func NewSchemaHandlerFromStruct(obj interface{}) (sh *SchemaHandler, err error)
 			}
 			stack = append(stack, newItem)
 
-		} else if item.GoType.Kind() == reflect.Slice &&
+		} else if item.GoType.Kind() == reflect.Slice && !leaf &&
 			item.Info.RepetitionType == parquet.FieldRepetitionType_REPEATED {
 			newItem := NewItem()
 			newItem.Info = item.Info
 			newItem.GoType = item.GoType.Elem()
 			stack = append(stack, newItem)
 
-		} else if item.GoType.Kind() == reflect.Map {
+		} else if item.GoType.Kind() == reflect.Map && !leaf {
 			schema := parquet.NewSchemaElement()
 			schema.Name = item.Info.InName
+			setFieldID(schema, item.Info.FieldID)
 			rt1 := item.Info.RepetitionType
 			schema.RepetitionType = &rt1
 			var numField1 int32 = 1
@@ -385,6 +426,13 @@ func NewSchemaHandlerFromStruct(obj interface{}) (sh *SchemaHandler, err error)
 			stack = append(stack, newItem)
 
 		} else {
+			if item.Info.Type == "" && types.IsMarshalerType(item.GoType, item.Info.Type) {
+				item.Info.Type, item.Info.ConvertedType = types.MarshalerType(item.GoType)
+			} else if infer {
+				if err = inferTag(item.Info, item.GoType); err != nil {
+					return nil, errors.Wrap(err, "inferTag")
+				}
+			}
 			schema, err := common.NewSchemaElementFromTagMap(item.Info)
 			if err != nil {
 				return nil, errors.Wrap(err, "common.NewSchemaElementFromTagMap")
@@ -448,6 +496,7 @@ func NewSchemaHandlerFromSchemaList(schemas []*parquet.SchemaElement) *SchemaHan
 	}
 	schemaHandler.setPathMap()
 	schemaHandler.setValueColumns()
+	schemaHandler.setFieldIDToPath()
 
 	return schemaHandler
 }
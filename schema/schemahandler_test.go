@@ -2,7 +2,11 @@ package schema
 
 import (
 	"fmt"
+	"math/big"
 	"testing"
+	"time"
+
+	"github.com/sabey/parquet-go/parquet"
 )
 
 type Class struct {
@@ -23,3 +27,132 @@ func TestNewSchemaHandlerFromStruct(t *testing.T) {
 	schemaMap, _ := NewSchemaHandlerFromStruct(new(Student))
 	fmt.Println(schemaMap)
 }
+
+type Address struct {
+	City string `json:"city"`
+	Zip  *uint16
+}
+
+type Payload []byte
+
+type Person struct {
+	ID       int64             `json:"id"`
+	Name     string            `json:"name,omitempty"`
+	Tags     []string          `json:"tags"`
+	Scores   map[string]int32  `json:"scores"`
+	Address  *Address          `json:"address"`
+	Created  time.Time         `json:"created"`
+	Checksum [4]byte           `json:"-"`
+	Balance  int64             `json:"balance" parquet:"name=amount, convertedtype=DECIMAL, precision=18, scale=2"`
+	Ignored  string            `parquet:"-"`
+	Attrs    map[string]string `parquet:"name=attrs, keytype=BYTE_ARRAY, valuetype=BYTE_ARRAY"`
+	Raw      []byte            `json:"raw"`
+	Payload  Payload           `json:"payload"`
+	Digits   []uint8           `json:"digits" parquet:"type=LIST, valuetype=INT32"`
+	secret   string
+}
+
+func TestNewSchemaHandlerFromStructInferred(t *testing.T) {
+	sh, err := NewSchemaHandlerFromStructInferred(new(Person))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path    string
+		exPath  string
+		pT      parquet.Type
+		cT      *parquet.ConvertedType
+		rT      parquet.FieldRepetitionType
+		logical bool
+	}{
+		{"ID", "id", parquet.Type_INT64, nil, parquet.FieldRepetitionType_REQUIRED, false},
+		{"Name", "name", parquet.Type_BYTE_ARRAY, parquet.ConvertedTypePtr(parquet.ConvertedType_UTF8), parquet.FieldRepetitionType_REQUIRED, true},
+		{"Tags\x01List\x01Element", "tags\x01list\x01element", parquet.Type_BYTE_ARRAY, parquet.ConvertedTypePtr(parquet.ConvertedType_UTF8), parquet.FieldRepetitionType_REQUIRED, true},
+		{"Scores\x01Key_value\x01Key", "scores\x01key_value\x01key", parquet.Type_BYTE_ARRAY, parquet.ConvertedTypePtr(parquet.ConvertedType_UTF8), parquet.FieldRepetitionType_REQUIRED, true},
+		{"Scores\x01Key_value\x01Value", "scores\x01key_value\x01value", parquet.Type_INT32, nil, parquet.FieldRepetitionType_REQUIRED, false},
+		{"Address\x01City", "address\x01city", parquet.Type_BYTE_ARRAY, parquet.ConvertedTypePtr(parquet.ConvertedType_UTF8), parquet.FieldRepetitionType_REQUIRED, true},
+		{"Address\x01Zip", "address\x01Zip", parquet.Type_INT32, parquet.ConvertedTypePtr(parquet.ConvertedType_UINT_16), parquet.FieldRepetitionType_OPTIONAL, true},
+		{"Created", "created", parquet.Type_INT64, parquet.ConvertedTypePtr(parquet.ConvertedType_TIMESTAMP_MICROS), parquet.FieldRepetitionType_REQUIRED, true},
+		{"Balance", "amount", parquet.Type_INT64, parquet.ConvertedTypePtr(parquet.ConvertedType_DECIMAL), parquet.FieldRepetitionType_REQUIRED, true},
+		{"Attrs\x01Key_value\x01Value", "attrs\x01key_value\x01value", parquet.Type_BYTE_ARRAY, nil, parquet.FieldRepetitionType_REQUIRED, false},
+		{"Raw", "raw", parquet.Type_BYTE_ARRAY, nil, parquet.FieldRepetitionType_REQUIRED, false},
+		{"Payload", "payload", parquet.Type_BYTE_ARRAY, nil, parquet.FieldRepetitionType_REQUIRED, false},
+		{"Digits\x01List\x01Element", "digits\x01list\x01element", parquet.Type_INT32, nil, parquet.FieldRepetitionType_REQUIRED, false},
+	}
+	for _, test := range tests {
+		path := "Parquet_go_root\x01" + test.path
+		index, ok := sh.MapIndex[path]
+		if !ok {
+			t.Errorf("missing column %q", test.path)
+			continue
+		}
+		if exPath := sh.InPathToExPath[path]; exPath != "parquet_go_root\x01"+test.exPath {
+			t.Errorf("%q: expect external path %q, get %q", test.path, test.exPath, exPath)
+		}
+		se := sh.SchemaElements[index]
+		if se.GetType() != test.pT || se.GetRepetitionType() != test.rT {
+			t.Errorf("%q: expect %v %v, get %v %v", test.path, test.pT, test.rT, se.GetType(), se.GetRepetitionType())
+		}
+		if (test.cT == nil) != (se.ConvertedType == nil) || (test.cT != nil && *test.cT != *se.ConvertedType) {
+			t.Errorf("%q: expect converted type %v, get %v", test.path, test.cT, se.ConvertedType)
+		}
+		if test.logical != (se.LogicalType != nil) {
+			t.Errorf("%q: expect logical type %v, get %v", test.path, test.logical, se.LogicalType)
+		}
+	}
+	if sh.SchemaElements[sh.MapIndex["Parquet_go_root\x01Created"]].LogicalType.TIMESTAMP.IsAdjustedToUTC != true {
+		t.Error("the times must be adjusted to UTC")
+	}
+	for _, path := range []string{"Checksum", "Ignored", "secret"} {
+		if _, ok := sh.MapIndex["Parquet_go_root\x01"+path]; ok {
+			t.Errorf("the field %s must be skipped", path)
+		}
+	}
+
+	//the types without a parquet type need a tag
+	if _, err = NewSchemaHandlerFromStructInferred(new(struct{ Any interface{} })); err == nil {
+		t.Error("the inference of interface{} should fail")
+	}
+	if _, err = NewSchemaHandlerFromStructInferred(new(struct{ Rat big.Rat })); err == nil {
+		t.Error("the inference of big.Rat should fail")
+	}
+	type fixed struct {
+		Hash [4]byte `json:"hash"`
+	}
+	if sh, err = NewSchemaHandlerFromStructInferred(new(fixed)); err != nil {
+		t.Fatal(err)
+	} else if se := sh.SchemaElements[1]; se.GetType() != parquet.Type_FIXED_LEN_BYTE_ARRAY || se.GetTypeLength() != 4 {
+		t.Errorf("expect FIXED_LEN_BYTE_ARRAY(4), get %v(%v)", se.GetType(), se.GetTypeLength())
+	}
+}
+
+type FieldIDRecord struct {
+	ID      int64            `parquet:"name=id, type=INT64, fieldid=1"`
+	Name    string           `parquet:"name=name, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Address *Address         `parquet:"name=address, fieldid=2"`
+	Scores  map[string]int32 `parquet:"name=scores, type=MAP, keytype=BYTE_ARRAY, keyfieldid=4, valuetype=INT32, valuefieldid=5, fieldid=3"`
+}
+
+func TestFieldIDToPath(t *testing.T) {
+	sh, err := NewSchemaHandlerFromStructInferred(new(FieldIDRecord))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[int32]string{
+		1: "Parquet_go_root\x01ID",
+		2: "Parquet_go_root\x01Address",
+		3: "Parquet_go_root\x01Scores",
+		4: "Parquet_go_root\x01Scores\x01Key_value\x01Key",
+		5: "Parquet_go_root\x01Scores\x01Key_value\x01Value",
+	}
+	if len(sh.FieldIDToPath) != len(expected) {
+		t.Errorf("field ids %v, expected %v", sh.FieldIDToPath, expected)
+	}
+	for fieldID, path := range expected {
+		if sh.FieldIDToPath[fieldID] != path {
+			t.Errorf("field id %v: path %q, expected %q", fieldID, sh.FieldIDToPath[fieldID], path)
+		}
+	}
+}
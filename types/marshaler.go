@@ -0,0 +1,160 @@
+package types
+
+import (
+	"encoding"
+	"reflect"
+
+	"github.com/pkg/errors"
+	"github.com/sabey/parquet-go/parquet"
+)
+
+//ParquetMarshaler is implemented by the types converting themselves to a value of the parquet type of their column,
+//e.g. an int64 for an INT64 column or a string for a BYTE_ARRAY column
+type ParquetMarshaler interface {
+	MarshalParquet(schema *parquet.SchemaElement) (interface{}, error)
+}
+
+//ParquetUnmarshaler is implemented by the types converting themselves from a value of the parquet type of their column
+type ParquetUnmarshaler interface {
+	UnmarshalParquet(src interface{}, schema *parquet.SchemaElement) error
+}
+
+var (
+	parquetMarshalerType   = reflect.TypeOf((*ParquetMarshaler)(nil)).Elem()
+	parquetUnmarshalerType = reflect.TypeOf((*ParquetUnmarshaler)(nil)).Elem()
+	textMarshalerType      = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType    = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	binaryMarshalerType    = reflect.TypeOf((*encoding.BinaryMarshaler)(nil)).Elem()
+	binaryUnmarshalerType  = reflect.TypeOf((*encoding.BinaryUnmarshaler)(nil)).Elem()
+)
+
+//implements returns true if the type or a pointer to it implements one of the interfaces
+func implements(t reflect.Type, interfaceTypes ...reflect.Type) bool {
+	for _, it := range interfaceTypes {
+		if t.Implements(it) || reflect.PtrTo(t).Implements(it) {
+			return true
+		}
+	}
+	return false
+}
+
+//IsMarshalerType returns true for the types converted by the marshalers in a column whose tag sets the parquet type pT,
+//"" if the tag doesn't set it. The types implementing ParquetMarshaler or ParquetUnmarshaler are always converted by them.
+//The encoding.TextMarshaler and encoding.BinaryMarshaler types are only converted for the BYTE_ARRAY and
+//FIXED_LEN_BYTE_ARRAY columns, and for the columns without a type but the structs, which stay groups.
+//The native types are converted by NativeToParquetType instead and the pointers are dereferenced first.
+//They are leaves of the schemas of structs.
+func IsMarshalerType(t reflect.Type, pT string) bool {
+	if t.Kind() == reflect.Ptr || IsNativeType(t) {
+		return false
+	}
+	if implements(t, parquetMarshalerType, parquetUnmarshalerType) {
+		return true
+	}
+	switch pT {
+	case "":
+		if t.Kind() == reflect.Struct {
+			return false
+		}
+	case parquet.Type_BYTE_ARRAY.String(), parquet.Type_FIXED_LEN_BYTE_ARRAY.String():
+	default:
+		return false
+	}
+	return implements(t, textMarshalerType, textUnmarshalerType, binaryMarshalerType, binaryUnmarshalerType)
+}
+
+//IsMarshalerColumn returns true if the values of type t in a column are converted by the marshalers, see IsMarshalerType
+func IsMarshalerColumn(t reflect.Type, schema *parquet.SchemaElement) bool {
+	return schema.IsSetType() && schema.GetNumChildren() == 0 && IsMarshalerType(t, schema.GetType().String())
+}
+
+//MarshalerType returns the parquet type and the converted type of the columns of a marshaler type without a type tag:
+//BYTE_ARRAY and UTF8 for the text marshalers, BYTE_ARRAY for the binary marshalers.
+//The types of the ParquetMarshaler columns must be set by the tags.
+func MarshalerType(t reflect.Type) (string, string) {
+	if implements(t, parquetMarshalerType, parquetUnmarshalerType) {
+		return "", ""
+	} else if implements(t, textMarshalerType, textUnmarshalerType) {
+		return parquet.Type_BYTE_ARRAY.String(), parquet.ConvertedType_UTF8.String()
+	}
+	return parquet.Type_BYTE_ARRAY.String(), ""
+}
+
+//isStringColumn returns true for the UTF8 columns, the text marshalers are preferred for them
+func isStringColumn(schema *parquet.SchemaElement) bool {
+	if schema.LogicalType != nil && schema.LogicalType.IsSetSTRING() {
+		return true
+	}
+	return schema.ConvertedType != nil && *schema.ConvertedType == parquet.ConvertedType_UTF8
+}
+
+//addressable returns a pointer to a copy of the value
+func addressable(src interface{}) interface{} {
+	value := reflect.ValueOf(src)
+	ptr := reflect.New(value.Type())
+	ptr.Elem().Set(value)
+	return ptr.Interface()
+}
+
+//MarshalerToParquetType converts a value of a marshaler type to the parquet type of its column.
+//ParquetMarshaler is preferred, then encoding.TextMarshaler for the UTF8 columns and encoding.BinaryMarshaler.
+func MarshalerToParquetType(src interface{}, schema *parquet.SchemaElement) (interface{}, error) {
+	//the method set of the pointer has the methods of both receivers
+	src = addressable(src)
+	if m, ok := src.(ParquetMarshaler); ok {
+		v, err := m.MarshalParquet(schema)
+		if err != nil {
+			return nil, errors.Wrap(err, "MarshalParquet")
+		}
+		if bs, ok := v.([]byte); ok {
+			return string(bs), nil
+		}
+		return InterfaceToParquetType(v, schema.Type), nil
+	}
+
+	textMarshaler, isText := src.(encoding.TextMarshaler)
+	binaryMarshaler, isBinary := src.(encoding.BinaryMarshaler)
+	if isText && (!isBinary || isStringColumn(schema)) {
+		bs, err := textMarshaler.MarshalText()
+		if err != nil {
+			return nil, errors.Wrap(err, "MarshalText")
+		}
+		return string(bs), nil
+	} else if isBinary {
+		bs, err := binaryMarshaler.MarshalBinary()
+		if err != nil {
+			return nil, errors.Wrap(err, "MarshalBinary")
+		}
+		return string(bs), nil
+	}
+	return nil, errors.Errorf("%T doesn't implement a marshaler", src)
+}
+
+//ParquetTypeToUnmarshaler sets an addressable value of an unmarshaler type from a value of the parquet type of its column.
+//ParquetUnmarshaler is preferred, then encoding.TextUnmarshaler for the UTF8 columns and encoding.BinaryUnmarshaler.
+func ParquetTypeToUnmarshaler(src interface{}, schema *parquet.SchemaElement, dst reflect.Value) error {
+	ptr := dst.Addr().Interface()
+
+	if u, ok := ptr.(ParquetUnmarshaler); ok {
+		return errors.Wrap(u.UnmarshalParquet(src, schema), "UnmarshalParquet")
+	}
+
+	var bs []byte
+	switch v := src.(type) {
+	case string:
+		bs = []byte(v)
+	case []byte:
+		bs = v
+	default:
+		return errors.Errorf("can't unmarshal %T from a %v column", ptr, schema.GetType())
+	}
+
+	textUnmarshaler, isText := ptr.(encoding.TextUnmarshaler)
+	binaryUnmarshaler, isBinary := ptr.(encoding.BinaryUnmarshaler)
+	if isText && (!isBinary || isStringColumn(schema)) {
+		return errors.Wrap(textUnmarshaler.UnmarshalText(bs), "UnmarshalText")
+	} else if isBinary {
+		return errors.Wrap(binaryUnmarshaler.UnmarshalBinary(bs), "UnmarshalBinary")
+	}
+	return errors.Errorf("%T doesn't implement an unmarshaler", ptr)
+}
@@ -0,0 +1,340 @@
+package types
+
+import (
+	"math/big"
+	"reflect"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sabey/parquet-go/parquet"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	bigIntType   = reflect.TypeOf(big.Int{})
+	bigRatType   = reflect.TypeOf(big.Rat{})
+	byteType     = reflect.TypeOf(byte(0))
+)
+
+//IsNativeType returns true for the Go types converted by the logical type of their column:
+//time.Time, time.Duration, big.Int, big.Rat and the byte arrays, e.g. [16]byte for UUID.
+//They are leaves of the schemas of structs.
+func IsNativeType(t reflect.Type) bool {
+	switch t {
+	case timeType, durationType, bigIntType, bigRatType:
+		return true
+	}
+	return t.Kind() == reflect.Array && t.Elem().Kind() == reflect.Uint8
+}
+
+//IsByteSliceType reports whether the Go type is a byte slice, like []byte, stored as a single value
+//when the type of its tag pT is empty, BYTE_ARRAY or FIXED_LEN_BYTE_ARRAY. The other byte slices are LISTs,
+//and the byte slices converted by their marshalers, like net.IP, are marshaler types.
+func IsByteSliceType(t reflect.Type, pT string) bool {
+	if t.Kind() != reflect.Slice || t.Elem() != byteType || IsMarshalerType(t, pT) {
+		return false
+	}
+	return pT == "" || pT == parquet.Type_BYTE_ARRAY.String() || pT == parquet.Type_FIXED_LEN_BYTE_ARRAY.String()
+}
+
+//IsByteSliceColumn reports whether the values of a column with the Go type are byte slices, see IsByteSliceType
+func IsByteSliceColumn(t reflect.Type, schema *parquet.SchemaElement) bool {
+	return schema.IsSetType() && schema.GetNumChildren() == 0 && IsByteSliceType(t, schema.GetType().String())
+}
+
+//NativeToParquetType converts a value of a native Go type to the parquet type of its column:
+//  - time.Time to DATE, TIME, TIMESTAMP and INT96
+//  - time.Duration to TIME, or nanoseconds for the other INT64 columns
+//  - big.Int, the unscaled value, and big.Rat to DECIMAL, a big.Rat is rounded half away from zero
+//  - the byte arrays and the byte slices to FIXED_LEN_BYTE_ARRAY and BYTE_ARRAY
+func NativeToParquetType(src interface{}, schema *parquet.SchemaElement) (interface{}, error) {
+	pT := schema.GetType()
+	switch v := src.(type) {
+	case time.Time:
+		return timeToParquetType(v, schema)
+
+	case time.Duration:
+		if unit, _, ok := timeUnit(schema); ok && isTime(schema) {
+			return durationToParquetType(int64(v/unit), pT), nil
+		} else if pT == parquet.Type_INT64 {
+			return int64(v), nil
+		}
+
+	case big.Int:
+		return decimalToParquetType(&v, schema)
+	case *big.Int:
+		return decimalToParquetType(v, schema)
+
+	case big.Rat:
+		return ratToParquetType(&v, schema)
+	case *big.Rat:
+		return ratToParquetType(v, schema)
+
+	default:
+		value := reflect.ValueOf(src)
+		if (value.Kind() == reflect.Array && value.Type().Elem().Kind() == reflect.Uint8 || value.Kind() == reflect.Slice && value.Type().Elem() == byteType) &&
+			(pT == parquet.Type_FIXED_LEN_BYTE_ARRAY || pT == parquet.Type_BYTE_ARRAY) {
+			buf := make([]byte, value.Len())
+			reflect.Copy(reflect.ValueOf(buf), value)
+			return string(buf), nil
+		}
+	}
+	return nil, errors.Errorf("can't convert %T to a %v column", src, pT)
+}
+
+//ParquetTypeToNative converts a parquet value of a column to a native Go type, see NativeToParquetType
+func ParquetTypeToNative(src interface{}, schema *parquet.SchemaElement, t reflect.Type) (reflect.Value, error) {
+	pT := schema.GetType()
+	switch t {
+	case timeType:
+		v, err := parquetTypeToTime(src, schema)
+		if err != nil {
+			return reflect.Value{}, errors.Wrap(err, "parquetTypeToTime")
+		}
+		return reflect.ValueOf(v), nil
+
+	case durationType:
+		if unit, _, ok := timeUnit(schema); ok && isTime(schema) {
+			return reflect.ValueOf(time.Duration(parquetTypeToInt64(src)) * unit), nil
+		} else if v, ok := src.(int64); ok {
+			return reflect.ValueOf(time.Duration(v)), nil
+		}
+
+	case bigIntType:
+		unscaled, err := parquetTypeToUnscaled(src)
+		if err != nil {
+			return reflect.Value{}, errors.Wrap(err, "parquetTypeToUnscaled")
+		}
+		return reflect.ValueOf(unscaled).Elem(), nil
+
+	case bigRatType:
+		unscaled, err := parquetTypeToUnscaled(src)
+		if err != nil {
+			return reflect.Value{}, errors.Wrap(err, "parquetTypeToUnscaled")
+		}
+		_, scale := decimalScale(schema)
+		res := new(big.Rat).SetFrac(unscaled, pow10(scale))
+		return reflect.ValueOf(res).Elem(), nil
+
+	default:
+		if s, ok := src.(string); ok && t.Kind() == reflect.Array && t.Elem().Kind() == reflect.Uint8 {
+			if len(s) != t.Len() {
+				return reflect.Value{}, errors.Errorf("can't convert %v bytes to %v", len(s), t)
+			}
+			res := reflect.New(t).Elem()
+			reflect.Copy(res, reflect.ValueOf([]byte(s)))
+			return res, nil
+		} else if ok && t.Kind() == reflect.Slice && t.Elem() == byteType {
+			return reflect.ValueOf([]byte(s)).Convert(t), nil
+		}
+	}
+	return reflect.Value{}, errors.Errorf("can't convert a %v value to %v", pT, t)
+}
+
+//timeUnit returns the unit of a TIME or TIMESTAMP column and if it's adjusted to UTC
+func timeUnit(schema *parquet.SchemaElement) (time.Duration, bool, bool) {
+	var unit *parquet.TimeUnit
+	var adjustedToUTC bool
+	if lT := schema.LogicalType; lT != nil && lT.IsSetTIMESTAMP() {
+		unit, adjustedToUTC = lT.TIMESTAMP.Unit, lT.TIMESTAMP.IsAdjustedToUTC
+	} else if lT != nil && lT.IsSetTIME() {
+		unit, adjustedToUTC = lT.TIME.Unit, lT.TIME.IsAdjustedToUTC
+	}
+	if unit != nil {
+		switch {
+		case unit.IsSetMILLIS():
+			return time.Millisecond, adjustedToUTC, true
+		case unit.IsSetMICROS():
+			return time.Microsecond, adjustedToUTC, true
+		case unit.IsSetNANOS():
+			return time.Nanosecond, adjustedToUTC, true
+		}
+	}
+
+	if schema.ConvertedType != nil {
+		switch *schema.ConvertedType {
+		case parquet.ConvertedType_TIMESTAMP_MILLIS, parquet.ConvertedType_TIME_MILLIS:
+			return time.Millisecond, true, true
+		case parquet.ConvertedType_TIMESTAMP_MICROS, parquet.ConvertedType_TIME_MICROS:
+			return time.Microsecond, true, true
+		}
+	}
+	return 0, false, false
+}
+
+//isTime returns true for the TIME columns, whose values are times of day
+func isTime(schema *parquet.SchemaElement) bool {
+	if schema.LogicalType != nil && schema.LogicalType.IsSetTIME() {
+		return true
+	}
+	cT := schema.ConvertedType
+	return cT != nil && (*cT == parquet.ConvertedType_TIME_MILLIS || *cT == parquet.ConvertedType_TIME_MICROS)
+}
+
+//isDate returns true for the DATE columns, whose values are days since the epoch
+func isDate(schema *parquet.SchemaElement) bool {
+	if schema.LogicalType != nil && schema.LogicalType.IsSetDATE() {
+		return true
+	}
+	return schema.ConvertedType != nil && *schema.ConvertedType == parquet.ConvertedType_DATE
+}
+
+func durationToParquetType(v int64, pT parquet.Type) interface{} {
+	if pT == parquet.Type_INT32 {
+		return int32(v)
+	}
+	return v
+}
+
+func parquetTypeToInt64(src interface{}) int64 {
+	switch v := src.(type) {
+	case int32:
+		return int64(v)
+	case int64:
+		return v
+	}
+	return 0
+}
+
+func timeToParquetType(t time.Time, schema *parquet.SchemaElement) (interface{}, error) {
+	pT := schema.GetType()
+	if pT == parquet.Type_INT96 {
+		return TimeToINT96(t), nil
+	} else if isDate(schema) {
+		date := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		return int32(date.Unix() / int64(24*time.Hour/time.Second)), nil
+	}
+
+	unit, adjustedToUTC, ok := timeUnit(schema)
+	if !ok {
+		return nil, errors.Errorf("can't convert time.Time to a %v column without a time logical type", pT)
+	}
+	if isTime(schema) {
+		if adjustedToUTC {
+			t = t.UTC()
+		}
+		nanos := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute +
+			time.Duration(t.Second())*time.Second + time.Duration(t.Nanosecond())
+		return durationToParquetType(int64(nanos/unit), pT), nil
+	}
+	return TimeToTIMESTAMP_NANOS(t, adjustedToUTC) / int64(unit), nil
+}
+
+func parquetTypeToTime(src interface{}, schema *parquet.SchemaElement) (time.Time, error) {
+	if s, ok := src.(string); ok && schema.GetType() == parquet.Type_INT96 {
+		return INT96ToTime(s), nil
+	} else if isDate(schema) {
+		return time.Unix(parquetTypeToInt64(src)*int64(24*time.Hour/time.Second), 0).UTC(), nil
+	}
+
+	unit, adjustedToUTC, ok := timeUnit(schema)
+	if !ok {
+		return time.Time{}, errors.Errorf("can't convert a %v column without a time logical type to time.Time", schema.GetType())
+	}
+	nanos := parquetTypeToInt64(src) * int64(unit)
+	if isTime(schema) {
+		location := time.UTC
+		if !adjustedToUTC {
+			location = time.Local
+		}
+		return time.Date(1970, 1, 1, 0, 0, 0, 0, location).Add(time.Duration(nanos)), nil
+	}
+	return TIMESTAMP_NANOSToTime(nanos, adjustedToUTC), nil
+}
+
+//decimalScale returns the precision and the scale of a DECIMAL column
+func decimalScale(schema *parquet.SchemaElement) (int32, int32) {
+	if schema.LogicalType != nil && schema.LogicalType.IsSetDECIMAL() {
+		return schema.LogicalType.DECIMAL.Precision, schema.LogicalType.DECIMAL.Scale
+	}
+	return schema.GetPrecision(), schema.GetScale()
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+func ratToParquetType(r *big.Rat, schema *parquet.SchemaElement) (interface{}, error) {
+	_, scale := decimalScale(schema)
+	num := new(big.Int).Mul(r.Num(), pow10(scale))
+	unscaled, rem := new(big.Int).QuoRem(num, r.Denom(), new(big.Int))
+	//round half away from zero
+	if rem.Sign() != 0 && new(big.Int).Mul(new(big.Int).Abs(rem), big.NewInt(2)).Cmp(r.Denom()) >= 0 {
+		unscaled.Add(unscaled, big.NewInt(int64(num.Sign())))
+	}
+	return decimalToParquetType(unscaled, schema)
+}
+
+//decimalToParquetType converts an unscaled value to the physical type of a DECIMAL column
+func decimalToParquetType(unscaled *big.Int, schema *parquet.SchemaElement) (interface{}, error) {
+	switch pT := schema.GetType(); pT {
+	case parquet.Type_INT32:
+		if !unscaled.IsInt64() || unscaled.Int64() != int64(int32(unscaled.Int64())) {
+			return nil, errors.Errorf("decimal %v overflows INT32", unscaled)
+		}
+		return int32(unscaled.Int64()), nil
+
+	case parquet.Type_INT64:
+		if !unscaled.IsInt64() {
+			return nil, errors.Errorf("decimal %v overflows INT64", unscaled)
+		}
+		return unscaled.Int64(), nil
+
+	case parquet.Type_FIXED_LEN_BYTE_ARRAY, parquet.Type_BYTE_ARRAY:
+		buf := bigIntToBytes(unscaled)
+		if pT == parquet.Type_FIXED_LEN_BYTE_ARRAY {
+			length := int(schema.GetTypeLength())
+			if len(buf) > length {
+				return nil, errors.Errorf("decimal %v overflows %v bytes", unscaled, length)
+			}
+			pad := byte(0)
+			if unscaled.Sign() < 0 {
+				pad = 0xff
+			}
+			padded := make([]byte, length)
+			for i := 0; i < length-len(buf); i++ {
+				padded[i] = pad
+			}
+			copy(padded[length-len(buf):], buf)
+			buf = padded
+		}
+		return string(buf), nil
+
+	default:
+		return nil, errors.Errorf("can't convert a decimal to a %v column", pT)
+	}
+}
+
+//bigIntToBytes returns the minimal big-endian two's complement of an integer
+func bigIntToBytes(n *big.Int) []byte {
+	if n.Sign() >= 0 {
+		buf := n.Bytes()
+		if len(buf) == 0 || buf[0]&0x80 != 0 {
+			buf = append([]byte{0}, buf...)
+		}
+		return buf
+	}
+	//the two's complement of -n is 2^(8*len) - n
+	length := (new(big.Int).Sub(new(big.Int).Neg(n), big.NewInt(1)).BitLen())/8 + 1
+	mod := new(big.Int).Lsh(big.NewInt(1), uint(8*length))
+	buf := new(big.Int).Add(mod, n).Bytes()
+	return append(make([]byte, length-len(buf)), buf...)
+}
+
+//parquetTypeToUnscaled returns the unscaled value of a DECIMAL column
+func parquetTypeToUnscaled(src interface{}) (*big.Int, error) {
+	switch v := src.(type) {
+	case int32:
+		return big.NewInt(int64(v)), nil
+	case int64:
+		return big.NewInt(v), nil
+	case string:
+		res := new(big.Int).SetBytes([]byte(v))
+		if len(v) > 0 && v[0]&0x80 != 0 {
+			res.Sub(res, new(big.Int).Lsh(big.NewInt(1), uint(8*len(v))))
+		}
+		return res, nil
+	}
+	return nil, errors.Errorf("can't convert %T to a decimal", src)
+}
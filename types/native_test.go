@@ -0,0 +1,61 @@
+package types
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sabey/parquet-go/parquet"
+)
+
+func TestNativeDecimal(t *testing.T) {
+	schema := parquet.NewSchemaElement()
+	schema.Type = parquet.TypePtr(parquet.Type_FIXED_LEN_BYTE_ARRAY)
+	schema.ConvertedType = parquet.ConvertedTypePtr(parquet.ConvertedType_DECIMAL)
+	length, precision, scale := int32(4), int32(9), int32(2)
+	schema.TypeLength, schema.Precision, schema.Scale = &length, &precision, &scale
+
+	r, _ := new(big.Rat).SetString("-12.345")
+	v, err := NativeToParquetType(r, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := DECIMAL_BYTE_ARRAY_ToString([]byte(v.(string)), 9, 2); s != "-12.35" {
+		t.Error("NativeToParquetType error: ", s)
+	}
+	n, err := ParquetTypeToNative(v, schema, reflect.TypeOf(big.Int{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i := n.Interface().(big.Int); i.Int64() != -1235 {
+		t.Error("ParquetTypeToNative error: ", i.String())
+	}
+
+	//the unscaled values must fit in the type length
+	if _, err = NativeToParquetType(new(big.Int).Lsh(big.NewInt(1), 40), schema); err == nil {
+		t.Error("NativeToParquetType should fail on overflow")
+	}
+}
+
+func TestNativeTime(t *testing.T) {
+	schema := parquet.NewSchemaElement()
+	schema.Type = parquet.TypePtr(parquet.Type_INT64)
+	schema.ConvertedType = parquet.ConvertedTypePtr(parquet.ConvertedType_TIMESTAMP_MICROS)
+
+	t1 := time.Date(2021, 3, 4, 5, 6, 7, 891234000, time.UTC)
+	v, err := NativeToParquetType(t1, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.(int64) != t1.UnixNano()/1000 {
+		t.Error("NativeToParquetType error: ", v)
+	}
+	t2, err := ParquetTypeToNative(v, schema, reflect.TypeOf(time.Time{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !t1.Equal(t2.Interface().(time.Time)) {
+		t.Error("ParquetTypeToNative error: ", t2)
+	}
+}
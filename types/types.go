@@ -266,15 +266,19 @@ func InterfaceToParquetType(src interface{}, pT *parquet.Type) interface{} {
 	case parquet.Type_INT32:
 		if _, ok := src.(int32); ok {
 			return src
+		} else if value := reflect.ValueOf(src); isUint(value.Kind()) {
+			return int32(value.Uint())
 		} else {
-			return int32(reflect.ValueOf(src).Int())
+			return int32(value.Int())
 		}
 
 	case parquet.Type_INT64:
 		if _, ok := src.(int64); ok {
 			return src
+		} else if value := reflect.ValueOf(src); isUint(value.Kind()) {
+			return int64(value.Uint())
 		} else {
-			return reflect.ValueOf(src).Int()
+			return value.Int()
 		}
 
 	case parquet.Type_FLOAT:
@@ -307,6 +311,15 @@ func InterfaceToParquetType(src interface{}, pT *parquet.Type) interface{} {
 	}
 }
 
+//isUint returns true for the unsigned integer kinds, they are stored in INT32 and INT64 with the UINT converted types
+func isUint(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
 //order=LittleEndian or BigEndian; length is byte num
 func StrIntToBinary(num string, order string, length int, signed bool) string {
 	bigNum := new(big.Int)
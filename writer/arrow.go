@@ -29,7 +29,7 @@ type ArrowWriter struct {
 //which we will write the record along with the number of parallel threads
 //which will write in the file.
 func NewArrowWriter(arrowSchema *arrow.Schema, pfile source.ParquetFile,
-	np int64) (*ArrowWriter, error) {
+	np int64, opts ...WriterOption) (*ArrowWriter, error) {
 	var err error
 	res := new(ArrowWriter)
 	res.SchemaHandler, err = schema.NewSchemaHandlerFromArrow(arrowSchema)
@@ -49,11 +49,17 @@ func NewArrowWriter(arrowSchema *arrow.Schema, pfile source.ParquetFile,
 	res.Footer.Version = footerVersion
 	res.Footer.Schema = append(res.Footer.Schema,
 		res.SchemaHandler.SchemaElements...)
+	// The arrow schema is stored to be restored by the arrow readers
+	serializedSchema, err := schema.SerializeArrowSchema(arrowSchema)
+	if err != nil {
+		return res, errors.Wrap(err, "schema.SerializeArrowSchema")
+	}
+	res.Footer.KeyValueMetadata = append(res.Footer.KeyValueMetadata,
+		&parquet.KeyValue{Key: schema.ArrowSchemaKey, Value: &serializedSchema})
 	res.Offset = offset
-	_, err = res.PFile.Write([]byte("PAR1"))
 	res.MarshalFunc = marshal.MarshalArrow
-	if err != nil {
-		return res, errors.Wrap(err, "res.PFile.Write")
+	if err = res.start(opts); err != nil {
+		return res, errors.Wrap(err, "res.start")
 	}
 	return res, nil
 }
@@ -64,16 +70,17 @@ func NewArrowWriter(arrowSchema *arrow.Schema, pfile source.ParquetFile,
 // can understand as it does not accepts data by columns, but rather by rows.
 func (w *ArrowWriter) WriteArrow(record array.Record) error {
 	table := make([][]interface{}, 0)
+	pos := 1
 	for i, column := range record.Columns() {
-		columnFromRecord, err := common.ArrowColToParquetCol(
+		columnFromRecord, err := common.ArrowNestedColToParquetCol(
 			record.Schema().Field(i),
 			column,
-			column.Len(),
-			w.SchemaHandler.SchemaElements[i+1])
+			w.SchemaHandler.SchemaElements[pos:])
 
 		if err != nil {
-			return errors.Wrap(err, "common.ArrowColToParquetCol")
+			return errors.Wrap(err, "common.ArrowNestedColToParquetCol")
 		}
+		pos += common.SchemaSize(w.SchemaHandler.SchemaElements[pos:])
 
 		if len(columnFromRecord) > 0 {
 			table = append(table, columnFromRecord)
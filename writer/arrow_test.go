@@ -10,6 +10,7 @@ import (
 	"github.com/apache/arrow/go/arrow/memory"
 	"github.com/sabey/parquet-go-source/buffer"
 	"github.com/sabey/parquet-go-source/writerfile"
+	"github.com/sabey/parquet-go/common"
 	"github.com/sabey/parquet-go/reader"
 	"github.com/stretchr/testify/assert"
 )
@@ -135,8 +136,9 @@ func testRecord(mem memory.Allocator) array.Record {
 	col12 := func() array.Interface {
 		ib := array.NewDate64Builder(mem)
 		defer ib.Release()
-		ib.AppendValues([]arrow.Date64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
-			nil)
+		for i := 1; i <= 10; i++ {
+			ib.Append(arrow.Date64(i * common.MillisecondsPerDay))
+		}
 		return ib.NewDate64Array()
 	}()
 	defer col12.Release()
@@ -322,3 +324,113 @@ func BenchmarkWrite(b *testing.B) {
 		_ = pw.WriteArrow(rec)
 	}
 }
+
+// nestedSchema is schema of nullable and nested fields
+var nestedSchema = arrow.NewSchema(
+	[]arrow.Field{
+		{Name: "id", Type: arrow.PrimitiveTypes.Int64, Nullable: true},
+		{Name: "list", Type: arrow.ListOf(arrow.PrimitiveTypes.Int32), Nullable: true},
+		{Name: "struct", Type: arrow.StructOf(
+			arrow.Field{Name: "a", Type: arrow.PrimitiveTypes.Int32},
+			arrow.Field{Name: "b", Type: arrow.BinaryTypes.String, Nullable: true},
+		), Nullable: true},
+		{Name: "fixed", Type: arrow.FixedSizeListOf(2, arrow.PrimitiveTypes.Float64)},
+		{Name: "lists", Type: arrow.ListOf(arrow.ListOf(arrow.BinaryTypes.String))},
+	},
+	nil,
+)
+
+func nestedRecord(mem memory.Allocator) array.Record {
+	b := array.NewRecordBuilder(mem, nestedSchema)
+	defer b.Release()
+
+	ids := b.Field(0).(*array.Int64Builder)
+	list := b.Field(1).(*array.ListBuilder)
+	listValues := list.ValueBuilder().(*array.Int32Builder)
+	st := b.Field(2).(*array.StructBuilder)
+	stA := st.FieldBuilder(0).(*array.Int32Builder)
+	stB := st.FieldBuilder(1).(*array.StringBuilder)
+	fixed := b.Field(3).(*array.FixedSizeListBuilder)
+	fixedValues := fixed.ValueBuilder().(*array.Float64Builder)
+	lists := b.Field(4).(*array.ListBuilder)
+	listsList := lists.ValueBuilder().(*array.ListBuilder)
+	listsValues := listsList.ValueBuilder().(*array.StringBuilder)
+	for i := 0; i < 4; i++ {
+		if i == 1 {
+			ids.AppendNull()
+			list.AppendNull()
+			st.AppendNull()
+		} else {
+			ids.Append(int64(i))
+			list.Append(true)
+			for j := 0; j < i; j++ {
+				if j == 1 {
+					listValues.AppendNull()
+				} else {
+					listValues.Append(int32(j))
+				}
+			}
+			st.Append(true)
+			stA.Append(int32(i))
+			if i == 2 {
+				stB.AppendNull()
+			} else {
+				stB.Append(fmt.Sprint("b", i))
+			}
+		}
+		fixed.Append(true)
+		fixedValues.AppendValues([]float64{float64(i), float64(i) / 2}, nil)
+		lists.Append(true)
+		for j := 0; j < i%3; j++ {
+			listsList.Append(true)
+			for k := 0; k < j+1; k++ {
+				listsValues.Append(fmt.Sprintf("%d%d%d", i, j, k))
+			}
+		}
+	}
+	return b.NewRecord()
+}
+
+func TestE2ENestedValid(t *testing.T) {
+	buf := new(bytes.Buffer)
+	fw := writerfile.NewWriterFile(buf)
+	w, err := NewArrowWriter(nestedSchema, fw, 2)
+	assert.Nil(t, err)
+
+	mem := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	rec := nestedRecord(mem)
+	defer rec.Release()
+	assert.Nil(t, w.WriteArrow(rec))
+	assert.Nil(t, w.WriteStop())
+
+	pr, err := reader.NewParquetColumnReader(buffer.NewBufferFileFromBytes(buf.Bytes()), 1)
+	assert.Nil(t, err)
+	expected := []struct {
+		path   string
+		values []interface{}
+		rls    []int32
+		dls    []int32
+	}{
+		{"Id", []interface{}{int64(0), nil, int64(2), int64(3)},
+			[]int32{0, 0, 0, 0}, []int32{1, 0, 1, 1}},
+		{"List\x01List\x01Element", []interface{}{nil, nil, int32(0), nil, int32(0), nil, int32(2)},
+			[]int32{0, 0, 0, 1, 0, 1, 1}, []int32{1, 0, 3, 2, 3, 2, 3}},
+		{"Struct\x01A", []interface{}{int32(0), nil, int32(2), int32(3)},
+			[]int32{0, 0, 0, 0}, []int32{1, 0, 1, 1}},
+		{"Struct\x01B", []interface{}{"b0", nil, nil, "b3"},
+			[]int32{0, 0, 0, 0}, []int32{2, 0, 1, 2}},
+		{"Fixed\x01List\x01Element", []interface{}{0.0, 0.0, 1.0, 0.5, 2.0, 1.0, 3.0, 1.5},
+			[]int32{0, 1, 0, 1, 0, 1, 0, 1}, []int32{2, 2, 2, 2, 2, 2, 2, 2}},
+		{"Lists\x01List\x01Element\x01List\x01Element", []interface{}{nil, "100", "200", "210", "211", nil},
+			[]int32{0, 0, 0, 1, 2, 0}, []int32{0, 4, 4, 4, 4, 0}},
+	}
+	assert.Equal(t, len(expected), len(pr.SchemaHandler.ValueColumns))
+	for _, column := range expected {
+		values, rls, dls, err := pr.ReadColumnByPath("Parquet45go45root\x01"+column.path, 100)
+		assert.Nil(t, err)
+		assert.Equal(t, column.values, values, column.path)
+		assert.Equal(t, column.rls, rls, column.path)
+		assert.Equal(t, column.dls, dls, column.path)
+	}
+	pr.ReadStop()
+}
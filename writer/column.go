@@ -0,0 +1,172 @@
+package writer
+
+import (
+	"github.com/pkg/errors"
+	"github.com/sabey/parquet-go/common"
+	"github.com/sabey/parquet-go/layout"
+	"github.com/sabey/parquet-go/parquet"
+)
+
+//writeColumn encodes the levels and the values of a column in pages. The values are set by setValue
+//from the values of the levels equal to the maximum definition level.
+func (pw *ParquetWriter) writeColumn(pathStr string, dataType parquet.Type, numValues int, defLevels []int16, repLevels []int16,
+	setValue func(values []interface{}, i int, j int)) error {
+	if len(pw.Objs) > 0 || pw.NumRows > 0 {
+		return errors.New("columns can't be written in a row group written by rows")
+	}
+
+	pathStr, err := pw.SchemaHandler.ConvertToInPathStr(pathStr)
+	if err != nil {
+		return errors.Wrap(err, "pw.SchemaHandler.ConvertToInPathStr")
+	}
+	index, ok := pw.SchemaHandler.MapIndex[pathStr]
+	if !ok {
+		return errors.Errorf("path %v not found", pathStr)
+	}
+	schema := pw.SchemaHandler.SchemaElements[index]
+	if schema.GetNumChildren() > 0 || !schema.IsSetType() || schema.GetType() != dataType {
+		return errors.Errorf("column %v is not %v", pathStr, dataType)
+	}
+
+	table := layout.NewEmptyTable()
+	table.Path = common.StrToPath(pathStr)
+	table.MaxDefinitionLevel, _ = pw.SchemaHandler.MaxDefinitionLevel(table.Path)
+	table.MaxRepetitionLevel, _ = pw.SchemaHandler.MaxRepetitionLevel(table.Path)
+	table.RepetitionType = schema.GetRepetitionType()
+	table.Schema = schema
+	table.Info = pw.SchemaHandler.Infos[index]
+
+	numLevels := numValues
+	if table.MaxDefinitionLevel > 0 {
+		numLevels = len(defLevels)
+	} else if table.MaxRepetitionLevel > 0 {
+		numLevels = len(repLevels)
+	}
+	if (table.MaxDefinitionLevel > 0 && defLevels == nil) || (table.MaxRepetitionLevel > 0 && len(repLevels) != numLevels) ||
+		(defLevels != nil && len(defLevels) != numLevels) || (repLevels != nil && len(repLevels) != numLevels) {
+		return errors.Errorf("invalid levels of %v", pathStr)
+	}
+
+	table.Values = make([]interface{}, numLevels)
+	table.DefinitionLevels = make([]int32, numLevels)
+	table.RepetitionLevels = make([]int32, numLevels)
+	var numRows int64
+	j := 0
+	for i := 0; i < numLevels; i++ {
+		dl, rl := table.MaxDefinitionLevel, int32(0)
+		if defLevels != nil {
+			dl = int32(defLevels[i])
+		}
+		if repLevels != nil {
+			rl = int32(repLevels[i])
+		}
+		if dl < 0 || dl > table.MaxDefinitionLevel || rl < 0 || rl > table.MaxRepetitionLevel || (i == 0 && rl != 0) {
+			return errors.Errorf("invalid levels of %v at %v", pathStr, i)
+		}
+		table.DefinitionLevels[i], table.RepetitionLevels[i] = dl, rl
+		if dl == table.MaxDefinitionLevel {
+			if j >= numValues {
+				return errors.Errorf("too few values of %v", pathStr)
+			}
+			setValue(table.Values, i, j)
+			if dataType == parquet.Type_FIXED_LEN_BYTE_ARRAY && len(table.Values[i].(string)) != int(schema.GetTypeLength()) {
+				return errors.Errorf("value %v of %v has %v bytes, %v expected", j, pathStr, len(table.Values[i].(string)), schema.GetTypeLength())
+			}
+			j++
+		}
+		if rl == 0 {
+			numRows++
+		}
+	}
+	if j != numValues {
+		return errors.Errorf("%v values of %v, %v expected", numValues, pathStr, j)
+	}
+
+	pages, err := pw.tableToPages(pathStr, table, nil)
+	if err != nil {
+		return errors.Wrap(err, "pw.tableToPages")
+	}
+	pw.PagesMapBuf[pathStr] = append(pw.PagesMapBuf[pathStr], pages...)
+	for _, page := range pages {
+		pw.Size += int64(len(page.RawData))
+		page.DataTable = nil //release memory
+	}
+
+	if pw.ColumnNumRows == nil {
+		pw.ColumnNumRows = make(map[string]int64)
+	}
+	pw.ColumnNumRows[pathStr] += numRows
+	return nil
+}
+
+//flushColumns checks that all the columns of the row group written by columns have the same number of rows
+func (pw *ParquetWriter) flushColumns() error {
+	if len(pw.ColumnNumRows) == 0 {
+		return nil
+	}
+	numRows := int64(-1)
+	for _, pathStr := range pw.SchemaHandler.ValueColumns {
+		n := pw.ColumnNumRows[pathStr]
+		if numRows >= 0 && n != numRows {
+			return errors.Errorf("%v rows in column %v, %v expected", n, pathStr, numRows)
+		}
+		numRows = n
+	}
+	pw.NumRows += numRows
+	pw.Footer.NumRows += numRows
+	pw.ColumnNumRows = nil
+	return nil
+}
+
+//WriteBooleans writes a batch of a BOOLEAN column. The values are those of the levels which are not null,
+//the level slices can be nil if the column has no such levels. The batch holds whole rows, and all the columns
+//of a row group must be written with the same number of rows before it's flushed with Flush(true) or WriteStop.
+//A row group is written either by columns or by rows.
+func (pw *ParquetWriter) WriteBooleans(pathStr string, values []bool, defLevels []int16, repLevels []int16) error {
+	return pw.writeColumn(pathStr, parquet.Type_BOOLEAN, len(values), defLevels, repLevels, func(vs []interface{}, i int, j int) {
+		vs[i] = values[j]
+	})
+}
+
+//WriteInt32s writes a batch of an INT32 column like WriteBooleans
+func (pw *ParquetWriter) WriteInt32s(pathStr string, values []int32, defLevels []int16, repLevels []int16) error {
+	return pw.writeColumn(pathStr, parquet.Type_INT32, len(values), defLevels, repLevels, func(vs []interface{}, i int, j int) {
+		vs[i] = values[j]
+	})
+}
+
+//WriteInt64s writes a batch of an INT64 column like WriteBooleans
+func (pw *ParquetWriter) WriteInt64s(pathStr string, values []int64, defLevels []int16, repLevels []int16) error {
+	return pw.writeColumn(pathStr, parquet.Type_INT64, len(values), defLevels, repLevels, func(vs []interface{}, i int, j int) {
+		vs[i] = values[j]
+	})
+}
+
+//WriteFloat32s writes a batch of a FLOAT column like WriteBooleans
+func (pw *ParquetWriter) WriteFloat32s(pathStr string, values []float32, defLevels []int16, repLevels []int16) error {
+	return pw.writeColumn(pathStr, parquet.Type_FLOAT, len(values), defLevels, repLevels, func(vs []interface{}, i int, j int) {
+		vs[i] = values[j]
+	})
+}
+
+//WriteFloat64s writes a batch of a DOUBLE column like WriteBooleans
+func (pw *ParquetWriter) WriteFloat64s(pathStr string, values []float64, defLevels []int16, repLevels []int16) error {
+	return pw.writeColumn(pathStr, parquet.Type_DOUBLE, len(values), defLevels, repLevels, func(vs []interface{}, i int, j int) {
+		vs[i] = values[j]
+	})
+}
+
+//WriteByteArrays writes a batch of a BYTE_ARRAY column like WriteBooleans
+func (pw *ParquetWriter) WriteByteArrays(pathStr string, values [][]byte, defLevels []int16, repLevels []int16) error {
+	return pw.writeColumn(pathStr, parquet.Type_BYTE_ARRAY, len(values), defLevels, repLevels, func(vs []interface{}, i int, j int) {
+		vs[i] = string(values[j])
+	})
+}
+
+//WriteFixedLenByteArrays writes a batch of a FIXED_LEN_BYTE_ARRAY column like WriteBooleans. The values must have
+//the length of the column.
+func (pw *ParquetWriter) WriteFixedLenByteArrays(pathStr string, values [][]byte, defLevels []int16, repLevels []int16) error {
+	return pw.writeColumn(pathStr, parquet.Type_FIXED_LEN_BYTE_ARRAY, len(values), defLevels, repLevels, func(vs []interface{}, i int, j int) {
+		vs[i] = string(values[j])
+	})
+}
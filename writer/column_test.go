@@ -0,0 +1,119 @@
+package writer
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sabey/parquet-go-source/buffer"
+	"github.com/sabey/parquet-go-source/writerfile"
+	"github.com/sabey/parquet-go/reader"
+	"github.com/stretchr/testify/assert"
+)
+
+type columnRecord struct {
+	ID    int64    `parquet:"name=id, type=INT64"`
+	Name  string   `parquet:"name=name, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Score *float64 `parquet:"name=score, type=DOUBLE"`
+	Tags  []int32  `parquet:"name=tags, type=INT32, repetitiontype=REPEATED"`
+}
+
+func TestWriteColumns(t *testing.T) {
+	buf := new(bytes.Buffer)
+	pw, err := NewParquetWriter(writerfile.NewWriterFile(buf), new(columnRecord), 1)
+	assert.Nil(t, err)
+	pw.PageSize = 64
+
+	//2 row groups of 2 batches of 50 rows
+	for rowGroup := 0; rowGroup < 2; rowGroup++ {
+		for batch := 0; batch < 2; batch++ {
+			first := int64(rowGroup*100 + batch*50)
+			var (
+				scoreDefs, tagDefs, tagReps []int16
+				ids                         []int64
+				names                       [][]byte
+				scores                      []float64
+				tags                        []int32
+			)
+			for id := first; id < first+50; id++ {
+				ids = append(ids, id)
+				names = append(names, []byte{byte('a' + id%26)})
+				if id%3 == 0 {
+					scoreDefs = append(scoreDefs, 0)
+				} else {
+					scoreDefs = append(scoreDefs, 1)
+					scores = append(scores, float64(id)/2)
+				}
+				if id%4 == 0 {
+					tagDefs, tagReps = append(tagDefs, 0), append(tagReps, 0)
+				}
+				for i := int64(0); i < id%4; i++ {
+					tagDefs, tagReps = append(tagDefs, 1), append(tagReps, 1)
+					if i == 0 {
+						tagReps[len(tagReps)-1] = 0
+					}
+					tags = append(tags, int32(id))
+				}
+			}
+			assert.Nil(t, pw.WriteInt64s("parquet_go_root\x01id", ids, nil, nil))
+			assert.Nil(t, pw.WriteByteArrays("parquet_go_root\x01name", names, nil, nil))
+			assert.Nil(t, pw.WriteFloat64s("parquet_go_root\x01score", scores, scoreDefs, nil))
+			assert.Nil(t, pw.WriteInt32s("parquet_go_root\x01tags", tags, tagDefs, tagReps))
+		}
+		assert.NotNil(t, pw.Write(columnRecord{}))
+		assert.Nil(t, pw.Flush(true))
+	}
+	assert.Nil(t, pw.WriteStop())
+
+	pr, err := reader.NewParquetReader(buffer.NewBufferFileFromBytes(buf.Bytes()), new(columnRecord), 1)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(200), pr.GetNumRows())
+	assert.Equal(t, 2, len(pr.Footer.RowGroups))
+	recs := make([]columnRecord, 200)
+	assert.Nil(t, pr.Read(&recs))
+	for id, rec := range recs {
+		expected := columnRecord{ID: int64(id), Name: string(rune('a' + id%26))}
+		if id%3 != 0 {
+			score := float64(id) / 2
+			expected.Score = &score
+		}
+		for i := 0; i < id%4; i++ {
+			expected.Tags = append(expected.Tags, int32(id))
+		}
+		assert.Equal(t, expected, rec)
+	}
+	pr.ReadStop()
+}
+
+func TestWriteColumnsError(t *testing.T) {
+	pw, err := NewParquetWriter(writerfile.NewWriterFile(new(bytes.Buffer)), new(columnRecord), 1)
+	assert.Nil(t, err)
+	assert.NotNil(t, pw.WriteInt32s("parquet_go_root\x01id", []int32{1}, nil, nil))
+	assert.NotNil(t, pw.WriteFloat64s("parquet_go_root\x01score", []float64{1}, nil, nil))
+	assert.NotNil(t, pw.WriteFloat64s("parquet_go_root\x01score", []float64{1}, []int16{1, 1}, nil))
+	assert.NotNil(t, pw.WriteInt32s("parquet_go_root\x01tags", []int32{1}, []int16{1}, []int16{1}))
+
+	//the columns of a row group have the same number of rows
+	assert.Nil(t, pw.WriteInt64s("parquet_go_root\x01id", []int64{1}, nil, nil))
+	assert.NotNil(t, pw.Flush(true))
+}
+
+func TestWriteFixedLenByteArraysLength(t *testing.T) {
+	type record struct {
+		Code *string `parquet:"name=code, type=FIXED_LEN_BYTE_ARRAY, length=3"`
+	}
+	buf := new(bytes.Buffer)
+	pw, err := NewParquetWriter(writerfile.NewWriterFile(buf), new(record), 1)
+	assert.Nil(t, err)
+	assert.NotNil(t, pw.WriteFixedLenByteArrays("parquet_go_root\x01code", [][]byte{[]byte("abcd")}, []int16{1}, nil))
+	assert.NotNil(t, pw.WriteFixedLenByteArrays("parquet_go_root\x01code", [][]byte{[]byte("ab")}, []int16{1}, nil))
+	assert.Nil(t, pw.WriteFixedLenByteArrays("parquet_go_root\x01code", [][]byte{[]byte("abc")}, []int16{1, 0}, nil))
+	assert.Nil(t, pw.WriteStop())
+
+	pr, err := reader.NewParquetReader(buffer.NewBufferFileFromBytes(buf.Bytes()), new(record), 1)
+	assert.Nil(t, err)
+	recs := make([]record, 2)
+	assert.Nil(t, pr.Read(&recs))
+	code := "abc"
+	assert.Equal(t, []record{{Code: &code}, {}}, recs)
+	pr.ReadStop()
+}
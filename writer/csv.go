@@ -17,9 +17,9 @@ type CSVWriter struct {
 	ParquetWriter
 }
 
-func NewCSVWriterFromWriter(md []string, w io.Writer, np int64) (*CSVWriter, error) {
+func NewCSVWriterFromWriter(md []string, w io.Writer, np int64, opts ...WriterOption) (*CSVWriter, error) {
 	wf := writerfile.NewWriterFile(w)
-	cw, err := NewCSVWriter(md, wf, np)
+	cw, err := NewCSVWriter(md, wf, np, opts...)
 	if err != nil {
 		return cw, errors.Wrap(err, "NewCSVWriter")
 	}
@@ -27,7 +27,7 @@ func NewCSVWriterFromWriter(md []string, w io.Writer, np int64) (*CSVWriter, err
 }
 
 //Create CSV writer
-func NewCSVWriter(md []string, pfile source.ParquetFile, np int64) (*CSVWriter, error) {
+func NewCSVWriter(md []string, pfile source.ParquetFile, np int64, opts ...WriterOption) (*CSVWriter, error) {
 	var err error
 	res := new(CSVWriter)
 	res.SchemaHandler, err = schema.NewSchemaHandlerFromMetadata(md)
@@ -45,10 +45,9 @@ func NewCSVWriter(md []string, pfile source.ParquetFile, np int64) (*CSVWriter,
 	res.Footer.Version = 1
 	res.Footer.Schema = append(res.Footer.Schema, res.SchemaHandler.SchemaElements...)
 	res.Offset = 4
-	_, err = res.PFile.Write([]byte("PAR1"))
 	res.MarshalFunc = marshal.MarshalCSV
-	if err != nil {
-		return res, errors.Wrap(err, "res.PFile.Write")
+	if err = res.start(opts); err != nil {
+		return res, errors.Wrap(err, "res.start")
 	}
 	return res, nil
 }
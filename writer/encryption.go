@@ -0,0 +1,116 @@
+package writer
+
+import (
+	"context"
+	"math"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/pkg/errors"
+	"github.com/sabey/parquet-go/common"
+	"github.com/sabey/parquet-go/encryption"
+	"github.com/sabey/parquet-go/layout"
+	"github.com/sabey/parquet-go/parquet"
+)
+
+//chunkCipher returns the cipher and the crypto metadata of a chunk of the row group which is flushed,
+//they are nil if the chunk isn't encrypted
+func (pw *ParquetWriter) chunkCipher(chunk *layout.Chunk, columnOrdinal int) (*encryption.ChunkCipher, *parquet.ColumnCryptoMetaData, error) {
+	if pw.FileEncryptor == nil {
+		return nil, nil, nil
+	}
+	if pw.columnKeyPaths == nil {
+		pw.columnKeyPaths = make(map[string]string)
+		for path := range pw.EncryptionProperties.ColumnKeys {
+			pathStr, err := pw.SchemaHandler.ConvertToInPathStr(path)
+			if err != nil {
+				return nil, nil, errors.Wrap(err, "pw.SchemaHandler.ConvertToInPathStr")
+			}
+			pw.columnKeyPaths[pathStr] = path
+		}
+	}
+
+	cipher, cryptoMetaData := pw.FileEncryptor.ColumnCipher(pw.columnKeyPaths[common.PathToStr(chunk.ChunkHeader.MetaData.PathInSchema)])
+	if cipher == nil {
+		return nil, nil, nil
+	}
+	rowGroupOrdinal := len(pw.Footer.RowGroups)
+	if rowGroupOrdinal > math.MaxInt16 || columnOrdinal > math.MaxInt16 {
+		return nil, nil, errors.New("too many row groups or columns to encrypt")
+	}
+	chunkCipher := &encryption.ChunkCipher{
+		Cipher:          cipher,
+		RowGroupOrdinal: int16(rowGroupOrdinal),
+		ColumnOrdinal:   int16(columnOrdinal),
+	}
+	return chunkCipher, cryptoMetaData, nil
+}
+
+//encryptChunk encrypts the pages of a chunk
+func encryptChunk(chunk *layout.Chunk, chunkCipher *encryption.ChunkCipher) error {
+	var totalCompressedSize int64
+	pageOrdinal := 0
+	for _, page := range chunk.Pages {
+		if pageOrdinal > math.MaxInt16 {
+			return errors.New("too many pages to encrypt")
+		}
+		if err := page.Encrypt(chunkCipher, int16(pageOrdinal)); err != nil {
+			return errors.Wrap(err, "page.Encrypt")
+		}
+		if page.Header.GetType() != parquet.PageType_DICTIONARY_PAGE {
+			pageOrdinal++
+		}
+		totalCompressedSize += int64(len(page.RawData))
+	}
+	chunk.ChunkHeader.MetaData.TotalCompressedSize = totalCompressedSize
+	return nil
+}
+
+//encryptColumnMetaData sets the crypto metadata of the encrypted chunks and encrypts their metadata.
+//It's kept in plaintext without statistics for a plaintext footer, the encrypted footer
+//protects the metadata of the chunks encrypted with the footer key.
+func (pw *ParquetWriter) encryptColumnMetaData() error {
+	ts := thrift.NewTSerializer()
+	ts.Protocol = thrift.NewTCompactProtocolFactory().GetProtocol(ts.Transport)
+	plaintextFooter := pw.EncryptionProperties.PlaintextFooter
+
+	idx := 0
+	for _, rowGroup := range pw.Footer.RowGroups {
+		for _, columnChunk := range rowGroup.Columns {
+			chunkCipher, cryptoMetaData := pw.ChunkCiphers[idx], pw.ColumnCryptoMetaData[idx]
+			idx++
+			if chunkCipher == nil {
+				continue
+			}
+
+			columnChunk.CryptoMetadata = cryptoMetaData
+			columnKey := cryptoMetaData.IsSetENCRYPTION_WITH_COLUMN_KEY()
+			if columnKey {
+				cryptoMetaData.ENCRYPTION_WITH_COLUMN_KEY.PathInSchema = columnChunk.MetaData.PathInSchema
+			} else if !plaintextFooter {
+				continue
+			}
+
+			metaDataBuf, err := ts.Write(context.TODO(), columnChunk.MetaData)
+			if err != nil {
+				return errors.Wrap(err, "ts.Write")
+			}
+			if columnChunk.EncryptedColumnMetadata, err = chunkCipher.Encrypt(metaDataBuf, encryption.ColumnMetaData, 0); err != nil {
+				return errors.Wrap(err, "chunkCipher.Encrypt")
+			}
+
+			if plaintextFooter {
+				metaData := *columnChunk.MetaData
+				metaData.Statistics = nil
+				columnChunk.MetaData = &metaData
+			} else {
+				columnChunk.MetaData = nil
+			}
+		}
+	}
+
+	if plaintextFooter {
+		pw.Footer.EncryptionAlgorithm = pw.FileEncryptor.EncryptionAlgorithm
+		pw.Footer.FooterSigningKeyMetadata = pw.EncryptionProperties.FooterKeyMetadata
+	}
+	return nil
+}
@@ -16,9 +16,9 @@ type JSONWriter struct {
 	ParquetWriter
 }
 
-func NewJSONWriterFromWriter(jsonSchema string, w io.Writer, np int64) (*JSONWriter, error) {
+func NewJSONWriterFromWriter(jsonSchema string, w io.Writer, np int64, opts ...WriterOption) (*JSONWriter, error) {
 	wf := writerfile.NewWriterFile(w)
-	jw, err := NewJSONWriter(jsonSchema, wf, np)
+	jw, err := NewJSONWriter(jsonSchema, wf, np, opts...)
 	if err != nil {
 		return jw, errors.Wrap(err, "NewJSONWriter")
 	}
@@ -26,7 +26,7 @@ func NewJSONWriterFromWriter(jsonSchema string, w io.Writer, np int64) (*JSONWri
 }
 
 //Create JSON writer
-func NewJSONWriter(jsonSchema string, pfile source.ParquetFile, np int64) (*JSONWriter, error) {
+func NewJSONWriter(jsonSchema string, pfile source.ParquetFile, np int64, opts ...WriterOption) (*JSONWriter, error) {
 	var err error
 	res := new(JSONWriter)
 	res.SchemaHandler, err = schema.NewSchemaHandlerFromJSON(jsonSchema)
@@ -45,10 +45,9 @@ func NewJSONWriter(jsonSchema string, pfile source.ParquetFile, np int64) (*JSON
 	res.Footer.Version = 1
 	res.Footer.Schema = append(res.Footer.Schema, res.SchemaHandler.SchemaElements...)
 	res.Offset = 4
-	_, err = res.PFile.Write([]byte("PAR1"))
 	res.MarshalFunc = marshal.MarshalJSON
-	if err != nil {
-		return res, errors.Wrap(err, "res.PFile.Write")
+	if err = res.start(opts); err != nil {
+		return res, errors.Wrap(err, "res.start")
 	}
 	return res, nil
 }
@@ -4,13 +4,19 @@ import (
 	"context"
 	"encoding/binary"
 	"io"
+	"math"
 	"reflect"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/apache/thrift/lib/go/thrift"
 	"github.com/pkg/errors"
 	"github.com/sabey/parquet-go-source/writerfile"
+	"github.com/sabey/parquet-go/bloomfilter"
 	"github.com/sabey/parquet-go/common"
+	"github.com/sabey/parquet-go/compress"
+	"github.com/sabey/parquet-go/encryption"
 	"github.com/sabey/parquet-go/layout"
 	"github.com/sabey/parquet-go/marshal"
 	"github.com/sabey/parquet-go/parquet"
@@ -24,11 +30,26 @@ type ParquetWriter struct {
 	NP            int64 //parallel number
 	Footer        *parquet.FileMetaData
 	PFile         source.ParquetFile
+	//Infer the schema of the struct objects from their Go types, see schema.NewSchemaHandlerFromStructInferred
+	InferSchema bool
 
 	PageSize        int64
 	RowGroupSize    int64
 	CompressionType parquet.CompressionCodec
 	Offset          int64
+	//Options of the compressors of the codecs, the registered compressors are used for the other codecs
+	CodecOptions map[parquet.CompressionCodec]compress.Options
+	//Compressors of the codecs used by the writer
+	compressors map[parquet.CompressionCodec]*compress.Compressor
+	//Codecs of the columns by path, they override CompressionType and the compression tags of the columns
+	ColumnCompression map[string]parquet.CompressionCodec
+	//Codecs of ColumnCompression by internal path
+	columnCodecs map[string]parquet.CompressionCodec
+	//Write the CRC32 checksums of the data and dictionary pages, they are verified by the readers
+	PageChecksum bool
+	//Version of the data pages, 1 for DATA_PAGE (default) and 2 for DATA_PAGE_V2.
+	//The datapageversion tag of a column overrides it.
+	DataPageVersion int32
 
 	Objs              []interface{}
 	ObjsSize          int64
@@ -38,18 +59,102 @@ type ParquetWriter struct {
 	PagesMapBuf map[string][]*layout.Page
 	Size        int64
 	NumRows     int64
+	//Rows of the columns of the current row group written by columns
+	ColumnNumRows map[string]int64
 
 	DictRecs map[string]*layout.DictRecType
+	//Maximum size of the dictionaries of the chunks, 0 means layout.DefaultDictSizeLimit and a negative
+	//size no limit. The pages are written in PLAIN once it's hit. The dictsizelimit tag of a column overrides it.
+	DictSizeLimit int64
+
+	//Choose the encoding of the columns without one in their tag from their values, see layout.ChooseEncoding.
+	//The columns whose tag sets an encoding, PLAIN too, keep it.
+	AdaptiveEncoding bool
+	//Encodings chosen for the columns of the current row group by the adaptive encoding
+	columnEncodings map[string]parquet.Encoding
 
 	ColumnIndexes []*parquet.ColumnIndex
 	OffsetIndexes []*parquet.OffsetIndex
 
+	//False positive probability of the Bloom filters, 0 means bloomfilter.DefaultFPP
+	BloomFilterFPP float64
+	//Hashes of the values of the columns with a Bloom filter in the current row group
+	BloomFilterHashes map[string][]uint64
+	BloomFilters      []*bloomfilter.Filter
+
+	//The file is encrypted if it's set
+	EncryptionProperties *encryption.FileEncryptionProperties
+	FileEncryptor        *encryption.FileEncryptor
+	//Ciphers and crypto metadata of the chunks, they are nil for plaintext chunks
+	ChunkCiphers         []*encryption.ChunkCipher
+	ColumnCryptoMetaData []*parquet.ColumnCryptoMetaData
+	//Paths of ColumnKeys by internal path
+	columnKeyPaths map[string]string
+
 	MarshalFunc func(src []interface{}, sh *schema.SchemaHandler) (*map[string]*layout.Table, error)
 }
 
-func NewParquetWriterFromWriter(w io.Writer, obj interface{}, np int64) (*ParquetWriter, error) {
+//WriterOption configures a parquet writer when it is created
+type WriterOption func(*ParquetWriter)
+
+//WithEncryption encrypts the file with the properties
+func WithEncryption(properties *encryption.FileEncryptionProperties) WriterOption {
+	return func(pw *ParquetWriter) {
+		pw.EncryptionProperties = properties
+	}
+}
+
+//WithCodecOptions compresses the pages of a codec with a compressor created with the options
+func WithCodecOptions(codec parquet.CompressionCodec, opts compress.Options) WriterOption {
+	return func(pw *ParquetWriter) {
+		if pw.CodecOptions == nil {
+			pw.CodecOptions = make(map[parquet.CompressionCodec]compress.Options)
+		}
+		pw.CodecOptions[codec] = opts
+	}
+}
+
+//WithColumnCompression compresses the pages of the column with the path with a codec
+func WithColumnCompression(path string, codec parquet.CompressionCodec) WriterOption {
+	return func(pw *ParquetWriter) {
+		if pw.ColumnCompression == nil {
+			pw.ColumnCompression = make(map[string]parquet.CompressionCodec)
+		}
+		pw.ColumnCompression[path] = codec
+	}
+}
+
+//WithDataPageVersion writes the data pages of the version, 1 for DATA_PAGE and 2 for DATA_PAGE_V2
+func WithDataPageVersion(version int32) WriterOption {
+	return func(pw *ParquetWriter) {
+		pw.DataPageVersion = version
+	}
+}
+
+//WithDictSizeLimit sets the maximum size of the dictionaries of the chunks, a negative size means no limit
+func WithDictSizeLimit(size int64) WriterOption {
+	return func(pw *ParquetWriter) {
+		pw.DictSizeLimit = size
+	}
+}
+
+//WithAdaptiveEncoding chooses the encoding of the columns without one in their tag from their values
+func WithAdaptiveEncoding() WriterOption {
+	return func(pw *ParquetWriter) {
+		pw.AdaptiveEncoding = true
+	}
+}
+
+//WithSchemaInference infers the schema of the struct object from its Go types, the parquet tags only override it
+func WithSchemaInference() WriterOption {
+	return func(pw *ParquetWriter) {
+		pw.InferSchema = true
+	}
+}
+
+func NewParquetWriterFromWriter(w io.Writer, obj interface{}, np int64, opts ...WriterOption) (*ParquetWriter, error) {
 	wf := writerfile.NewWriterFile(w)
-	pw, err := NewParquetWriter(wf, obj, np)
+	pw, err := NewParquetWriter(wf, obj, np, opts...)
 	if err != nil {
 		return pw, errors.Wrap(err, "NewParquetWriter")
 	}
@@ -57,7 +162,7 @@ func NewParquetWriterFromWriter(w io.Writer, obj interface{}, np int64) (*Parque
 }
 
 //Create a parquet handler. Obj is a object with tags or JSON schema string.
-func NewParquetWriter(pFile source.ParquetFile, obj interface{}, np int64) (*ParquetWriter, error) {
+func NewParquetWriter(pFile source.ParquetFile, obj interface{}, np int64, opts ...WriterOption) (*ParquetWriter, error) {
 	var err error
 
 	res := new(ParquetWriter)
@@ -79,10 +184,12 @@ func NewParquetWriter(pFile source.ParquetFile, obj interface{}, np int64) (*Par
 	res.OffsetIndexes = make([]*parquet.OffsetIndex, 0)
 	//include the createdBy to avoid
 	//WARN  CorruptStatistics:118 - Ignoring statistics because created_by is null or empty! See PARQUET-251 and PARQUET-297
-	createdBy := "parquet-go version latest"
+	createdBy := common.CreatedBy
 	res.Footer.CreatedBy = &createdBy
-	_, err = res.PFile.Write([]byte("PAR1"))
 	res.MarshalFunc = marshal.Marshal
+	if err = res.start(opts); err != nil {
+		return res, errors.Wrap(err, "res.start")
+	}
 
 	if obj != nil {
 		if sa, ok := obj.(string); ok {
@@ -93,7 +200,12 @@ func NewParquetWriter(pFile source.ParquetFile, obj interface{}, np int64) (*Par
 			res.SchemaHandler = schema.NewSchemaHandlerFromSchemaList(sa)
 
 		} else {
-			if res.SchemaHandler, err = schema.NewSchemaHandlerFromStruct(obj); err != nil {
+			if res.InferSchema {
+				res.SchemaHandler, err = schema.NewSchemaHandlerFromStructInferred(obj)
+			} else {
+				res.SchemaHandler, err = schema.NewSchemaHandlerFromStruct(obj)
+			}
+			if err != nil {
 				return res, errors.Wrap(err, "schema.NewSchemaHandlerFromStruct")
 			}
 		}
@@ -101,12 +213,36 @@ func NewParquetWriter(pFile source.ParquetFile, obj interface{}, np int64) (*Par
 		res.Footer.Schema = append(res.Footer.Schema, res.SchemaHandler.SchemaElements...)
 	}
 
-	if err != nil {
-		return res, errors.Wrap(err, "res.PFile.Write")
-	}
 	return res, nil
 }
 
+//start applies the options and writes the magic number
+func (pw *ParquetWriter) start(opts []WriterOption) error {
+	var err error
+	for _, opt := range opts {
+		opt(pw)
+	}
+	if pw.DataPageVersion < 0 || pw.DataPageVersion > 2 {
+		return errors.Errorf("unknown data page version: %v", pw.DataPageVersion)
+	}
+	for codec := range pw.CodecOptions {
+		if _, err = pw.compressor(codec, nil); err != nil {
+			return errors.Wrap(err, "pw.compressor")
+		}
+	}
+	magic := []byte("PAR1")
+	if pw.EncryptionProperties != nil {
+		if pw.FileEncryptor, err = encryption.NewFileEncryptor(pw.EncryptionProperties); err != nil {
+			return errors.Wrap(err, "encryption.NewFileEncryptor")
+		}
+		magic = pw.FileEncryptor.Magic()
+	}
+	if _, err = pw.PFile.Write(magic); err != nil {
+		return errors.Wrap(err, "pw.PFile.Write")
+	}
+	return nil
+}
+
 func (pw *ParquetWriter) SetSchemaHandlerFromJSON(jsonSchema string) error {
 	var err error
 	if pw.SchemaHandler, err = schema.NewSchemaHandlerFromJSON(jsonSchema); err != nil {
@@ -134,28 +270,85 @@ func (pw *ParquetWriter) RenameSchema() {
 
 //Write the footer and stop writing
 func (pw *ParquetWriter) WriteStop() error {
+	return pw.WriteStopContext(context.Background())
+}
+
+//Write the footer and stop writing, it returns ctx.Err() when ctx is done before the footer is written.
+//The file is incomplete and the writer can't be used after the write is cancelled.
+func (pw *ParquetWriter) WriteStopContext(ctx context.Context) error {
 	var err error
 
-	if err = pw.Flush(true); err != nil {
-		return errors.Wrap(err, "pw.Flush")
+	if err = pw.FlushContext(ctx, true); err != nil {
+		return errors.Wrap(err, "pw.FlushContext")
 	}
 	ts := thrift.NewTSerializer()
 	ts.Protocol = thrift.NewTCompactProtocolFactory().GetProtocol(ts.Transport)
 	pw.RenameSchema()
 
-	// write ColumnIndex
+	// write BloomFilter
 	idx := 0
 	for _, rowGroup := range pw.Footer.RowGroups {
 		for _, columnChunk := range rowGroup.Columns {
-			columnIndexBuf, err := ts.Write(context.TODO(), pw.ColumnIndexes[idx])
+			bloomFilter := pw.BloomFilters[idx]
+			idx++
+			if bloomFilter == nil {
+				continue
+			}
+			if err = ctx.Err(); err != nil {
+				return errors.Wrap(err, "ctx.Err")
+			}
+
+			headerBuf, err := ts.Write(ctx, bloomFilter.Header())
 			if err != nil {
 				return errors.Wrap(err, "ts.Write")
 			}
-			if _, err = pw.PFile.Write(columnIndexBuf); err != nil {
+			bitsetBuf := bloomFilter.Bytes()
+			if chunkCipher := pw.ChunkCiphers[idx-1]; chunkCipher != nil {
+				if headerBuf, err = chunkCipher.Encrypt(headerBuf, encryption.BloomFilterHeader, 0); err != nil {
+					return errors.Wrap(err, "chunkCipher.Encrypt")
+				}
+				if bitsetBuf, err = chunkCipher.Encrypt(bitsetBuf, encryption.BloomFilterBitset, 0); err != nil {
+					return errors.Wrap(err, "chunkCipher.Encrypt")
+				}
+			}
+			buf := append(headerBuf, bitsetBuf...)
+			if _, err = pw.PFile.Write(buf); err != nil {
 				return errors.Wrap(err, "pw.PFile.Write")
 			}
 
+			pos, length := pw.Offset, int32(len(buf))
+			columnChunk.MetaData.BloomFilterOffset = &pos
+			columnChunk.MetaData.BloomFilterLength = &length
+
+			pw.Offset += int64(len(buf))
+		}
+	}
+
+	// write ColumnIndex
+	idx = 0
+	for _, rowGroup := range pw.Footer.RowGroups {
+		for _, columnChunk := range rowGroup.Columns {
+			columnIndex := pw.ColumnIndexes[idx]
 			idx++
+			if columnIndex == nil {
+				continue
+			}
+			if err = ctx.Err(); err != nil {
+				return errors.Wrap(err, "ctx.Err")
+			}
+
+			columnIndexBuf, err := ts.Write(ctx, columnIndex)
+			if err != nil {
+				return errors.Wrap(err, "ts.Write")
+			}
+			if chunkCipher := pw.ChunkCiphers[idx-1]; chunkCipher != nil {
+				if columnIndexBuf, err = chunkCipher.Encrypt(columnIndexBuf, encryption.ColumnIndex, 0); err != nil {
+					return errors.Wrap(err, "chunkCipher.Encrypt")
+				}
+			}
+			if _, err = pw.PFile.Write(columnIndexBuf); err != nil {
+				return errors.Wrap(err, "pw.PFile.Write")
+			}
 
 			pos := pw.Offset
 			columnChunk.ColumnIndexOffset = &pos
@@ -170,10 +363,18 @@ func (pw *ParquetWriter) WriteStop() error {
 	idx = 0
 	for _, rowGroup := range pw.Footer.RowGroups {
 		for _, columnChunk := range rowGroup.Columns {
-			offsetIndexBuf, err := ts.Write(context.TODO(), pw.OffsetIndexes[idx])
+			if err = ctx.Err(); err != nil {
+				return errors.Wrap(err, "ctx.Err")
+			}
+			offsetIndexBuf, err := ts.Write(ctx, pw.OffsetIndexes[idx])
 			if err != nil {
 				return errors.Wrap(err, "ts.Write")
 			}
+			if chunkCipher := pw.ChunkCiphers[idx]; chunkCipher != nil {
+				if offsetIndexBuf, err = chunkCipher.Encrypt(offsetIndexBuf, encryption.OffsetIndex, 0); err != nil {
+					return errors.Wrap(err, "chunkCipher.Encrypt")
+				}
+			}
 			if _, err = pw.PFile.Write(offsetIndexBuf); err != nil {
 				return errors.Wrap(err, "pw.PFile.Write")
 			}
@@ -189,10 +390,23 @@ func (pw *ParquetWriter) WriteStop() error {
 		}
 	}
 
-	footerBuf, err := ts.Write(context.TODO(), pw.Footer)
+	magic := []byte("PAR1")
+	if pw.FileEncryptor != nil {
+		if err = pw.encryptColumnMetaData(); err != nil {
+			return errors.Wrap(err, "pw.encryptColumnMetaData")
+		}
+		magic = pw.FileEncryptor.Magic()
+	}
+
+	footerBuf, err := ts.Write(ctx, pw.Footer)
 	if err != nil {
 		return errors.Wrap(err, "ts.Write")
 	}
+	if pw.FileEncryptor != nil {
+		if footerBuf, err = pw.FileEncryptor.EncryptFooter(footerBuf); err != nil {
+			return errors.Wrap(err, "pw.FileEncryptor.EncryptFooter")
+		}
+	}
 
 	if _, err = pw.PFile.Write(footerBuf); err != nil {
 		return errors.Wrap(err, "pw.PFile.Write")
@@ -203,7 +417,7 @@ func (pw *ParquetWriter) WriteStop() error {
 	if _, err = pw.PFile.Write(footerSizeBuf); err != nil {
 		return errors.Wrap(err, "pw.PFile.Write")
 	}
-	if _, err = pw.PFile.Write([]byte("PAR1")); err != nil {
+	if _, err = pw.PFile.Write(magic); err != nil {
 		return errors.Wrap(err, "pw.PFile.Write")
 	}
 	return nil
@@ -212,8 +426,16 @@ func (pw *ParquetWriter) WriteStop() error {
 
 //Write one object to parquet file
 func (pw *ParquetWriter) Write(src interface{}) error {
+	return pw.WriteContext(context.Background(), src)
+}
+
+//Write one object to parquet file, ctx is used by the flush of the write buffer
+func (pw *ParquetWriter) WriteContext(ctx context.Context, src interface{}) error {
 	var err error
 	ln := int64(len(pw.Objs))
+	if len(pw.ColumnNumRows) > 0 {
+		return errors.New("rows can't be written in a row group written by columns")
+	}
 
 	val := reflect.ValueOf(src)
 	if val.Kind() == reflect.Ptr {
@@ -230,19 +452,19 @@ func (pw *ParquetWriter) Write(src interface{}) error {
 	criSize := pw.NP * pw.PageSize * pw.SchemaHandler.GetColumnNum()
 
 	if pw.ObjsSize >= criSize {
-		err = pw.Flush(false)
+		err = pw.FlushContext(ctx, false)
 
 	} else {
 		dln := (criSize - pw.ObjsSize + pw.ObjSize - 1) / pw.ObjSize / 2
 		pw.CheckSizeCritical = dln + ln
 	}
 	if err != nil {
-		return errors.Wrap(err, "pw.Flush")
+		return errors.Wrap(err, "pw.FlushContext")
 	}
 	return nil
 }
 
-func (pw *ParquetWriter) flushObjs() error {
+func (pw *ParquetWriter) flushObjs(ctx context.Context) error {
 	var err error
 	l := int64(len(pw.Objs))
 	if l <= 0 {
@@ -255,7 +477,10 @@ func (pw *ParquetWriter) flushObjs() error {
 
 	var c int64 = 0
 	delta := (l + pw.NP - 1) / pw.NP
-	lock := new(sync.Mutex)
+	var lock *sync.Mutex
+	if pw.NP > 1 {
+		lock = new(sync.Mutex)
+	}
 	var wg sync.WaitGroup
 	var errs []error = make([]error, pw.NP)
 
@@ -288,29 +513,22 @@ func (pw *ParquetWriter) flushObjs() error {
 			if e <= b {
 				return
 			}
+			if err2 := ctx.Err(); err2 != nil {
+				errs[index] = errors.Wrap(err2, "ctx.Err")
+				return
+			}
 
 			tableMap, err2 := pw.MarshalFunc(pw.Objs[b:e], pw.SchemaHandler)
 
 			if err2 == nil {
 				for name, table := range *tableMap {
-					if table.Info.Encoding == parquet.Encoding_PLAIN_DICTIONARY ||
-						table.Info.Encoding == parquet.Encoding_RLE_DICTIONARY {
-
-						func() {
-							if pw.NP > 1 {
-								lock.Lock()
-								defer lock.Unlock()
-							}
-							if _, ok := pw.DictRecs[name]; !ok {
-								pw.DictRecs[name] = layout.NewDictRec(*table.Schema.Type)
-							}
-							pagesMapList[index][name], _ = layout.TableToDictDataPages(pw.DictRecs[name],
-								table, int32(pw.PageSize), 32, pw.CompressionType)
-						}()
-
-					} else {
-						pagesMapList[index][name], _ = layout.TableToDataPages(table, int32(pw.PageSize),
-							pw.CompressionType)
+					if err2 = ctx.Err(); err2 != nil {
+						errs[index] = errors.Wrap(err2, "ctx.Err")
+						return
+					}
+					if pagesMapList[index][name], err2 = pw.tableToPages(name, table, lock); err2 != nil {
+						errs[index] = errors.Wrap(err2, "pw.tableToPages")
+						return
 					}
 				}
 			} else {
@@ -350,20 +568,280 @@ func (pw *ParquetWriter) flushObjs() error {
 	return nil
 }
 
+//tableToPages encodes the table of a column in data pages. The dictionary and the Bloom filter hashes
+//of the column are shared by the tables of a row group, lock guards their maps if it isn't nil.
+//The dictionary guards its values itself, so the tables of different columns are encoded in parallel.
+func (pw *ParquetWriter) tableToPages(name string, table *layout.Table, lock *sync.Mutex) ([]*layout.Page, error) {
+	codec, err := pw.columnCompression(name, table.Info, lock)
+	if err != nil {
+		return nil, errors.Wrap(err, "pw.columnCompression")
+	}
+	compressor, err := pw.compressor(codec, lock)
+	if err != nil {
+		return nil, errors.Wrap(err, "pw.compressor")
+	}
+	table.Compressor = compressor
+
+	if table.Info.BloomFilter {
+		hashes, err := bloomFilterHashes(table.Values)
+		if err != nil {
+			return nil, errors.Wrap(err, "bloomFilterHashes")
+		}
+		func() {
+			if lock != nil {
+				lock.Lock()
+				defer lock.Unlock()
+			}
+			if pw.BloomFilterHashes == nil {
+				pw.BloomFilterHashes = make(map[string][]uint64)
+			}
+			pw.BloomFilterHashes[name] = append(pw.BloomFilterHashes[name], hashes...)
+		}()
+	}
+
+	if pw.AdaptiveEncoding && !table.Info.HasEncoding {
+		table.Info = pw.adaptiveInfo(name, table, lock)
+	}
+
+	var pages []*layout.Page
+	if table.Info.Encoding == parquet.Encoding_PLAIN_DICTIONARY ||
+		table.Info.Encoding == parquet.Encoding_RLE_DICTIONARY {
+
+		var dictRec *layout.DictRecType
+		func() {
+			if lock != nil {
+				lock.Lock()
+				defer lock.Unlock()
+			}
+			var ok bool
+			if dictRec, ok = pw.DictRecs[name]; !ok {
+				dictRec = layout.NewDictRec(*table.Schema.Type)
+				dictRec.SizeLimit = pw.dictSizeLimit(table.Info)
+				dictRec.Compressor = compressor
+				pw.DictRecs[name] = dictRec
+			}
+		}()
+		if pages, _, err = layout.TableToDictDataPagesVersionErr(dictRec,
+			table, int32(pw.PageSize), 32, codec, pw.DataPageVersion); err != nil {
+			return nil, errors.Wrap(err, "layout.TableToDictDataPagesVersionErr")
+		}
+
+	} else if pages, _, err = layout.TableToDataPagesVersionErr(table, int32(pw.PageSize),
+		codec, pw.DataPageVersion); err != nil {
+		return nil, errors.Wrap(err, "layout.TableToDataPagesVersionErr")
+	}
+
+	if pw.PageChecksum {
+		for _, page := range pages {
+			if err := page.SetChecksum(); err != nil {
+				return nil, errors.Wrap(err, "page.SetChecksum")
+			}
+		}
+	}
+	return pages, nil
+}
+
+//columnCompression returns the codec of a column: its codec in ColumnCompression, the codec of its tag
+//or CompressionType. The codecs are shared by the tables of the writer, lock guards them if it isn't nil.
+func (pw *ParquetWriter) columnCompression(name string, info *common.Tag, lock *sync.Mutex) (parquet.CompressionCodec, error) {
+	if lock != nil {
+		lock.Lock()
+		defer lock.Unlock()
+	}
+	if pw.columnCodecs == nil {
+		pw.columnCodecs = make(map[string]parquet.CompressionCodec)
+		for path, codec := range pw.ColumnCompression {
+			pathStr, err := pw.SchemaHandler.ConvertToInPathStr(path)
+			if err != nil {
+				pw.columnCodecs = nil
+				return 0, errors.Wrap(err, "pw.SchemaHandler.ConvertToInPathStr")
+			}
+			pw.columnCodecs[pathStr] = codec
+		}
+	}
+
+	if codec, ok := pw.columnCodecs[name]; ok {
+		return codec, nil
+	} else if info.Compression != "" {
+		codec, err := parquet.CompressionCodecFromString(strings.ToUpper(info.Compression))
+		if err != nil {
+			return 0, errors.Wrap(err, "parquet.CompressionCodecFromString")
+		}
+		return codec, nil
+	}
+	return pw.CompressionType, nil
+}
+
+//compressor returns the compressor of a codec, it's created with the options of the codec if it has some.
+//The compressors are shared by the tables of the writer, lock guards them if it isn't nil.
+func (pw *ParquetWriter) compressor(codec parquet.CompressionCodec, lock *sync.Mutex) (*compress.Compressor, error) {
+	if lock != nil {
+		lock.Lock()
+		defer lock.Unlock()
+	}
+	if compressor, ok := pw.compressors[codec]; ok {
+		return compressor, nil
+	}
+
+	var compressor *compress.Compressor
+	var err error
+	if opts, ok := pw.CodecOptions[codec]; ok {
+		if compressor, err = compress.NewCompressor(codec, opts); err != nil {
+			return nil, errors.Wrap(err, "compress.NewCompressor")
+		}
+	} else if compressor, err = compress.GetCompressor(codec); err != nil {
+		return nil, errors.Wrap(err, "compress.GetCompressor")
+	}
+	if pw.compressors == nil {
+		pw.compressors = make(map[parquet.CompressionCodec]*compress.Compressor)
+	}
+	pw.compressors[codec] = compressor
+	return compressor, nil
+}
+
+//dictSizeLimit returns the maximum size of the dictionary of a column, 0 means no limit
+func (pw *ParquetWriter) dictSizeLimit(info *common.Tag) int64 {
+	size := pw.DictSizeLimit
+	if info.DictSizeLimit != 0 {
+		size = info.DictSizeLimit
+	}
+	if size == 0 {
+		return layout.DefaultDictSizeLimit
+	} else if size < 0 {
+		return 0
+	}
+	return size
+}
+
+//adaptiveInfo returns a copy of the tag of a column with the encoding chosen for the current row group.
+//The encoding is chosen from the first table of the column in the row group, the dictionary falls back
+//to PLAIN pages itself once it's full. The encodings are shared by the tables of the writer, lock guards
+//them if it isn't nil; the values are scanned without it.
+func (pw *ParquetWriter) adaptiveInfo(name string, table *layout.Table, lock *sync.Mutex) *common.Tag {
+	if lock != nil {
+		lock.Lock()
+	}
+	encoding, ok := pw.columnEncodings[name]
+	if lock != nil {
+		lock.Unlock()
+	}
+
+	if !ok {
+		dictSizeLimit := pw.dictSizeLimit(table.Info)
+		if dictSizeLimit == 0 {
+			dictSizeLimit = math.MaxInt64
+		}
+		encoding = layout.ChooseEncoding(table, dictSizeLimit)
+
+		//a table of the column may have been scanned in the meantime, the first encoding is kept
+		if lock != nil {
+			lock.Lock()
+		}
+		if pw.columnEncodings == nil {
+			pw.columnEncodings = make(map[string]parquet.Encoding)
+		}
+		if chosen, ok := pw.columnEncodings[name]; ok {
+			encoding = chosen
+		} else {
+			pw.columnEncodings[name] = encoding
+		}
+		if lock != nil {
+			lock.Unlock()
+		}
+	}
+
+	info := *table.Info
+	info.Encoding = encoding
+	return &info
+}
+
+//usesDictionary returns true if a page is dictionary encoded, the pages of a full dictionary are PLAIN
+func usesDictionary(pages []*layout.Page) bool {
+	for _, page := range pages {
+		if page.Info.Encoding == parquet.Encoding_PLAIN_DICTIONARY ||
+			page.Info.Encoding == parquet.Encoding_RLE_DICTIONARY {
+			return true
+		}
+	}
+	return false
+}
+
+//bloomFilterHashes returns the distinct hashes of the non-null values, sorted
+func bloomFilterHashes(values []interface{}) ([]uint64, error) {
+	hashes := make([]uint64, 0, len(values))
+	for _, value := range values {
+		if value == nil {
+			continue
+		}
+		hash, err := bloomfilter.Hash(value)
+		if err != nil {
+			return nil, errors.Wrap(err, "bloomfilter.Hash")
+		}
+		hashes = append(hashes, hash)
+	}
+	return uniqueHashes(hashes), nil
+}
+
+//uniqueHashes sorts the hashes and removes the duplicates in place
+func uniqueHashes(hashes []uint64) []uint64 {
+	sort.Slice(hashes, func(i, j int) bool { return hashes[i] < hashes[j] })
+	res := hashes[:0]
+	for _, hash := range hashes {
+		if len(res) == 0 || hash != res[len(res)-1] {
+			res = append(res, hash)
+		}
+	}
+	return res
+}
+
+//newBloomFilter builds the Bloom filter of a column in the current row group, it's nil if the column has none
+func (pw *ParquetWriter) newBloomFilter(name string) *bloomfilter.Filter {
+	hashes, ok := pw.BloomFilterHashes[name]
+	if !ok {
+		return nil
+	}
+	hashes = uniqueHashes(hashes)
+	bloomFilter := bloomfilter.New(bloomfilter.OptimalNumBytes(int64(len(hashes)), pw.BloomFilterFPP))
+	for _, hash := range hashes {
+		bloomFilter.Insert(hash)
+	}
+	return bloomFilter
+}
+
 //Flush the write buffer to parquet file
 func (pw *ParquetWriter) Flush(flag bool) error {
+	return pw.FlushContext(context.Background(), flag)
+}
+
+//Flush the write buffer to parquet file, it returns ctx.Err() when ctx is done before the buffer is flushed.
+//The file is incomplete and the writer can't be used after the flush is cancelled.
+func (pw *ParquetWriter) FlushContext(ctx context.Context, flag bool) error {
 	var err error
 
-	if err = pw.flushObjs(); err != nil {
+	if err = pw.flushObjs(ctx); err != nil {
 		return errors.Wrap(err, "pw.flushObjs")
 	}
 
-	if (pw.Size+pw.ObjsSize >= pw.RowGroupSize || flag) && len(pw.PagesMapBuf) > 0 {
+	//the row groups written by columns are only flushed on demand, when all their columns are written
+	if (pw.Size+pw.ObjsSize >= pw.RowGroupSize && len(pw.ColumnNumRows) == 0 || flag) && len(pw.PagesMapBuf) > 0 {
+		if err = pw.flushColumns(); err != nil {
+			return errors.Wrap(err, "pw.flushColumns")
+		}
+
 		//pages -> chunk
 		chunkMap := make(map[string]*layout.Chunk)
 		for name, pages := range pw.PagesMapBuf {
-			if len(pages) > 0 && (pages[0].Info.Encoding == parquet.Encoding_PLAIN_DICTIONARY || pages[0].Info.Encoding == parquet.Encoding_RLE_DICTIONARY) {
-				dictPage, _ := layout.DictRecToDictPage(pw.DictRecs[name], int32(pw.PageSize), pw.CompressionType)
+			//the dictionary is written if a page of the chunk uses it
+			if _, ok := pw.DictRecs[name]; ok && usesDictionary(pages) {
+				dictPage, _, err := layout.DictRecToDictPageErr(pw.DictRecs[name], int32(pw.PageSize), pages[0].CompressType)
+				if err != nil {
+					return errors.Wrap(err, "layout.DictRecToDictPageErr")
+				}
+				if pw.PageChecksum {
+					if err = dictPage.SetChecksum(); err != nil {
+						return errors.Wrap(err, "dictPage.SetChecksum")
+					}
+				}
 				tmp := append([]*layout.Page{dictPage}, pages...)
 				chunkMap[name] = layout.PagesToDictChunk(tmp)
 			} else {
@@ -373,6 +851,7 @@ func (pw *ParquetWriter) Flush(flag bool) error {
 		}
 
 		pw.DictRecs = make(map[string]*layout.DictRecType) //clean records for next chunks
+		pw.columnEncodings = nil
 
 		//chunks -> rowGroup
 		rowGroup := layout.NewRowGroup()
@@ -384,11 +863,13 @@ func (pw *ParquetWriter) Flush(flag bool) error {
 			if schema.GetNumChildren() > 0 {
 				continue
 			}
-			chunk := chunkMap[pw.SchemaHandler.IndexMap[int32(k)]]
+			name := pw.SchemaHandler.IndexMap[int32(k)]
+			chunk := chunkMap[name]
 			if chunk == nil {
 				continue
 			}
 			rowGroup.Chunks = append(rowGroup.Chunks, chunk)
+			pw.BloomFilters = append(pw.BloomFilters, pw.newBloomFilter(name))
 			//rowGroup.RowGroupHeader.TotalByteSize += chunk.ChunkHeader.MetaData.TotalCompressedSize
 			rowGroup.RowGroupHeader.TotalByteSize += chunk.ChunkHeader.MetaData.TotalUncompressedSize
 			rowGroup.RowGroupHeader.Columns = append(rowGroup.RowGroupHeader.Columns, chunk.ChunkHeader)
@@ -396,19 +877,37 @@ func (pw *ParquetWriter) Flush(flag bool) error {
 		rowGroup.RowGroupHeader.NumRows = pw.NumRows
 		pw.NumRows = 0
 
+		if pw.FileEncryptor != nil {
+			ordinal := int16(len(pw.Footer.RowGroups))
+			rowGroup.RowGroupHeader.Ordinal = &ordinal
+		}
+
 		for k := 0; k < len(rowGroup.Chunks); k++ {
+			chunkCipher, cryptoMetaData, err := pw.chunkCipher(rowGroup.Chunks[k], k)
+			if err != nil {
+				return errors.Wrap(err, "pw.chunkCipher")
+			}
+			if chunkCipher != nil {
+				if err = encryptChunk(rowGroup.Chunks[k], chunkCipher); err != nil {
+					return errors.Wrap(err, "encryptChunk")
+				}
+			}
+			pw.ChunkCiphers = append(pw.ChunkCiphers, chunkCipher)
+			pw.ColumnCryptoMetaData = append(pw.ColumnCryptoMetaData, cryptoMetaData)
+
 			rowGroup.Chunks[k].ChunkHeader.MetaData.DataPageOffset = -1
 			rowGroup.Chunks[k].ChunkHeader.FileOffset = pw.Offset
 
 			pageCount := len(rowGroup.Chunks[k].Pages)
 
-			//add ColumnIndex
+			//add ColumnIndex, it's dropped if the chunk has no statistics
 			columnIndex := parquet.NewColumnIndex()
-			columnIndex.NullPages = make([]bool, pageCount)
-			columnIndex.MinValues = make([][]byte, pageCount)
-			columnIndex.MaxValues = make([][]byte, pageCount)
+			columnIndex.NullPages = make([]bool, 0, pageCount)
+			columnIndex.MinValues = make([][]byte, 0, pageCount)
+			columnIndex.MaxValues = make([][]byte, 0, pageCount)
+			columnIndex.NullCounts = make([]int64, 0, pageCount)
 			columnIndex.BoundaryOrder = parquet.BoundaryOrder_UNORDERED
-			pw.ColumnIndexes = append(pw.ColumnIndexes, columnIndex)
+			hasStats := true
 
 			//add OffsetIndex
 			offsetIndex := parquet.NewOffsetIndex()
@@ -433,19 +932,23 @@ func (pw *ParquetWriter) Flush(flag bool) error {
 						panic(errors.New("unsupported data page: " + page.Header.String()))
 					}
 
-					var minVal []byte
-					var maxVal []byte
-					if page.Header.DataPageHeader != nil && page.Header.DataPageHeader.Statistics != nil {
-						minVal = page.Header.DataPageHeader.Statistics.Min
-						maxVal = page.Header.DataPageHeader.Statistics.Max
-
-					} else if page.Header.DataPageHeaderV2 != nil && page.Header.DataPageHeaderV2.Statistics != nil {
-						minVal = page.Header.DataPageHeaderV2.Statistics.Min
-						maxVal = page.Header.DataPageHeaderV2.Statistics.Max
+					var statistics *parquet.Statistics
+					if page.Header.DataPageHeader != nil {
+						statistics = page.Header.DataPageHeader.Statistics
+					} else {
+						statistics = page.Header.DataPageHeaderV2.Statistics
 					}
 
-					columnIndex.MinValues[l] = minVal
-					columnIndex.MaxValues[l] = maxVal
+					if statistics != nil && statistics.NullCount != nil {
+						//a page without min/max has only null values
+						nullPage := statistics.Min == nil && statistics.Max == nil
+						columnIndex.NullPages = append(columnIndex.NullPages, nullPage)
+						columnIndex.MinValues = append(columnIndex.MinValues, statistics.Min)
+						columnIndex.MaxValues = append(columnIndex.MaxValues, statistics.Max)
+						columnIndex.NullCounts = append(columnIndex.NullCounts, *statistics.NullCount)
+					} else {
+						hasStats = false
+					}
 
 					pageLocation := parquet.NewPageLocation()
 					pageLocation.Offset = pw.Offset
@@ -454,20 +957,29 @@ func (pw *ParquetWriter) Flush(flag bool) error {
 
 					offsetIndex.PageLocations = append(offsetIndex.PageLocations, pageLocation)
 
-					firstRowIndex += int64(page.Header.DataPageHeader.NumValues)
+					firstRowIndex += page.NumRows
 				}
 
+				if err = ctx.Err(); err != nil {
+					return errors.Wrap(err, "ctx.Err")
+				}
 				data := rowGroup.Chunks[k].Pages[l].RawData
 				if _, err = pw.PFile.Write(data); err != nil {
 					return errors.Wrap(err, "pw.PFile.Write")
 				}
 				pw.Offset += int64(len(data))
 			}
+
+			if !hasStats {
+				columnIndex = nil
+			}
+			pw.ColumnIndexes = append(pw.ColumnIndexes, columnIndex)
 		}
 
 		pw.Footer.RowGroups = append(pw.Footer.RowGroups, rowGroup.RowGroupHeader)
 		pw.Size = 0
 		pw.PagesMapBuf = make(map[string][]*layout.Page)
+		pw.BloomFilterHashes = nil
 	}
 	pw.Footer.NumRows += int64(len(pw.Objs))
 	pw.Objs = pw.Objs[:0]
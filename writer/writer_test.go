@@ -0,0 +1,633 @@
+package writer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/pkg/errors"
+	"github.com/sabey/parquet-go-source/buffer"
+	"github.com/sabey/parquet-go-source/writerfile"
+	"github.com/sabey/parquet-go/common"
+	"github.com/sabey/parquet-go/compress"
+	"github.com/sabey/parquet-go/layout"
+	"github.com/sabey/parquet-go/parquet"
+	"github.com/sabey/parquet-go/reader"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteContext(t *testing.T) {
+	buf := new(bytes.Buffer)
+	pw, err := NewParquetWriter(writerfile.NewWriterFile(buf), new(columnRecord), 2)
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	for id := int64(0); id < 100; id++ {
+		assert.Nil(t, pw.WriteContext(ctx, columnRecord{ID: id, Name: "a"}))
+	}
+	assert.Nil(t, pw.FlushContext(ctx, true))
+	for id := int64(100); id < 200; id++ {
+		assert.Nil(t, pw.WriteContext(ctx, columnRecord{ID: id, Name: "b"}))
+	}
+	assert.Nil(t, pw.WriteStopContext(ctx))
+
+	pr, err := reader.NewParquetReader(buffer.NewBufferFileFromBytes(buf.Bytes()), new(columnRecord), 1)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(200), pr.GetNumRows())
+	pr.ReadStop()
+
+	//the buffered rows aren't flushed with a cancelled context
+	pw, err = NewParquetWriter(writerfile.NewWriterFile(new(bytes.Buffer)), new(columnRecord), 2)
+	assert.Nil(t, err)
+	for id := int64(0); id < 100; id++ {
+		assert.Nil(t, pw.WriteContext(ctx, columnRecord{ID: id, Name: "a"}))
+	}
+	cancel()
+	assert.True(t, errors.Is(pw.FlushContext(ctx, true), context.Canceled))
+	assert.True(t, errors.Is(pw.WriteStopContext(ctx), context.Canceled))
+}
+
+func TestPageChecksum(t *testing.T) {
+	buf := new(bytes.Buffer)
+	pw, err := NewParquetWriter(writerfile.NewWriterFile(buf), new(columnRecord), 1)
+	assert.Nil(t, err)
+	pw.PageSize = 64
+	pw.PageChecksum = true
+	for id := int64(0); id < 100; id++ {
+		score := float64(id)
+		assert.Nil(t, pw.Write(columnRecord{ID: id, Name: string(rune('a' + id%3)), Score: &score, Tags: []int32{int32(id)}}))
+	}
+	assert.Nil(t, pw.WriteStop())
+	data := buf.Bytes()
+
+	pr, err := reader.NewParquetReader(buffer.NewBufferFileFromBytes(data), new(columnRecord), 1)
+	assert.Nil(t, err)
+	recs := make([]columnRecord, 100)
+	assert.Nil(t, pr.Read(&recs))
+	assert.Equal(t, int64(99), recs[99].ID)
+	assert.Equal(t, "a", recs[99].Name)
+
+	//flip a bit of the last page of the ids
+	metaData := pr.Footer.RowGroups[0].Columns[0].MetaData
+	pr.ReadStop()
+	data[metaData.DataPageOffset+metaData.TotalCompressedSize-1] ^= 1
+
+	pr, err = reader.NewParquetReader(buffer.NewBufferFileFromBytes(data), new(columnRecord), 1)
+	assert.Nil(t, err)
+	err = pr.Read(&recs)
+	var corruptErr *layout.CorruptPageError
+	assert.True(t, errors.As(err, &corruptErr))
+	assert.Equal(t, parquet.PageType_DATA_PAGE, corruptErr.PageType)
+	var columnErr *reader.ColumnError
+	assert.True(t, errors.As(err, &columnErr))
+	assert.Equal(t, "Parquet_go_root\x01ID", columnErr.Path)
+	pr.ReadStop()
+}
+
+type dataPageVersionRecord struct {
+	ID   int64  `parquet:"name=id, type=INT64, datapageversion=2"`
+	Name string `parquet:"name=name, type=BYTE_ARRAY, convertedtype=UTF8"`
+}
+
+//dataPageHeaders returns the headers of the data pages of the column chunks of the first row group
+func dataPageHeaders(t *testing.T, data []byte, footer *parquet.FileMetaData) [][]*parquet.PageHeader {
+	res := make([][]*parquet.PageHeader, 0)
+	for _, column := range footer.RowGroups[0].Columns {
+		metaData := column.MetaData
+		offset := metaData.DataPageOffset
+		if metaData.DictionaryPageOffset != nil {
+			offset = *metaData.DictionaryPageOffset
+		}
+		chunk := bytes.NewReader(data[offset : offset+metaData.TotalCompressedSize])
+		thriftReader := thrift.NewTBufferedTransport(thrift.NewStreamTransportR(chunk), 1024)
+		headers := make([]*parquet.PageHeader, 0)
+		for values := int64(0); values < metaData.NumValues; {
+			header, err := layout.ReadPageHeader(thriftReader)
+			assert.Nil(t, err)
+			_, err = io.CopyN(ioutil.Discard, thriftReader, int64(header.CompressedPageSize))
+			assert.Nil(t, err)
+			if header.Type == parquet.PageType_DICTIONARY_PAGE {
+				continue
+			}
+			headers = append(headers, header)
+			values += int64(header.DataPageHeaderV2.GetNumValues())
+		}
+		res = append(res, headers)
+	}
+	return res
+}
+
+func TestDataPageV2(t *testing.T) {
+	buf := new(bytes.Buffer)
+	pw, err := NewParquetWriter(writerfile.NewWriterFile(buf), new(columnRecord), 1, WithDataPageVersion(2))
+	assert.Nil(t, err)
+	pw.PageSize = 64
+	pw.PageChecksum = true
+	for id := int64(0); id < 100; id++ {
+		score := float64(id)
+		rec := columnRecord{ID: id, Name: string(rune('a' + id%3)), Tags: []int32{int32(id), int32(id)}}
+		if id%2 == 0 {
+			rec.Score = &score
+		}
+		assert.Nil(t, pw.Write(rec))
+	}
+	assert.Nil(t, pw.WriteStop())
+	data := buf.Bytes()
+
+	pr, err := reader.NewParquetReader(buffer.NewBufferFileFromBytes(data), new(columnRecord), 1)
+	assert.Nil(t, err)
+	recs := make([]columnRecord, 100)
+	assert.Nil(t, pr.Read(&recs))
+	for id, rec := range recs {
+		assert.Equal(t, int64(id), rec.ID)
+		assert.Equal(t, string(rune('a'+id%3)), rec.Name)
+		assert.Equal(t, id%2 == 0, rec.Score != nil)
+		assert.Equal(t, []int32{int32(id), int32(id)}, rec.Tags)
+	}
+
+	for i, headers := range dataPageHeaders(t, data, pr.Footer) {
+		rows := int32(0)
+		for _, header := range headers {
+			assert.Equal(t, parquet.PageType_DATA_PAGE_V2, header.Type)
+			assert.NotNil(t, header.Crc)
+			rows += header.DataPageHeaderV2.NumRows
+		}
+		assert.Equal(t, int32(100), rows)
+		if i == 1 {
+			assert.Equal(t, parquet.Encoding_RLE_DICTIONARY, headers[0].DataPageHeaderV2.Encoding)
+		}
+	}
+	pr.ReadStop()
+}
+
+func TestDataPageVersionTag(t *testing.T) {
+	buf := new(bytes.Buffer)
+	pw, err := NewParquetWriter(writerfile.NewWriterFile(buf), new(dataPageVersionRecord), 1)
+	assert.Nil(t, err)
+	pw.CompressionType = parquet.CompressionCodec_UNCOMPRESSED
+	for id := int64(0); id < 10; id++ {
+		assert.Nil(t, pw.Write(dataPageVersionRecord{ID: id, Name: "name"}))
+	}
+	assert.Nil(t, pw.WriteStop())
+	data := buf.Bytes()
+
+	pr, err := reader.NewParquetReader(buffer.NewBufferFileFromBytes(data), new(dataPageVersionRecord), 1)
+	assert.Nil(t, err)
+	recs := make([]dataPageVersionRecord, 10)
+	assert.Nil(t, pr.Read(&recs))
+	assert.Equal(t, int64(9), recs[9].ID)
+	assert.Equal(t, "name", recs[9].Name)
+
+	metaData := pr.Footer.RowGroups[0].Columns[0].MetaData
+	thriftReader := thrift.NewTBufferedTransport(thrift.NewStreamTransportR(bytes.NewReader(data[metaData.DataPageOffset:])), 1024)
+	header, err := layout.ReadPageHeader(thriftReader)
+	assert.Nil(t, err)
+	assert.Equal(t, parquet.PageType_DATA_PAGE_V2, header.Type)
+	assert.False(t, header.DataPageHeaderV2.IsCompressed)
+	assert.Equal(t, int32(10), header.DataPageHeaderV2.NumRows)
+
+	metaData = pr.Footer.RowGroups[0].Columns[1].MetaData
+	thriftReader = thrift.NewTBufferedTransport(thrift.NewStreamTransportR(bytes.NewReader(data[metaData.DataPageOffset:])), 1024)
+	header, err = layout.ReadPageHeader(thriftReader)
+	assert.Nil(t, err)
+	assert.Equal(t, parquet.PageType_DATA_PAGE, header.Type)
+	pr.ReadStop()
+
+	_, err = NewParquetWriter(writerfile.NewWriterFile(new(bytes.Buffer)), new(dataPageVersionRecord), 1, WithDataPageVersion(3))
+	assert.NotNil(t, err)
+}
+
+type encodingRecord struct {
+	I32   int32    `parquet:"name=i32, type=INT32, encoding=DELTA_BINARY_PACKED"`
+	I64   *int64   `parquet:"name=i64, type=INT64, encoding=DELTA_BINARY_PACKED"`
+	Str   string   `parquet:"name=str, type=BYTE_ARRAY, convertedtype=UTF8, encoding=DELTA_BYTE_ARRAY"`
+	Bytes *string  `parquet:"name=bytes, type=BYTE_ARRAY, encoding=DELTA_LENGTH_BYTE_ARRAY"`
+	Fixed string   `parquet:"name=fixed, type=FIXED_LEN_BYTE_ARRAY, length=4, encoding=DELTA_BYTE_ARRAY"`
+	F32   float32  `parquet:"name=f32, type=FLOAT, encoding=BYTE_STREAM_SPLIT"`
+	F64   *float64 `parquet:"name=f64, type=DOUBLE, encoding=BYTE_STREAM_SPLIT"`
+}
+
+func newEncodingRecord(i int) encodingRecord {
+	rec := encodingRecord{
+		I32:   int32(i * 7919),
+		Str:   fmt.Sprintf("prefix-%03d", i),
+		Fixed: fmt.Sprintf("%04d", i),
+		F32:   float32(i) / 4,
+	}
+	if i%2 == 0 {
+		rec.I32 = math.MinInt32 + int32(i)
+	}
+	//the first rows are null, so that whole pages are
+	if i >= 40 {
+		i64, bytes, f64 := int64(i)<<40, fmt.Sprint(i), float64(i)/8
+		rec.I64, rec.Bytes, rec.F64 = &i64, &bytes, &f64
+	}
+	return rec
+}
+
+func TestWriteEncodings(t *testing.T) {
+	for _, version := range []int32{1, 2} {
+		buf := new(bytes.Buffer)
+		pw, err := NewParquetWriter(writerfile.NewWriterFile(buf), new(encodingRecord), 1, WithDataPageVersion(version))
+		assert.Nil(t, err)
+		pw.PageSize = 64
+		for i := 0; i < 100; i++ {
+			assert.Nil(t, pw.Write(newEncodingRecord(i)))
+		}
+		assert.Nil(t, pw.WriteStop())
+
+		pr, err := reader.NewParquetReader(buffer.NewBufferFileFromBytes(buf.Bytes()), new(encodingRecord), 1)
+		assert.Nil(t, err)
+		recs := make([]encodingRecord, 100)
+		assert.Nil(t, pr.Read(&recs))
+		for i, rec := range recs {
+			assert.Equal(t, newEncodingRecord(i), rec)
+		}
+
+		pageType := parquet.PageType_DATA_PAGE
+		if version == 2 {
+			pageType = parquet.PageType_DATA_PAGE_V2
+		}
+		expected := []parquet.Encoding{
+			parquet.Encoding_DELTA_BINARY_PACKED, parquet.Encoding_DELTA_BINARY_PACKED,
+			parquet.Encoding_DELTA_BYTE_ARRAY, parquet.Encoding_DELTA_LENGTH_BYTE_ARRAY, parquet.Encoding_DELTA_BYTE_ARRAY,
+			parquet.Encoding_BYTE_STREAM_SPLIT, parquet.Encoding_BYTE_STREAM_SPLIT,
+		}
+		for i, column := range pr.Footer.RowGroups[0].Columns {
+			metaData := column.MetaData
+			assert.Equal(t, []parquet.Encoding{parquet.Encoding_RLE, expected[i]}, metaData.Encodings)
+			assert.Equal(t, 1, len(metaData.EncodingStats))
+			assert.Equal(t, pageType, metaData.EncodingStats[0].PageType)
+			assert.Equal(t, expected[i], metaData.EncodingStats[0].Encoding)
+			assert.True(t, metaData.EncodingStats[0].Count > 1)
+		}
+		statistics := pr.Footer.RowGroups[0].Columns[0].MetaData.Statistics
+		assert.Equal(t, []byte{0, 0, 0, 0x80}, statistics.MinValue)
+		statistics = pr.Footer.RowGroups[0].Columns[1].MetaData.Statistics
+		assert.Equal(t, int64(40), *statistics.NullCount)
+		pr.ReadStop()
+	}
+
+	jsonSchema := `{"Tag": "name=parquet-go-root", "Fields": [
+		{"Tag": "name=id, type=INT64, encoding=DELTA_BINARY_PACKED"},
+		{"Tag": "name=name, type=BYTE_ARRAY, convertedtype=UTF8, encoding=DELTA_BYTE_ARRAY"},
+		{"Tag": "name=score, type=DOUBLE, encoding=BYTE_STREAM_SPLIT"}
+	]}`
+	buf := new(bytes.Buffer)
+	jw, err := NewJSONWriter(jsonSchema, writerfile.NewWriterFile(buf), 1)
+	assert.Nil(t, err)
+	assert.Nil(t, jw.Write(`{"id": 1, "name": "a", "score": 1.5}`))
+	assert.Nil(t, jw.WriteStop())
+	pr, err := reader.NewParquetReader(buffer.NewBufferFileFromBytes(buf.Bytes()), nil, 1)
+	assert.Nil(t, err)
+	for i, encoding := range []parquet.Encoding{parquet.Encoding_DELTA_BINARY_PACKED, parquet.Encoding_DELTA_BYTE_ARRAY, parquet.Encoding_BYTE_STREAM_SPLIT} {
+		assert.Equal(t, encoding, pr.Footer.RowGroups[0].Columns[i].MetaData.EncodingStats[0].Encoding)
+	}
+	pr.ReadStop()
+
+	_, err = NewJSONWriter(`{"Tag": "name=root", "Fields": [{"Tag": "name=id, type=INT64, encoding=BYTE_STREAM_SPLIT"}]}`,
+		writerfile.NewWriterFile(new(bytes.Buffer)), 1)
+	assert.NotNil(t, err)
+}
+
+type adaptiveRecord struct {
+	ID       int64   `parquet:"name=id, type=INT64"`
+	Category string  `parquet:"name=category, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Score    float64 `parquet:"name=score, type=DOUBLE"`
+	Name     string  `parquet:"name=name, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Flag     bool    `parquet:"name=flag, type=BOOLEAN"`
+	Tagged   string  `parquet:"name=tagged, type=BYTE_ARRAY, convertedtype=UTF8, encoding=DELTA_BYTE_ARRAY"`
+	Plain    string  `parquet:"name=plain, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN"`
+}
+
+func TestAdaptiveEncoding(t *testing.T) {
+	newRecord := func(i int) adaptiveRecord {
+		return adaptiveRecord{
+			ID:       int64(i),
+			Category: string(rune('a' + i%4)),
+			Score:    math.Sin(float64(i)),
+			Name:     fmt.Sprint("name-", i),
+			Flag:     i%3 == 0,
+			Tagged:   fmt.Sprint("tagged-", i%5),
+			Plain:    fmt.Sprint("plain-", i%5),
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	pw, err := NewParquetWriter(writerfile.NewWriterFile(buf), new(adaptiveRecord), 2, WithAdaptiveEncoding())
+	assert.Nil(t, err)
+	for i := 0; i < 1000; i++ {
+		assert.Nil(t, pw.Write(newRecord(i)))
+	}
+	assert.Nil(t, pw.WriteStop())
+
+	pr, err := reader.NewParquetReader(buffer.NewBufferFileFromBytes(buf.Bytes()), new(adaptiveRecord), 1)
+	assert.Nil(t, err)
+	recs := make([]adaptiveRecord, 1000)
+	assert.Nil(t, pr.Read(&recs))
+	for i, rec := range recs {
+		assert.Equal(t, newRecord(i), rec)
+	}
+	expected := []parquet.Encoding{
+		parquet.Encoding_DELTA_BINARY_PACKED, parquet.Encoding_PLAIN_DICTIONARY, parquet.Encoding_BYTE_STREAM_SPLIT,
+		parquet.Encoding_PLAIN, parquet.Encoding_PLAIN, parquet.Encoding_DELTA_BYTE_ARRAY, parquet.Encoding_PLAIN,
+	}
+	for i, column := range pr.Footer.RowGroups[0].Columns {
+		encodingStats := column.MetaData.EncodingStats
+		assert.Equal(t, expected[i], encodingStats[len(encodingStats)-1].Encoding, column.MetaData.PathInSchema)
+	}
+	pr.ReadStop()
+}
+
+func TestAdaptiveEncodingDictFallback(t *testing.T) {
+	newCategory := func(i int) string {
+		if i < 100 {
+			return string(rune('a' + i%4))
+		}
+		return fmt.Sprintf("%01024d", i)
+	}
+
+	buf := new(bytes.Buffer)
+	pw, err := NewParquetWriter(writerfile.NewWriterFile(buf), new(adaptiveRecord), 1, WithAdaptiveEncoding())
+	assert.Nil(t, err)
+	for i := 0; i < 2000; i++ {
+		assert.Nil(t, pw.Write(adaptiveRecord{ID: int64(i), Category: newCategory(i)}))
+		if i%100 == 99 {
+			assert.Nil(t, pw.Flush(false))
+		}
+	}
+	assert.Nil(t, pw.WriteStop())
+
+	pr, err := reader.NewParquetReader(buffer.NewBufferFileFromBytes(buf.Bytes()), new(adaptiveRecord), 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(pr.Footer.RowGroups))
+	recs := make([]adaptiveRecord, 2000)
+	assert.Nil(t, pr.Read(&recs))
+	for i, rec := range recs {
+		assert.Equal(t, newCategory(i), rec.Category)
+	}
+	metaData := pr.Footer.RowGroups[0].Columns[1].MetaData
+	assert.Equal(t, []parquet.Encoding{parquet.Encoding_PLAIN, parquet.Encoding_RLE, parquet.Encoding_PLAIN_DICTIONARY}, metaData.Encodings)
+	pageEncodings := make(map[parquet.Encoding]bool)
+	for _, stats := range metaData.EncodingStats {
+		if stats.PageType == parquet.PageType_DATA_PAGE {
+			pageEncodings[stats.Encoding] = true
+		}
+	}
+	assert.Equal(t, map[parquet.Encoding]bool{parquet.Encoding_PLAIN_DICTIONARY: true, parquet.Encoding_PLAIN: true}, pageEncodings)
+	pr.ReadStop()
+}
+
+type dictSizeRecord struct {
+	Limited   string `parquet:"name=limited, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY, dictsizelimit=200"`
+	Unlimited string `parquet:"name=unlimited, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY, dictsizelimit=-1"`
+	Tiny      string `parquet:"name=tiny, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY, dictsizelimit=1"`
+}
+
+func TestDictSizeLimit(t *testing.T) {
+	newRecord := func(i int) dictSizeRecord {
+		value := fmt.Sprintf("v%d", i%10)
+		if i >= 500 {
+			value = fmt.Sprintf("%032d", i)
+		}
+		return dictSizeRecord{Limited: value, Unlimited: value, Tiny: value}
+	}
+
+	buf := new(bytes.Buffer)
+	pw, err := NewParquetWriter(writerfile.NewWriterFile(buf), new(dictSizeRecord), 4)
+	assert.Nil(t, err)
+	for i := 0; i < 1000; i++ {
+		assert.Nil(t, pw.Write(newRecord(i)))
+		if i == 499 {
+			assert.Nil(t, pw.Flush(false))
+		}
+	}
+	assert.Nil(t, pw.WriteStop())
+
+	pr, err := reader.NewParquetReader(buffer.NewBufferFileFromBytes(buf.Bytes()), new(dictSizeRecord), 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(pr.Footer.RowGroups))
+	recs := make([]dictSizeRecord, 1000)
+	assert.Nil(t, pr.Read(&recs))
+	for i, rec := range recs {
+		assert.Equal(t, newRecord(i), rec)
+	}
+	pr.ReadStop()
+
+	pageEncodings := func(metaData *parquet.ColumnMetaData) map[parquet.Encoding]bool {
+		res := make(map[parquet.Encoding]bool)
+		for _, stats := range metaData.EncodingStats {
+			if stats.PageType == parquet.PageType_DATA_PAGE {
+				res[stats.Encoding] = true
+			}
+		}
+		return res
+	}
+	columns := pr.Footer.RowGroups[0].Columns
+	//the chunk switches to PLAIN pages when the dictionary is full
+	assert.NotNil(t, columns[0].MetaData.DictionaryPageOffset)
+	assert.Equal(t, map[parquet.Encoding]bool{parquet.Encoding_PLAIN_DICTIONARY: true, parquet.Encoding_PLAIN: true},
+		pageEncodings(columns[0].MetaData))
+	assert.NotNil(t, columns[1].MetaData.DictionaryPageOffset)
+	assert.Equal(t, map[parquet.Encoding]bool{parquet.Encoding_PLAIN_DICTIONARY: true}, pageEncodings(columns[1].MetaData))
+	//no dictionary page is written if no page uses it
+	assert.Nil(t, columns[2].MetaData.DictionaryPageOffset)
+	assert.Equal(t, map[parquet.Encoding]bool{parquet.Encoding_PLAIN: true}, pageEncodings(columns[2].MetaData))
+}
+
+func TestWriteCodecs(t *testing.T) {
+	tests := []struct {
+		codec   parquet.CompressionCodec
+		version int32
+		opts    []WriterOption
+	}{
+		{parquet.CompressionCodec_BROTLI, 1, nil},
+		{parquet.CompressionCodec_BROTLI, 2, nil},
+		{parquet.CompressionCodec_LZ4_RAW, 1, nil},
+		{parquet.CompressionCodec_LZ4_RAW, 2, nil},
+		{parquet.CompressionCodec_GZIP, 1, []WriterOption{WithCodecOptions(parquet.CompressionCodec_GZIP, compress.Options{Level: 9})}},
+		{parquet.CompressionCodec_ZSTD, 2, []WriterOption{WithCodecOptions(parquet.CompressionCodec_ZSTD, compress.Options{Level: 19, Concurrency: 1})}},
+	}
+	for _, test := range tests {
+		buf := new(bytes.Buffer)
+		opts := append([]WriterOption{WithDataPageVersion(test.version)}, test.opts...)
+		pw, err := NewParquetWriter(writerfile.NewWriterFile(buf), new(columnRecord), 2, opts...)
+		assert.Nil(t, err)
+		pw.CompressionType = test.codec
+		pw.PageSize = 256
+		for id := int64(0); id < 500; id++ {
+			score := float64(id)
+			assert.Nil(t, pw.Write(columnRecord{ID: id, Name: string(rune('a' + id%3)), Score: &score, Tags: []int32{int32(id)}}))
+		}
+		assert.Nil(t, pw.WriteStop())
+
+		pr, err := reader.NewParquetReader(buffer.NewBufferFileFromBytes(buf.Bytes()), new(columnRecord), 1)
+		assert.Nil(t, err)
+		for _, column := range pr.Footer.RowGroups[0].Columns {
+			assert.Equal(t, test.codec, column.MetaData.Codec)
+		}
+		recs := make([]columnRecord, 500)
+		assert.Nil(t, pr.Read(&recs), test.codec.String())
+		for id, rec := range recs {
+			assert.Equal(t, int64(id), rec.ID)
+			assert.Equal(t, string(rune('a'+id%3)), rec.Name)
+			assert.Equal(t, []int32{int32(id)}, rec.Tags)
+		}
+		pr.ReadStop()
+	}
+}
+
+func TestWriteCodecErrors(t *testing.T) {
+	_, err := NewParquetWriter(writerfile.NewWriterFile(ioutil.Discard), new(columnRecord), 1,
+		WithCodecOptions(parquet.CompressionCodec_ZSTD, compress.Options{Level: 42}))
+	assert.NotNil(t, err)
+
+	pw, err := NewParquetWriter(writerfile.NewWriterFile(ioutil.Discard), new(columnRecord), 1)
+	assert.Nil(t, err)
+	pw.CompressionType = parquet.CompressionCodec_LZO
+	assert.Nil(t, pw.Write(columnRecord{ID: 1}))
+	assert.NotNil(t, pw.WriteStop())
+}
+
+type columnCompressionRecord struct {
+	ID    int64             `parquet:"name=id, type=INT64, compression=zstd"`
+	Blob  string            `parquet:"name=blob, type=BYTE_ARRAY, compression=UNCOMPRESSED, encoding=PLAIN_DICTIONARY"`
+	Score float64           `parquet:"name=score, type=DOUBLE"`
+	Note  string            `parquet:"name=note, type=BYTE_ARRAY, convertedtype=UTF8, compression=GZIP"`
+	Attrs map[string]string `parquet:"name=attrs, type=MAP, keytype=BYTE_ARRAY, keyconvertedtype=UTF8, keycompression=LZ4_RAW, valuetype=BYTE_ARRAY, valueconvertedtype=UTF8"`
+}
+
+func TestColumnCompression(t *testing.T) {
+	newRecord := func(i int) columnCompressionRecord {
+		return columnCompressionRecord{ID: int64(i), Blob: fmt.Sprint(i % 5), Score: float64(i) / 2, Note: fmt.Sprint(i),
+			Attrs: map[string]string{"key": fmt.Sprint(i)}}
+	}
+	buf := new(bytes.Buffer)
+	pw, err := NewParquetWriter(writerfile.NewWriterFile(buf), new(columnCompressionRecord), 2,
+		WithColumnCompression("Parquet_go_root\x01Note", parquet.CompressionCodec_BROTLI))
+	assert.Nil(t, err)
+	pw.CompressionType = parquet.CompressionCodec_SNAPPY
+	for i := 0; i < 100; i++ {
+		assert.Nil(t, pw.Write(newRecord(i)))
+	}
+	assert.Nil(t, pw.WriteStop())
+
+	pr, err := reader.NewParquetReader(buffer.NewBufferFileFromBytes(buf.Bytes()), new(columnCompressionRecord), 1)
+	assert.Nil(t, err)
+	recs := make([]columnCompressionRecord, 100)
+	assert.Nil(t, pr.Read(&recs))
+	for i, rec := range recs {
+		assert.Equal(t, newRecord(i), rec)
+	}
+	expected := []parquet.CompressionCodec{
+		parquet.CompressionCodec_ZSTD, parquet.CompressionCodec_UNCOMPRESSED, parquet.CompressionCodec_SNAPPY,
+		parquet.CompressionCodec_BROTLI, parquet.CompressionCodec_LZ4_RAW, parquet.CompressionCodec_SNAPPY,
+	}
+	for i, column := range pr.Footer.RowGroups[0].Columns {
+		assert.Equal(t, expected[i], column.MetaData.Codec, common.PathToStr(column.MetaData.PathInSchema))
+	}
+	pr.ReadStop()
+
+	jsonSchema := `{"Tag": "name=parquet-go-root", "Fields": [
+		{"Tag": "name=id, type=INT64, compression=ZSTD"},
+		{"Tag": "name=name, type=BYTE_ARRAY, convertedtype=UTF8"}
+	]}`
+	buf = new(bytes.Buffer)
+	jw, err := NewJSONWriter(jsonSchema, writerfile.NewWriterFile(buf), 1)
+	assert.Nil(t, err)
+	assert.Nil(t, jw.Write(`{"id": 1, "name": "a"}`))
+	assert.Nil(t, jw.WriteStop())
+	pr, err = reader.NewParquetReader(buffer.NewBufferFileFromBytes(buf.Bytes()), nil, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, parquet.CompressionCodec_ZSTD, pr.Footer.RowGroups[0].Columns[0].MetaData.Codec)
+	assert.Equal(t, parquet.CompressionCodec_SNAPPY, pr.Footer.RowGroups[0].Columns[1].MetaData.Codec)
+	pr.ReadStop()
+
+	_, err = NewJSONWriter(`{"Tag": "name=root", "Fields": [{"Tag": "name=id, type=INT64, compression=LZMA"}]}`,
+		writerfile.NewWriterFile(new(bytes.Buffer)), 1)
+	assert.NotNil(t, err)
+
+	pw, err = NewParquetWriter(writerfile.NewWriterFile(ioutil.Discard), new(columnCompressionRecord), 1,
+		WithColumnCompression("Parquet_go_root\x01Unknown", parquet.CompressionCodec_ZSTD))
+	assert.Nil(t, err)
+	assert.Nil(t, pw.Write(newRecord(0)))
+	assert.NotNil(t, pw.WriteStop())
+}
+
+type inferredItem struct {
+	SKU      string `json:"sku"`
+	Quantity uint16 `json:"quantity"`
+}
+
+type inferredPayload []byte
+
+type inferredRecord struct {
+	ID      int               `json:"id"`
+	Small   int8              `json:"small"`
+	Count   uint64            `json:"count"`
+	Ratio   float32           `json:"ratio"`
+	Active  bool              `json:"active"`
+	Note    *string           `json:"note"`
+	Created time.Time         `json:"created"`
+	Items   []inferredItem    `json:"items"`
+	Labels  map[string]string `json:"labels"`
+	Price   int64             `json:"price" parquet:"convertedtype=DECIMAL, precision=18, scale=2"`
+	Raw     []byte            `json:"raw"`
+	Payload *inferredPayload  `json:"payload"`
+}
+
+func TestSchemaInference(t *testing.T) {
+	note := "note"
+	created := time.Date(2021, 6, 7, 8, 9, 10, 123456000, time.UTC)
+	newRecord := func(i int) inferredRecord {
+		rec := inferredRecord{ID: i, Small: int8(-i), Count: math.MaxUint64 - uint64(i), Ratio: float32(i) / 4,
+			Active: i%2 == 0, Created: created.Add(time.Duration(i) * time.Hour),
+			Items:  []inferredItem{{SKU: fmt.Sprint("sku", i), Quantity: uint16(i)}},
+			Labels: map[string]string{"key": fmt.Sprint(i)}, Price: int64(i) * 100, Raw: []byte{byte(i), 0}}
+		if i%3 == 0 {
+			rec.Note = &note
+			payload := inferredPayload(fmt.Sprint(i))
+			rec.Payload = &payload
+		}
+		return rec
+	}
+
+	buf := new(bytes.Buffer)
+	pw, err := NewParquetWriter(writerfile.NewWriterFile(buf), new(inferredRecord), 2, WithSchemaInference())
+	assert.Nil(t, err)
+	for i := 0; i < 100; i++ {
+		assert.Nil(t, pw.Write(newRecord(i)))
+	}
+	assert.Nil(t, pw.WriteStop())
+
+	pr, err := reader.NewParquetReader(buffer.NewBufferFileFromBytes(buf.Bytes()), new(inferredRecord), 1,
+		reader.WithSchemaInference())
+	assert.Nil(t, err)
+	recs := make([]inferredRecord, 100)
+	assert.Nil(t, pr.Read(&recs))
+	for i, rec := range recs {
+		assert.Equal(t, newRecord(i), rec)
+	}
+	pr.ReadStop()
+
+	//the file schema has the inferred names
+	pr, err = reader.NewParquetReader(buffer.NewBufferFileFromBytes(buf.Bytes()), nil, 1)
+	assert.Nil(t, err)
+	names := []string{}
+	for _, info := range pr.SchemaHandler.Infos[1:] {
+		names = append(names, info.ExName)
+	}
+	assert.Equal(t, []string{"id", "small", "count", "ratio", "active", "note", "created", "items", "list", "element",
+		"sku", "quantity", "labels", "key_value", "key", "value", "price", "raw", "payload"}, names)
+	pr.ReadStop()
+
+	//without the inference the tags must set the types
+	_, err = NewParquetWriter(writerfile.NewWriterFile(ioutil.Discard), new(inferredRecord), 1)
+	assert.NotNil(t, err)
+}